@@ -0,0 +1,103 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	DEFAULT_PARALLELISM = 4
+	// PARTIAL_FAILURE_EXIT_CODE is the exit code of a command which has failed for some connectors only.
+	PARTIAL_FAILURE_EXIT_CODE = 2
+)
+
+// exitCoder is implemented by results whose exit code depends on their content.
+type exitCoder interface {
+	ExitCode() int
+}
+
+// Result is the outcome of a command for a single connector.
+type Result struct {
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// Report is the aggregated outcome of a command executed on several connectors.
+type Report struct {
+	Command   string            `json:"command"`
+	Total     int               `json:"total"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   map[string]Result `json:"results"`
+}
+
+// ExitCode returns 0 if the command has succeeded for all connectors, PARTIAL_FAILURE_EXIT_CODE if it has failed
+// for some of them only, or 1 otherwise.
+func (r Report) ExitCode() int {
+	switch {
+	case r.Failed == 0:
+		return 0
+	case r.Succeeded > 0:
+		return PARTIAL_FAILURE_EXIT_CODE
+	}
+	return 1
+}
+
+// executeAll applies a function to each connector, running at most parallelism at once. Unlike a sequential loop,
+// a failure (or a panic of the client, e.g when a worker is unreachable) does not prevent the other connectors
+// from being processed.
+// Return the Report of all executions.
+func executeAll(command string, connectors []string, parallelism int, apply func(conn string) (interface{}, error)) Report {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	report := Report{Command: command, Total: len(connectors), Results: make(map[string]Result)}
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan bool, parallelism)
+	for _, conn := range connectors {
+		wg.Add(1)
+		slots <- true
+		go func(conn string) {
+			defer func() { <-slots }()
+			defer wg.Done()
+			result, err := safeApply(apply, conn)
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				report.Failed++
+				report.Results[conn] = Result{Error: strings.TrimSpace(err.Error())}
+			} else {
+				report.Succeeded++
+				report.Results[conn] = Result{Result: result}
+			}
+		}(conn)
+	}
+	wg.Wait()
+	return report
+}
+
+// safeApply applies a function to a connector, recovering from panics as errors.
+func safeApply(apply func(conn string) (interface{}, error), conn string) (result interface{}, e error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e = fmt.Errorf("%v", r)
+		}
+	}()
+	return apply(conn)
+}
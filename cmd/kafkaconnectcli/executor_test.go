@@ -0,0 +1,84 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExecuteAll(t *testing.T) {
+	report := executeAll("test", []string{"ok", "error", "panic"}, 2, func(conn string) (interface{}, error) {
+		switch conn {
+		case "error":
+			return nil, errors.New("failed ")
+		case "panic":
+			panic("connection refused")
+		}
+		return conn, nil
+	})
+	if report.Total != 3 || report.Succeeded != 1 || report.Failed != 2 {
+		t.Errorf("expected 1 success and 2 failures, got %v", report)
+	}
+	if report.Results["ok"].Result != "ok" {
+		t.Errorf("expected result 'ok', got %v", report.Results["ok"])
+	}
+	if report.Results["error"].Error != "failed" {
+		t.Errorf("expected error 'failed', got %v", report.Results["error"])
+	}
+	if report.Results["panic"].Error != "connection refused" {
+		t.Errorf("expected error 'connection refused', got %v", report.Results["panic"])
+	}
+}
+
+func TestExecuteAllLimitsParallelism(t *testing.T) {
+	var lock sync.Mutex
+	running, max := 0, 0
+	executeAll("test", []string{"a", "b", "c", "d", "e", "f"}, 2, func(conn string) (interface{}, error) {
+		lock.Lock()
+		running++
+		if running > max {
+			max = running
+		}
+		lock.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		lock.Lock()
+		running--
+		lock.Unlock()
+		return nil, nil
+	})
+	if max > 2 {
+		t.Errorf("expected at most 2 concurrent executions, got %d", max)
+	}
+}
+
+func TestReportExitCode(t *testing.T) {
+	tests := []struct {
+		succeeded, failed int
+		expected          int
+	}{
+		{2, 0, 0},
+		{0, 0, 0},
+		{1, 1, PARTIAL_FAILURE_EXIT_CODE},
+		{0, 2, 1},
+	}
+	for _, test := range tests {
+		report := Report{Succeeded: test.succeeded, Failed: test.failed}
+		if code := report.ExitCode(); code != test.expected {
+			t.Errorf("%d succeeded and %d failed: expected exit code %d, got %d", test.succeeded, test.failed, test.expected, code)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"github.com/fhussonnois/kafkacli/connect"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// CAUSED_BY prefixes the causes of a Java exception in a stack trace.
+const CAUSED_BY = "Caused by: "
+
+// FailureCause describes a root-cause exception shared by failed connectors and tasks.
+type FailureCause struct {
+	Exception string `json:"exception"`
+	Message   string `json:"message"`
+	Count     int    `json:"count"`
+	// Instances are the failed connectors and tasks, tasks being named <connector>/task-<id>.
+	Instances []string `json:"instances"`
+}
+
+// FailuresReport groups failed connectors and tasks by root cause, the most frequent first.
+type FailuresReport struct {
+	Failed int            `json:"failed"`
+	Causes []FailureCause `json:"causes"`
+}
+
+// handleFailuresCommand executes "failures" command. If dir is set, the trace of each failed instance is written
+// into this directory.
+// Return a FailuresReport, or nil if it has been printed as a table.
+func handleFailuresCommand(client connect.Client, connector string, dir string, output string) (result interface{}, e error) {
+	connectRegex := regexp.MustCompile(connector)
+	connectors, e := client.ListExpanded(connect.EXPAND_STATUS)
+	if e != nil {
+		return
+	}
+	traces := make(map[string]string)
+	for conn, expanded := range connectors {
+		if !connectRegex.MatchString(conn) {
+			continue
+		}
+		status := expanded.Status
+		if status.Connector.State == "FAILED" {
+			traces[conn] = status.Connector.Trace
+		}
+		for _, task := range status.Tasks {
+			if task.State == "FAILED" {
+				traces[conn+"/task-"+strconv.Itoa(task.ID)] = task.Trace
+			}
+		}
+	}
+
+	report := FailuresReport{Failed: len(traces), Causes: []FailureCause{}}
+	causes := make(map[string]*FailureCause)
+	for instance, trace := range traces {
+		exception, message := rootCause(trace)
+		key := exception + ": " + message
+		if _, ok := causes[key]; !ok {
+			causes[key] = &FailureCause{Exception: exception, Message: message}
+		}
+		causes[key].Count++
+		causes[key].Instances = append(causes[key].Instances, instance)
+	}
+	for _, cause := range causes {
+		sort.Strings(cause.Instances)
+		report.Causes = append(report.Causes, *cause)
+	}
+	sort.Slice(report.Causes, func(i, j int) bool {
+		if report.Causes[i].Count != report.Causes[j].Count {
+			return report.Causes[i].Count > report.Causes[j].Count
+		}
+		return report.Causes[i].Exception+report.Causes[i].Message < report.Causes[j].Exception+report.Causes[j].Message
+	})
+
+	if dir != "" {
+		if e = dumpTraces(dir, traces); e != nil {
+			return
+		}
+	}
+	if output == OUTPUT_TABLE {
+		printFailuresTable(report)
+		return nil, nil
+	}
+	return report, nil
+}
+
+// rootCause returns the class and the message of the innermost cause of a Java stack trace.
+// Return the first line as message if the trace is not a stack trace.
+func rootCause(trace string) (exception string, message string) {
+	lines := strings.Split(strings.TrimSpace(trace), "\n")
+	cause := lines[0]
+	for _, line := range lines[1:] {
+		if strings.HasPrefix(line, CAUSED_BY) {
+			cause = strings.TrimPrefix(line, CAUSED_BY)
+		}
+	}
+	cause = strings.TrimSpace(cause)
+	i := strings.Index(cause, ": ")
+	if i < 0 {
+		i = len(cause)
+	}
+	// Exception classes are qualified names, such as java.lang.NullPointerException or pkg.Outer$Inner.
+	if class := cause[:i]; strings.Contains(class, ".") && !strings.ContainsAny(class, " \t") {
+		return class, strings.TrimSpace(strings.TrimPrefix(cause[i:], ": "))
+	}
+	return "", cause
+}
+
+// dumpTraces writes each trace to <dir>/<instance>.trace, the instance being escaped as a path segment (e.g
+// my-connector%2Ftask-0.trace) so that connector names such as "../x" cannot be written outside the directory.
+func dumpTraces(dir string, traces map[string]string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for instance, trace := range traces {
+		name := url.PathEscape(instance)
+		// Nor written as hidden files.
+		if strings.HasPrefix(name, ".") {
+			name = "%2E" + name[1:]
+		}
+		file := filepath.Join(dir, name+".trace")
+		if err := ioutil.WriteFile(file, []byte(trace), 0644); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d trace(s) written to %s\n", len(traces), dir)
+	return nil
+}
+
+// printFailuresTable prints a FailuresReport as a table.
+func printFailuresTable(report FailuresReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COUNT\tEXCEPTION\tMESSAGE\tINSTANCES")
+	for _, cause := range report.Causes {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", cause.Count, cause.Exception, cause.Message, strings.Join(cause.Instances, ","))
+	}
+	w.Flush()
+}
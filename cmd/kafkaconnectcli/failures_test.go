@@ -0,0 +1,146 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"github.com/fhussonnois/kafkacli/connect/connecttest"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+const TRACE = `org.apache.kafka.connect.errors.ConnectException: Exiting WorkerSinkTask due to unrecoverable exception.
+	at org.apache.kafka.connect.runtime.WorkerSinkTask.deliverMessages(WorkerSinkTask.java:614)
+Caused by: org.apache.kafka.common.errors.SerializationException: Error deserializing key/value
+	at io.confluent.connect.avro.AvroConverter.toConnectData(AvroConverter.java:110)
+Caused by: java.net.ConnectException: Connection refused (Connection refused)
+	... 10 more`
+
+func TestRootCause(t *testing.T) {
+	tests := []struct {
+		trace     string
+		exception string
+		message   string
+	}{
+		{TRACE, "java.net.ConnectException", "Connection refused (Connection refused)"},
+		{"java.lang.NullPointerException\n\tat Main.main(Main.java:1)", "java.lang.NullPointerException", ""},
+		{"pkg.Outer$Inner: failed: twice", "pkg.Outer$Inner", "failed: twice"},
+		{"Tasks failed: no more retries", "", "Tasks failed: no more retries"},
+		{"", "", ""},
+	}
+	for _, test := range tests {
+		exception, message := rootCause(test.trace)
+		if exception != test.exception || message != test.message {
+			t.Errorf("expected (%q, %q), got (%q, %q) for trace %q", test.exception, test.message, exception, message, test.trace)
+		}
+	}
+}
+
+func TestHandleFailuresCommand(t *testing.T) {
+	server, client := newServer(t, "sink-a", "sink-b", "other")
+	defer server.Close()
+	server.SetConnectorState("sink-a", connecttest.FAILED, TRACE)
+	server.SetTaskState("sink-a", 0, connecttest.FAILED, TRACE)
+	server.SetTaskState("sink-b", 1, connecttest.FAILED, "java.lang.NullPointerException")
+	server.SetTaskState("other", 0, connecttest.FAILED, TRACE)
+
+	result, err := handleFailuresCommand(client, "sink-.*", "", OUTPUT_JSON)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	report := result.(FailuresReport)
+	if report.Failed != 3 || len(report.Causes) != 2 {
+		t.Fatalf("expected 3 failures with 2 causes, got %v", report)
+	}
+	first := report.Causes[0]
+	if first.Exception != "java.net.ConnectException" || first.Count != 2 {
+		t.Errorf("expected 2 java.net.ConnectException first, got %v", first)
+	}
+	expected := []string{"sink-a", "sink-a/task-0"}
+	if len(first.Instances) != 2 || first.Instances[0] != expected[0] || first.Instances[1] != expected[1] {
+		t.Errorf("expected instances %v, got %v", expected, first.Instances)
+	}
+	if instances := report.Causes[1].Instances; len(instances) != 1 || instances[0] != "sink-b/task-1" {
+		t.Errorf("expected instances [sink-b/task-1], got %v", instances)
+	}
+}
+
+func TestHandleFailuresCommandNoFailure(t *testing.T) {
+	server, client := newServer(t, "sink")
+	defer server.Close()
+
+	result, err := handleFailuresCommand(client, ".*", "", OUTPUT_JSON)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if report := result.(FailuresReport); report.Failed != 0 || report.Causes == nil {
+		t.Errorf("expected an empty report, got %v", report)
+	}
+}
+
+func TestDumpTracesEscapesInstances(t *testing.T) {
+	dir, err := ioutil.TempDir("", "traces")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "out")
+
+	err = dumpTraces(out, map[string]string{"sink/task-0": "a", "../evil": "b", ".hidden": "c"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	entries, _ := ioutil.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected traces to be written into %s only, got %d entries in %s", out, len(entries), dir)
+	}
+	files := []string{}
+	entries, _ = ioutil.ReadDir(out)
+	for _, entry := range entries {
+		files = append(files, entry.Name())
+	}
+	sort.Strings(files)
+	expected := []string{"%2E.%2Fevil.trace", "%2Ehidden.trace", "sink%2Ftask-0.trace"}
+	if len(files) != len(expected) {
+		t.Fatalf("expected files %v, got %v", expected, files)
+	}
+	for i := range expected {
+		if files[i] != expected[i] {
+			t.Errorf("expected files %v, got %v", expected, files)
+		}
+	}
+	if content, _ := ioutil.ReadFile(filepath.Join(out, "sink%2Ftask-0.trace")); string(content) != "a" {
+		t.Errorf("expected trace 'a', got %q", content)
+	}
+}
+
+func TestHandleFailuresCommandDumpsTraces(t *testing.T) {
+	server, client := newServer(t, "sink")
+	defer server.Close()
+	server.SetTaskState("sink", 1, connecttest.FAILED, TRACE)
+	dir, err := ioutil.TempDir("", "traces")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := handleFailuresCommand(client, ".*", dir, OUTPUT_JSON); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if content, _ := ioutil.ReadFile(filepath.Join(dir, "sink%2Ftask-1.trace")); string(content) != TRACE {
+		t.Errorf("expected the full trace of task 1, got %q", content)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fhussonnois/kafkacli/connect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Health statuses, which are also the exit codes of the "health" command as expected by Nagios and Icinga.
+const (
+	HEALTH_OK       = 0
+	HEALTH_WARNING  = 1
+	HEALTH_CRITICAL = 2
+	HEALTH_UNKNOWN  = 3
+)
+
+// HEALTH_STATUSES are the names of the health statuses, by code.
+var HEALTH_STATUSES = []string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+// HealthThresholds are the thresholds of the "health" command.
+type HealthThresholds struct {
+	// WarnFailedTasks is the number of failed tasks above which the status is WARNING.
+	WarnFailedTasks int
+	// MaxFailedTasks is the number of failed tasks above which the status is CRITICAL. As it prevails over WARNING,
+	// it must be greater than WarnFailedTasks for the WARNING status to be reachable.
+	MaxFailedTasks int
+	// RequireRunning is a regex matching the connectors which must exist and be RUNNING along with all their tasks.
+	RequireRunning string
+}
+
+// HealthReport is the outcome of the "health" command.
+type HealthReport struct {
+	Status           string   `json:"status"`
+	Code             int      `json:"code"`
+	Summary          string   `json:"summary"`
+	Version          string   `json:"version,omitempty"`
+	Connectors       int      `json:"connectors"`
+	Tasks            int      `json:"tasks"`
+	FailedConnectors int      `json:"failed_connectors"`
+	FailedTasks      int      `json:"failed_tasks"`
+	Problems         []string `json:"problems"`
+}
+
+// ExitCode returns the code of the health status.
+func (r HealthReport) ExitCode() int {
+	return r.Code
+}
+
+// String returns the report as a single line, followed by performance data as expected by Nagios and Icinga.
+func (r HealthReport) String() string {
+	return fmt.Sprintf("KAFKA CONNECT %s - %s | connectors=%d tasks=%d failed_connectors=%d failed_tasks=%d",
+		r.Status, r.Summary, r.Connectors, r.Tasks, r.FailedConnectors, r.FailedTasks)
+}
+
+// handleHealthCommand executes "health" command. The status is UNKNOWN if the worker cannot be reached,
+// CRITICAL if a connector is FAILED or if a threshold is exceeded, and WARNING if the warning threshold is exceeded.
+func handleHealthCommand(client connect.Client, thresholds HealthThresholds) (report HealthReport) {
+	report.Problems = []string{}
+	// The client panics when the worker is unreachable.
+	defer func() {
+		if r := recover(); r != nil {
+			report.setStatus(HEALTH_UNKNOWN, fmt.Sprintf("Worker unreachable: %v", r))
+		}
+	}()
+
+	info, e := client.Version()
+	if e != nil {
+		report.setStatus(HEALTH_UNKNOWN, "Failed to get worker version: "+strings.TrimSpace(e.Error()))
+		return
+	}
+	var version struct {
+		Version string `json:"version"`
+	}
+	json.Unmarshal([]byte(info), &version)
+	report.Version = version.Version
+
+	connectors, e := client.ListExpanded(connect.EXPAND_STATUS)
+	if e != nil {
+		report.setStatus(HEALTH_UNKNOWN, "Failed to list connectors: "+strings.TrimSpace(e.Error()))
+		return
+	}
+	names := []string{}
+	for conn := range connectors {
+		names = append(names, conn)
+	}
+	sort.Strings(names)
+
+	code := HEALTH_OK
+	critical := func(format string, args ...interface{}) {
+		code = HEALTH_CRITICAL
+		report.Problems = append(report.Problems, fmt.Sprintf(format, args...))
+	}
+	required := regexp.MustCompile(thresholds.RequireRunning)
+	requiredFound := false
+	for _, conn := range names {
+		status := connectors[conn].Status
+		report.Connectors++
+		if status.Connector.State == "FAILED" {
+			report.FailedConnectors++
+			critical("connector %s is FAILED", conn)
+		} else if thresholds.RequireRunning != "" && required.MatchString(conn) && status.Connector.State != "RUNNING" {
+			critical("connector %s is %s", conn, status.Connector.State)
+		}
+		requiredFound = requiredFound || (thresholds.RequireRunning != "" && required.MatchString(conn))
+		for _, task := range status.Tasks {
+			report.Tasks++
+			if task.State == "FAILED" {
+				report.FailedTasks++
+			}
+			if thresholds.RequireRunning != "" && required.MatchString(conn) && task.State != "RUNNING" {
+				critical("task %s/task-%d is %s", conn, task.ID, task.State)
+			}
+		}
+	}
+	if thresholds.RequireRunning != "" && !requiredFound {
+		critical("no connector matching '%s'", thresholds.RequireRunning)
+	}
+	switch {
+	case report.FailedTasks > thresholds.MaxFailedTasks:
+		critical("%d failed task(s) (critical above %d)", report.FailedTasks, thresholds.MaxFailedTasks)
+	case report.FailedTasks > thresholds.WarnFailedTasks:
+		if code == HEALTH_OK {
+			code = HEALTH_WARNING
+		}
+		report.Problems = append(report.Problems, fmt.Sprintf("%d failed task(s) (warning above %d)", report.FailedTasks, thresholds.WarnFailedTasks))
+	}
+
+	summary := fmt.Sprintf("%d connector(s) and %d task(s) on worker version %s", report.Connectors, report.Tasks, report.Version)
+	if len(report.Problems) > 0 {
+		summary = strings.Join(report.Problems, ", ")
+	}
+	report.setStatus(code, summary)
+	return
+}
+
+func (r *HealthReport) setStatus(code int, summary string) {
+	r.Code, r.Status, r.Summary = code, HEALTH_STATUSES[code], summary
+	if code == HEALTH_UNKNOWN {
+		r.Problems = append(r.Problems, summary)
+	}
+}
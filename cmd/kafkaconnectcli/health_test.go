@@ -0,0 +1,77 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"github.com/fhussonnois/kafkacli/connect/connecttest"
+	"testing"
+)
+
+func TestHandleHealthCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		thresholds HealthThresholds
+		setup      func(server *connecttest.Server)
+		expected   int
+	}{
+		{"healthy", HealthThresholds{MaxFailedTasks: 5}, func(server *connecttest.Server) {}, HEALTH_OK},
+		{"failed tasks below warning", HealthThresholds{WarnFailedTasks: 1, MaxFailedTasks: 5}, func(server *connecttest.Server) {
+			server.SetTaskState("sink-a", 0, connecttest.FAILED, "")
+		}, HEALTH_OK},
+		{"failed tasks above warning", HealthThresholds{MaxFailedTasks: 5}, func(server *connecttest.Server) {
+			server.SetTaskState("sink-a", 0, connecttest.FAILED, "")
+		}, HEALTH_WARNING},
+		{"failed tasks above critical", HealthThresholds{MaxFailedTasks: 1}, func(server *connecttest.Server) {
+			server.SetTaskState("sink-a", 0, connecttest.FAILED, "")
+			server.SetTaskState("sink-b", 1, connecttest.FAILED, "")
+		}, HEALTH_CRITICAL},
+		{"failed connector", HealthThresholds{MaxFailedTasks: 5}, func(server *connecttest.Server) {
+			server.SetConnectorState("sink-b", connecttest.FAILED, "")
+		}, HEALTH_CRITICAL},
+		{"required connector paused", HealthThresholds{MaxFailedTasks: 5, RequireRunning: "sink-a"}, func(server *connecttest.Server) {
+			server.Client().Pause("sink-a")
+		}, HEALTH_CRITICAL},
+		{"required connector running", HealthThresholds{MaxFailedTasks: 5, RequireRunning: "sink-a"}, func(server *connecttest.Server) {
+			server.Client().Pause("sink-b")
+		}, HEALTH_OK},
+		{"required connector missing", HealthThresholds{MaxFailedTasks: 5, RequireRunning: "source"}, func(server *connecttest.Server) {}, HEALTH_CRITICAL},
+		{"worker unreachable", HealthThresholds{MaxFailedTasks: 5}, func(server *connecttest.Server) {
+			server.Close()
+		}, HEALTH_UNKNOWN},
+	}
+	for _, test := range tests {
+		server, client := newServer(t, "sink-a", "sink-b")
+		test.setup(server)
+		report := handleHealthCommand(client, test.thresholds)
+		if report.ExitCode() != test.expected {
+			t.Errorf("%s: expected status %s, got %s", test.name, HEALTH_STATUSES[test.expected], report)
+		}
+		if report.Code != HEALTH_OK && len(report.Problems) == 0 {
+			t.Errorf("%s: expected problems to be reported, got %s", test.name, report)
+		}
+		server.Close()
+	}
+}
+
+func TestHandleHealthCommandNamesTasksLikeFailures(t *testing.T) {
+	server, client := newServer(t, "sink-a")
+	defer server.Close()
+	server.SetTaskState("sink-a", 1, connecttest.FAILED, "")
+
+	report := handleHealthCommand(client, HealthThresholds{MaxFailedTasks: 5, RequireRunning: "sink-a"})
+	expected := "task sink-a/task-1 is FAILED, 1 failed task(s) (warning above 0)"
+	if report.Code != HEALTH_CRITICAL || report.Summary != expected {
+		t.Errorf("expected CRITICAL status with summary %q, got %s", expected, report)
+	}
+}
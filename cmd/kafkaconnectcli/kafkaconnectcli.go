@@ -15,6 +15,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/fhussonnois/kafkacli/connect"
@@ -25,30 +26,48 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var Commands = map[string]string{
-	"list":           "Listing active connectors on a worker.",
-	"config":         "Getting connector configuration.",
-	"create":         "Creating a new connector.",
-	"delete":         "Deleting a connector.",
-	"delete-all":     "eleting all connectors.",
-	"pause":          "Pausing a connector (useful if downtime is needed for the system the connector interacts with).",
-	"plugins":        "Listing installed connectors plugins.",
-	"resume":         "Restarting a connector.",
-	"restart-failed": "Restarting failed tasks for a connector.",
-	"status":         "Getting connector status.",
-	"tasks":          "Getting tasks for a connector.",
-	"scale":          "Scaling up/down the number of tasks for a connector.",
-	"update":         "Updating connector configuration.",
-	"version":        "Getting a connect worker version.",
+	"list":            "Listing active connectors on a worker.",
+	"config":          "Getting connector configuration.",
+	"create":          "Creating a new connector.",
+	"delete":          "Deleting a connector.",
+	"failures":        "Summarizing the root causes of failed connectors and tasks, optionally dumping their traces to files.",
+	"health":          "Checking the health of a worker and its connectors, with Nagios exit codes (0: OK, 1: WARNING, 2: CRITICAL, 3: UNKNOWN).",
+	"delete-all":      "eleting all connectors.",
+	"pause":           "Pausing a connector (useful if downtime is needed for the system the connector interacts with).",
+	"loggers":         "Listing (list), getting (get) or setting (set) the log levels of workers, optionally reverted after a duration.",
+	"offsets":         "Getting (get), altering (set) or resetting (reset) the offsets of a stopped connector (requires Kafka Connect 3.6 or later).",
+	"plugins":         "Listing installed connectors plugins.",
+	"resume":          "Restarting a connector.",
+	"reset-topics":    "Resetting the set of active topics of a connector.",
+	"restart":         "Restarting a connector and optionally its tasks.",
+	"restart-failed":  "Restarting failed tasks for a connector.",
+	"rolling-restart": "Restarting connectors and their tasks by batches, waiting for each batch to be running.",
+	"serve-mock":      "Serving an in-memory mock worker emulating the Kafka Connect REST API, with failure injection.",
+	"status":          "Getting connector status.",
+	"stop":            "Stopping a connector and shutting down its tasks (requires Kafka Connect 3.5 or later).",
+	"tasks":           "Getting tasks for a connector.",
+	"topics":          "Getting the topics used by connectors, or the connectors using each topic (-by-topic).",
+	"scale":           "Scaling up/down the number of tasks for a connector.",
+	"update":          "Updating connector configuration.",
+	"version":         "Getting a connect worker version.",
+	"workers":         "Getting the number of connectors, tasks and failed tasks by worker, and how evenly tasks are spread.",
+}
+
+// SubCommands lists the actions accepted by commands of the form "command action [arguments]".
+var SubCommands = map[string][]string{
+	"loggers": {"list", "get", "set"},
+	"offsets": {"get", "set", "reset"},
 }
 
 // Display commands usage and exit with return code 1.
 func usage() {
-	fmt.Println("A simple Command line interface (CLI) to manage connectors through the Kafka Connect REST Interface.\n")
+	fmt.Print("A simple Command line interface (CLI) to manage connectors through the Kafka Connect REST Interface.\n\n")
 	fmt.Fprintf(os.Stdin, "Usage of %s: command [arguments] \n", os.Args[0])
-	fmt.Println("The commands are : \n")
+	fmt.Print("The commands are : \n\n")
 	keys := []string{}
 	for k := range Commands {
 		keys = append(keys, k)
@@ -69,15 +88,39 @@ const (
 )
 
 type CommandArgs struct {
-	host      *string
-	port      *int
-	pretty    *bool
-	connector *string
-	state     *string
-	json      *string
-	jsonFile  *string
-	propsFile *string
-	tasks     *int
+	host           *string
+	port           *int
+	pretty         *bool
+	connector      *string
+	state          *string
+	json           *string
+	jsonFile       *string
+	propsFile      *string
+	tasks          *int
+	listen         *string
+	includeTasks   *bool
+	onlyFailed     *bool
+	offsetsFile    *string
+	topic          *string
+	partition      *int
+	offset         *int64
+	backup         *string
+	byTopic        *bool
+	workers        *string
+	logger         *string
+	level          *string
+	scope          *string
+	revertAfter    *time.Duration
+	parallelism    *int
+	batch          *int
+	maxUnavailable *int
+	timeout        *time.Duration
+	clusterSize    *int
+	output         *string
+	dump           *string
+	warnFailed     *int
+	maxFailed      *int
+	requireRunning *string
 }
 
 type Validator struct {
@@ -134,8 +177,16 @@ func (p *ArgParser) withConnectorArg() *ArgParser {
 	p.addValidators(Validator{message: "Missing or invalid argument 'connector'", apply: apply})
 	return p
 }
+func (p *ArgParser) withOptionalConnectorArg() *ArgParser {
+	p.Args.connector = p.Flag.String("connector", "", "The connector name or a regex. (default all connectors)")
+	return p
+}
+func (p *ArgParser) withByTopicArg() *ArgParser {
+	p.Args.byTopic = p.Flag.Bool("by-topic", false, "Group connectors by the topics they use.")
+	return p
+}
 func (p *ArgParser) withStateArg() *ArgParser {
-	p.Args.state = p.Flag.String("with-state", "", "Filter on connector/task for the specified state [running|failed|paused|unassigned]")
+	p.Args.state = p.Flag.String("with-state", "", "Filter on connector/task for the specified state [running|failed|paused|stopped|unassigned]")
 	return p
 }
 func (p *ArgParser) withConfigArg() *ArgParser {
@@ -153,6 +204,106 @@ func (p *ArgParser) withTasksMaxArg() *ArgParser {
 	p.addValidators(Validator{message: "Missing or invalid argument 'tasks-max'", apply: apply})
 	return p
 }
+func (p *ArgParser) withRestartArg() *ArgParser {
+	p.Args.includeTasks = p.Flag.Bool("include-tasks", false, "Restart the connector tasks along with the connector.")
+	p.Args.onlyFailed = p.Flag.Bool("only-failed", false, "Only restart the connector and tasks which are in the FAILED state.")
+	return p
+}
+func (p *ArgParser) withOffsetsArg() *ArgParser {
+	p.Args.offsetsFile = p.Flag.String("offsets.json", "", "<file> The offsets json file, e.g a backup file. (Required, unless -topic is set)")
+	p.Args.topic = p.Flag.String("topic", "", "The topic of the sink connector offset to set. (Required, unless -offsets.json is set)")
+	p.Args.partition = p.Flag.Int("partition", 0, "The partition of the sink connector offset to set.")
+	p.Args.offset = p.Flag.Int64("offset", 0, "The sink connector offset to set, i.e the offset of the next record to consume.")
+
+	apply := func(args CommandArgs) bool { return (*args.offsetsFile != "") != (*args.topic != "") }
+	p.addValidators(Validator{message: "Missing or invalid arguments [offsets.json | topic]", apply: apply})
+	return p
+}
+func (p *ArgParser) withBackupArg() *ArgParser {
+	p.Args.backup = p.Flag.String("backup", "", "<file> The file to back up current offsets to. (default \"<connector>-offsets-<timestamp>.json\")")
+	return p
+}
+func (p *ArgParser) withWorkersArg() *ArgParser {
+	p.Args.workers = p.Flag.String("workers", "", "The comma-separated list of workers <host>:<port>. (default -host and -port)")
+	return p
+}
+func (p *ArgParser) withLoggerArg() *ArgParser {
+	p.Args.logger = p.Flag.String("logger", "", "The logger name, e.g a connector class or 'root'. (Required)")
+	apply := func(args CommandArgs) bool { return *args.logger != "" }
+	p.addValidators(Validator{message: "Missing or invalid argument 'logger'", apply: apply})
+	return p
+}
+func (p *ArgParser) withLevelArg() *ArgParser {
+	p.Args.level = p.Flag.String("level", "", "The log level to set ["+strings.ToLower(strings.Join(LOG_LEVELS, "|"))+"]. (Required)")
+	p.Args.scope = p.Flag.String("scope", connect.SCOPE_WORKER, "The scope of the change [worker|cluster], cluster requires Kafka Connect 3.7 or later.")
+	p.Args.revertAfter = p.Flag.Duration("revert-after", 0, "The duration after which previous levels are set back, e.g 10m.")
+
+	apply := func(args CommandArgs) bool {
+		for _, level := range LOG_LEVELS {
+			if strings.ToUpper(*args.level) == level {
+				return true
+			}
+		}
+		return false
+	}
+	p.addValidators(Validator{message: "Missing or invalid argument 'level'", apply: apply})
+	apply = func(args CommandArgs) bool {
+		return *args.scope == connect.SCOPE_WORKER || *args.scope == connect.SCOPE_CLUSTER
+	}
+	p.addValidators(Validator{message: "Invalid argument 'scope'", apply: apply})
+	return p
+}
+func (p *ArgParser) withParallelismArg() *ArgParser {
+	p.Args.parallelism = p.Flag.Int("parallelism", DEFAULT_PARALLELISM, "The maximum number of connectors processed concurrently.")
+	apply := func(args CommandArgs) bool { return *args.parallelism > 0 }
+	p.addValidators(Validator{message: "Invalid argument 'parallelism'", apply: apply})
+	return p
+}
+func (p *ArgParser) withRollingRestartArg() *ArgParser {
+	p.Args.batch = p.Flag.Int("batch", 5, "The number of connectors restarted together.")
+	p.Args.maxUnavailable = p.Flag.Int("max-unavailable", 5, "The maximum number of connectors unavailable at once, either being restarted or failed to get back to RUNNING.")
+	p.Args.timeout = p.Flag.Duration("timeout", 5*time.Minute, "The maximum duration to wait for a batch to get back to RUNNING.")
+
+	apply := func(args CommandArgs) bool { return *args.batch > 0 && *args.maxUnavailable > 0 && *args.timeout > 0 }
+	p.addValidators(Validator{message: "Invalid arguments [batch | max-unavailable | timeout]", apply: apply})
+	return p
+}
+func (p *ArgParser) withOutputArg(formats ...string) *ArgParser {
+	p.Args.output = p.Flag.String("output", formats[0], "The output format ["+strings.Join(formats, "|")+"].")
+	apply := func(args CommandArgs) bool {
+		for _, format := range formats {
+			if *args.output == format {
+				return true
+			}
+		}
+		return false
+	}
+	p.addValidators(Validator{message: "Invalid argument 'output'", apply: apply})
+	return p
+}
+func (p *ArgParser) withDumpArg() *ArgParser {
+	p.Args.dump = p.Flag.String("dump", "", "<dir> The directory to write the full trace of each failed connector and task to.")
+	return p
+}
+func (p *ArgParser) withHealthArg() *ArgParser {
+	p.Args.warnFailed = p.Flag.Int("warn-failed-tasks", 0, "The number of failed tasks above which the status is WARNING, unless CRITICAL.")
+	p.Args.maxFailed = p.Flag.Int("max-failed-tasks", 5, "The number of failed tasks above which the status is CRITICAL, must not be lower than -warn-failed-tasks.")
+	p.Args.requireRunning = p.Flag.String("require-running", "", "The regex of the connectors which must exist and be running, along with their tasks.")
+
+	apply := func(args CommandArgs) bool { return *args.warnFailed >= 0 && *args.maxFailed >= *args.warnFailed }
+	p.addValidators(Validator{message: "Invalid arguments [warn-failed-tasks | max-failed-tasks]", apply: apply})
+	apply = func(args CommandArgs) bool {
+		_, err := regexp.Compile(*args.requireRunning)
+		return err == nil
+	}
+	p.addValidators(Validator{message: "Invalid argument 'require-running'", apply: apply})
+	return p
+}
+func (p *ArgParser) withListenArg() *ArgParser {
+	p.Args.listen = p.Flag.String("listen", ":"+DEFAULT_PORT, "The address to listen on.")
+	p.Args.clusterSize = p.Flag.Int("cluster-size", 1, "The number of workers of the emulated cluster, over which connectors and tasks are spread.")
+	return p
+}
 func (p *ArgParser) parse(args []string) CommandArgs {
 	p.Flag.Parse(args)
 	return p.Args
@@ -174,7 +325,10 @@ func main() {
 	CommonArgParser.withCommonArgs()
 
 	ConnectorArgParser := NewArgParser("ConnectorArgParser")
-	ConnectorArgParser.withCommonArgs().withConnectorArg()
+	ConnectorArgParser.withCommonArgs().withConnectorArg().withParallelismArg()
+
+	DeleteAllArgParser := NewArgParser("DeleteAllArgParser")
+	DeleteAllArgParser.withCommonArgs().withParallelismArg()
 
 	ListArgParser := NewArgParser("ListArgParser")
 	ListArgParser.withCommonArgs().withStateArg()
@@ -188,14 +342,67 @@ func main() {
 	ScaleArgParser := NewArgParser("ScaleArgParser")
 	ScaleArgParser.withCommonArgs().withConnectorArg().withTasksMaxArg()
 
-	command := os.Args[1]
+	RestartArgParser := NewArgParser("RestartArgParser")
+	RestartArgParser.withCommonArgs().withConnectorArg().withRestartArg().withParallelismArg()
+
+	OffsetsArgParser := NewArgParser("OffsetsArgParser")
+	OffsetsArgParser.withCommonArgs().withConnectorArg()
+
+	SetOffsetsArgParser := NewArgParser("SetOffsetsArgParser")
+	SetOffsetsArgParser.withCommonArgs().withConnectorArg().withOffsetsArg().withBackupArg()
+
+	ResetOffsetsArgParser := NewArgParser("ResetOffsetsArgParser")
+	ResetOffsetsArgParser.withCommonArgs().withConnectorArg().withBackupArg()
+
+	TopicsArgParser := NewArgParser("TopicsArgParser")
+	TopicsArgParser.withCommonArgs().withOptionalConnectorArg().withByTopicArg()
+
+	LoggersArgParser := NewArgParser("LoggersArgParser")
+	LoggersArgParser.withCommonArgs().withWorkersArg()
+
+	LoggerArgParser := NewArgParser("LoggerArgParser")
+	LoggerArgParser.withCommonArgs().withWorkersArg().withLoggerArg()
+
+	SetLoggerArgParser := NewArgParser("SetLoggerArgParser")
+	SetLoggerArgParser.withCommonArgs().withWorkersArg().withLoggerArg().withLevelArg()
+
+	RollingRestartArgParser := NewArgParser("RollingRestartArgParser")
+	RollingRestartArgParser.withCommonArgs().withConnectorArg().withRollingRestartArg()
+
+	WorkersArgParser := NewArgParser("WorkersArgParser")
+	WorkersArgParser.withCommonArgs().withOptionalConnectorArg().withOutputArg(OUTPUT_JSON, OUTPUT_TABLE)
+
+	FailuresArgParser := NewArgParser("FailuresArgParser")
+	FailuresArgParser.withCommonArgs().withOptionalConnectorArg().withDumpArg().withOutputArg(OUTPUT_JSON, OUTPUT_TABLE)
+
+	HealthArgParser := NewArgParser("HealthArgParser")
+	HealthArgParser.withCommonArgs().withHealthArg().withOutputArg(OUTPUT_TEXT, OUTPUT_JSON)
+
+	ServeMockArgParser := NewArgParser("ServeMockArgParser")
+	ServeMockArgParser.withPrettyArg().withListenArg()
+
+	command, commandArgs := resolveCommand(os.Args[1:])
 	var commandArgParser ArgParser
 	switch command {
-	case "config", "status", "delete", "resume", "pause", "tasks", "restart-failed":
+	case "config", "status", "delete", "resume", "pause", "stop", "tasks", "restart-failed", "reset-topics":
 		commandArgParser = ConnectorArgParser
+	case "topics":
+		commandArgParser = TopicsArgParser
+	case "workers":
+		commandArgParser = WorkersArgParser
+	case "failures":
+		commandArgParser = FailuresArgParser
+	case "health":
+		commandArgParser = HealthArgParser
+	case "restart":
+		commandArgParser = RestartArgParser
+	case "rolling-restart":
+		commandArgParser = RollingRestartArgParser
 	case "list":
 		commandArgParser = ListArgParser
-	case "delete-all", "plugins", "version":
+	case "delete-all":
+		commandArgParser = DeleteAllArgParser
+	case "plugins", "version":
 		commandArgParser = CommonArgParser
 	case "create":
 		commandArgParser = CreateArgParser
@@ -203,6 +410,20 @@ func main() {
 		commandArgParser = UpdateArgParser
 	case "scale":
 		commandArgParser = ScaleArgParser
+	case "loggers list":
+		commandArgParser = LoggersArgParser
+	case "loggers get":
+		commandArgParser = LoggerArgParser
+	case "loggers set":
+		commandArgParser = SetLoggerArgParser
+	case "offsets get":
+		commandArgParser = OffsetsArgParser
+	case "offsets set":
+		commandArgParser = SetOffsetsArgParser
+	case "offsets reset":
+		commandArgParser = ResetOffsetsArgParser
+	case "serve-mock":
+		commandArgParser = ServeMockArgParser
 	case "help":
 		if len(os.Args) < 3 {
 			usage()
@@ -210,8 +431,20 @@ func main() {
 		subCommand := os.Args[2]
 		fmt.Printf("Usage of %s: %s\nThe arguments are :\n", subCommand, Commands[subCommand])
 		switch subCommand {
-		case "config", "status", "delete", "resume", "pause", "tasks", "restart-failed":
+		case "config", "status", "delete", "resume", "pause", "stop", "tasks", "restart-failed", "reset-topics":
 			ConnectorArgParser.Flag.PrintDefaults()
+		case "topics":
+			TopicsArgParser.Flag.PrintDefaults()
+		case "workers":
+			WorkersArgParser.Flag.PrintDefaults()
+		case "failures":
+			FailuresArgParser.Flag.PrintDefaults()
+		case "health":
+			HealthArgParser.Flag.PrintDefaults()
+		case "restart":
+			RestartArgParser.Flag.PrintDefaults()
+		case "rolling-restart":
+			RollingRestartArgParser.Flag.PrintDefaults()
 		case "create":
 			CreateArgParser.Flag.PrintDefaults()
 		case "scale":
@@ -220,7 +453,25 @@ func main() {
 			UpdateArgParser.Flag.PrintDefaults()
 		case "list":
 			ListArgParser.Flag.PrintDefaults()
-		case "delete-all", "plugins", "version":
+		case "loggers":
+			fmt.Println("\nThe arguments of 'loggers list' are :")
+			LoggersArgParser.Flag.PrintDefaults()
+			fmt.Println("\nThe arguments of 'loggers get' are :")
+			LoggerArgParser.Flag.PrintDefaults()
+			fmt.Println("\nThe arguments of 'loggers set' are :")
+			SetLoggerArgParser.Flag.PrintDefaults()
+		case "offsets":
+			fmt.Println("\nThe arguments of 'offsets get' are :")
+			OffsetsArgParser.Flag.PrintDefaults()
+			fmt.Println("\nThe arguments of 'offsets set' are :")
+			SetOffsetsArgParser.Flag.PrintDefaults()
+			fmt.Println("\nThe arguments of 'offsets reset' are :")
+			ResetOffsetsArgParser.Flag.PrintDefaults()
+		case "serve-mock":
+			ServeMockArgParser.Flag.PrintDefaults()
+		case "delete-all":
+			DeleteAllArgParser.Flag.PrintDefaults()
+		case "plugins", "version":
 			CommonArgParser.Flag.PrintDefaults()
 		default:
 			fmt.Fprint(os.Stderr, "Unknown help command `"+subCommand+"`.  Run '"+os.Args[0]+" help'.\n")
@@ -230,16 +481,65 @@ func main() {
 		usage()
 	}
 
-	args := commandArgParser.parse(os.Args[2:])
+	args := commandArgParser.parse(commandArgs)
 	commandArgParser.Validates()
 
-	client := connect.NewConnectClient(*args.host, *args.port)
+	if ServeMockArgParser.Flag.Parsed() {
+		printOutputAndExit(nil, handleServeMockCommand(*args.listen, *args.clusterSize), *args.pretty)
+		os.Exit(1)
+	}
+
+	restClient := connect.NewConnectClient(*args.host, *args.port)
+	var client connect.Client = &restClient
+
+	if HealthArgParser.Flag.Parsed() {
+		thresholds := HealthThresholds{WarnFailedTasks: *args.warnFailed, MaxFailedTasks: *args.maxFailed, RequireRunning: *args.requireRunning}
+		report := handleHealthCommand(client, thresholds)
+		if *args.output == OUTPUT_TEXT {
+			fmt.Println(report)
+			os.Exit(report.ExitCode())
+		}
+		printOutputAndExit(report, nil, *args.pretty)
+	}
 
 	var err error
 	var result interface{}
 
 	if ConnectorArgParser.Flag.Parsed() {
-		result, err = handleConnectorCommands(client, command, *args.connector)
+		result, err = handleConnectorCommands(client, command, *args.connector, *args.parallelism)
+	}
+
+	if RollingRestartArgParser.Flag.Parsed() {
+		result, err = handleRollingRestartCommand(client, *args.connector, *args.batch, *args.maxUnavailable, *args.timeout)
+	}
+
+	if DeleteAllArgParser.Flag.Parsed() {
+		result, err = handleDeleteAllCommand(client, *args.parallelism)
+	}
+
+	if RestartArgParser.Flag.Parsed() {
+		options := connect.RestartOptions{IncludeTasks: *args.includeTasks, OnlyFailed: *args.onlyFailed}
+		result, err = handleRestartCommand(client, *args.connector, options, *args.parallelism)
+	}
+
+	if OffsetsArgParser.Flag.Parsed() || SetOffsetsArgParser.Flag.Parsed() || ResetOffsetsArgParser.Flag.Parsed() {
+		result, err = handleOffsetsCommand(client, command, args)
+	}
+
+	if LoggersArgParser.Flag.Parsed() || LoggerArgParser.Flag.Parsed() || SetLoggerArgParser.Flag.Parsed() {
+		result, err = handleLoggersCommand(client, command, args)
+	}
+
+	if WorkersArgParser.Flag.Parsed() {
+		result, err = handleWorkersCommand(client, *args.connector, *args.output)
+	}
+
+	if FailuresArgParser.Flag.Parsed() {
+		result, err = handleFailuresCommand(client, *args.connector, *args.dump, *args.output)
+	}
+
+	if TopicsArgParser.Flag.Parsed() {
+		result, err = handleTopicsCommand(client, *args.connector, *args.byTopic)
 	}
 
 	if ListArgParser.Flag.Parsed() {
@@ -265,65 +565,167 @@ func main() {
 }
 
 // handleConnectorCommands executes all connectors commands.
-func handleConnectorCommands(client connect.ConnectRestClient, command string, connector string) (result interface{}, e error) {
+// Statuses and configurations of connectors are listed in a single request, see connectorExpansions.
+// Commands are executed concurrently, and return a Report of the results and errors by connector.
+// For backward compatibility, "config", "status" and "tasks" return the result as is if a single connector matches.
+func handleConnectorCommands(client connect.Client, command string, connector string, parallelism int) (result interface{}, e error) {
+	connectRegex := regexp.MustCompile(connector)
+	connectors, e := client.ListExpanded(connectorExpansions(command)...)
+	if e != nil {
+		return
+	}
+	matches := []string{}
+	for conn := range connectors {
+		if connectRegex.MatchString(conn) {
+			matches = append(matches, conn)
+		}
+	}
+	sort.Strings(matches)
+	if len(matches) == 0 {
+		fmt.Fprintf(os.Stdin, "No matching connector found for '%s' \n", connector)
+	}
+	report := executeAll(command, matches, parallelism, func(conn string) (interface{}, error) {
+		var err error
+		switch command {
+		case "config":
+			return *connectors[conn].Info, nil
+		case "status":
+			return *connectors[conn].Status, nil
+		case "tasks":
+			tasks, err := client.Tasks(conn)
+			return json.RawMessage(tasks), err
+		case "delete":
+			return deleteConnector(client, *connectors[conn].Info)
+		case "resume":
+			err = client.Resume(conn)
+			if err == nil {
+				fmt.Fprintf(os.Stdin, "Successfully resumed connector %s \n", conn)
+			}
+		case "pause":
+			err = client.Pause(conn)
+			if err == nil {
+				fmt.Fprintf(os.Stdin, "Successfully paused connector %s \n", conn)
+			}
+		case "stop":
+			err = client.Stop(conn)
+			if err == nil {
+				fmt.Fprintf(os.Stdin, "Successfully stopped connector %s \n", conn)
+			}
+		case "reset-topics":
+			err = client.ResetTopics(conn)
+			if err == nil {
+				fmt.Fprintf(os.Stdin, "Successfully reset active topics of connector %s \n", conn)
+			}
+		case "restart-failed":
+			return restartFailedTasks(client, *connectors[conn].Status)
+		}
+		return nil, err
+	})
+	switch command {
+	case "config", "status", "tasks":
+		if len(matches) == 1 {
+			single := report.Results[matches[0]]
+			if single.Error != "" {
+				return nil, errors.New(single.Error)
+			}
+			return single.Result, nil
+		}
+	}
+	return report, nil
+}
+
+// restartFailedTasks restarts all the failed tasks of a connector, even if some restarts fail.
+// Return the IDs of the restarted tasks.
+func restartFailedTasks(client connect.Client, status connect.ConnectorStatus) (restarted []int, e error) {
+	var errs []string
+	for _, task := range status.Tasks {
+		if task.State == "FAILED" {
+			if err := client.Restart(status.Name, task.ID); err != nil {
+				errs = append(errs, fmt.Sprintf("task %d: %v", task.ID, err))
+			} else {
+				restarted = append(restarted, task.ID)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		e = fmt.Errorf("Failed to restart %s", strings.Join(errs, ", "))
+	}
+	return
+}
+
+// connectorExpansions returns the expansions of the connectors listing needed by a connector command.
+func connectorExpansions(command string) []string {
+	switch command {
+	case "config", "delete":
+		return []string{connect.EXPAND_INFO}
+	case "status", "restart-failed":
+		return []string{connect.EXPAND_STATUS}
+	}
+	return nil
+}
+
+// handleRestartCommand executes "restart" command on all connectors matching the specified regex.
+// Return a Report, with the status of each restarted connector if the worker returns one.
+func handleRestartCommand(client connect.Client, connector string, options connect.RestartOptions, parallelism int) (result interface{}, e error) {
 	connectRegex := regexp.MustCompile(connector)
 	matches, e := findMatchingConnectors(client, func(conn string) (bool, error) { return connectRegex.MatchString(conn), nil })
 	if e == nil {
 		if len(matches) == 0 {
 			fmt.Fprintf(os.Stdin, "No matching connector found for '%s' \n", connector)
 		}
-		for _, conn := range matches {
-			switch command {
-			case "config":
-				result, e = client.GetConfig(conn)
-			case "status":
-				result, e = client.Status(conn)
-			case "delete":
-				e = deleteConnector(client, conn)
-			case "resume":
-				e = client.Resume(conn)
-				if e == nil {
-					fmt.Fprintf(os.Stdin, "Successfully resumed connector %s \n", conn)
-				}
-			case "pause":
-				e = client.Pause(conn)
-				if e == nil {
-					fmt.Fprintf(os.Stdin, "Successfully paused connector %s \n", conn)
-				}
-			case "tasks":
-				result, e = client.Tasks(conn)
-			case "restart-failed":
-				status, e := client.Status(conn)
-				if e == nil {
-					for _, task := range status.Tasks {
-						if task.State == "FAILED" {
-							client.Restart(status.Name, task.ID)
-						}
-					}
-				}
+		result = executeAll("restart", matches, parallelism, func(conn string) (interface{}, error) {
+			status, err := client.RestartConnector(conn, options)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(os.Stdin, "Successfully restarted connector %s \n", conn)
+			// Workers only return the connector status when restart options are set.
+			if status.Name == "" {
+				return nil, nil
 			}
+			return status, nil
+		})
+	}
+	return
+}
+
+// handleDeleteAllCommand executes "delete-all" command.
+// Return a Report.
+func handleDeleteAllCommand(client connect.Client, parallelism int) (result interface{}, e error) {
+	connectors, e := client.ListExpanded(connect.EXPAND_INFO)
+	if e == nil {
+		names := []string{}
+		for conn := range connectors {
+			names = append(names, conn)
 		}
+		result = executeAll("delete-all", names, parallelism, func(conn string) (interface{}, error) {
+			return deleteConnector(client, *connectors[conn].Info)
+		})
 	}
 	return
 }
 
 // handleListCommand executes "list" command.
-func handleListCommand(client connect.ConnectRestClient, state string) (result interface{}, e error) {
+func handleListCommand(client connect.Client, state string) (result interface{}, e error) {
 	state = strings.ToUpper(state)
 	switch state {
-	case "RUNNING", "FAILED", "PAUSED", "UNASSIGNED":
-		result, e = findMatchingConnectors(client, func(conn string) (bool, error) {
-			status, e := client.Status(conn)
-			if e != nil {
-				return false, e
-			}
-
-			res := status.Connector.State == state
-			for _, task := range status.Tasks {
+	case "RUNNING", "FAILED", "PAUSED", "STOPPED", "UNASSIGNED":
+		connectors, err := client.ListExpanded(connect.EXPAND_STATUS)
+		if err != nil {
+			return nil, err
+		}
+		matches := []string{}
+		for conn, expanded := range connectors {
+			res := expanded.Status.Connector.State == state
+			for _, task := range expanded.Status.Tasks {
 				res = res || task.State == state
 			}
-			return res, nil
-		})
+			if res {
+				matches = append(matches, conn)
+			}
+		}
+		sort.Strings(matches)
+		result = matches
 	default:
 		result, e = client.List()
 	}
@@ -331,20 +733,20 @@ func handleListCommand(client connect.ConnectRestClient, state string) (result i
 }
 
 // handleCreateCommand executes "create" command.
-func handleCreateCommand(client connect.ConnectRestClient, args CommandArgs) (result interface{}, e error) {
+func handleCreateCommand(client connect.Client, args CommandArgs) (result interface{}, e error) {
 	result, e = client.Create(readConnectorConfig(args))
 	return
 }
 
 // handleUpdateCommand executes "update" command.
-func handleUpdateCommand(client connect.ConnectRestClient, args CommandArgs) (result interface{}, e error) {
+func handleUpdateCommand(client connect.Client, args CommandArgs) (result interface{}, e error) {
 	config := readConnectorConfig(args)
 	result, e = client.Update(connect.ConnectorConfig{Name: *args.connector, Config: config.Config})
 	return
 }
 
 // handleScaleCommand executes "scale" command.
-func handleScaleCommand(client connect.ConnectRestClient, connector string, tasks int) (result interface{}, e error) {
+func handleScaleCommand(client connect.Client, connector string, tasks int) (result interface{}, e error) {
 	config, err := client.GetConfig(connector)
 	if err != nil {
 		return nil, err
@@ -354,23 +756,13 @@ func handleScaleCommand(client connect.ConnectRestClient, connector string, task
 	return
 }
 
-// handleCommonsCommand executes either "version", "plugin" or "delete-all" commands.
-func handleCommonsCommand(command string, client connect.ConnectRestClient) (result interface{}, e error) {
+// handleCommonsCommand executes either "version" or "plugin" commands.
+func handleCommonsCommand(command string, client connect.Client) (result interface{}, e error) {
 	switch command {
 	case "version":
 		result, e = client.Version()
 	case "plugins":
 		result, e = client.Plugins()
-	case "delete-all":
-		connectors, e := client.List()
-		if e == nil {
-			for _, conn := range connectors {
-				e = deleteConnector(client, conn)
-				if e != nil {
-					break
-				}
-			}
-		}
 	}
 	return
 }
@@ -418,24 +810,21 @@ func readConnectorConfig(args CommandArgs) (config connect.ConnectorConfig) {
 	return
 }
 
-func deleteConnector(client connect.ConnectRestClient, connector string) (e error) {
-	connectorTasks, e := client.GetConfig(connector)
+// deleteConnector deletes a connector.
+// Return its configuration, to use as the `-config.json` option during rollback connector.
+func deleteConnector(client connect.Client, connectorTasks connect.ConnectorTasksConfig) (r connect.ConnectorConfig, e error) {
+	connector := connectorTasks.Name
+	e = client.Delete(connector)
 	if e == nil {
-		fmt.Fprintf(os.Stdin, "\nCurrent configuration for connector %s\n\n", connector)
-		config, _ := json.Marshal(connect.ConnectorConfig{Name: connectorTasks.Name, Config: connectorTasks.Config})
-		utils.PrintJson(string(config), true)
-		fmt.Fprint(os.Stdin, "\nSave this to use as the `-config.json` option during rollback connector\n\n")
-		e = client.Delete(connector)
-		if e == nil {
-			fmt.Fprintf(os.Stdin, "Successfully deleted connector %s \n", connector)
-		}
+		fmt.Fprintf(os.Stdin, "Successfully deleted connector %s \n", connector)
+		r = connect.ConnectorConfig{Name: connectorTasks.Name, Config: connectorTasks.Config}
 	}
 	return
 }
 
 type Matcher func(connectorName string) (bool, error)
 
-func findMatchingConnectors(client connect.ConnectRestClient, matcher Matcher) (connectors []string, e error) {
+func findMatchingConnectors(client connect.Client, matcher Matcher) (connectors []string, e error) {
 	list, e := client.List()
 	if e == nil {
 		for _, conn := range list {
@@ -451,12 +840,37 @@ func findMatchingConnectors(client connect.ConnectRestClient, matcher Matcher) (
 	return
 }
 
+// resolveCommand returns the command to execute and its remaining arguments.
+// Commands declared in SubCommands are returned along with their action, e.g "offsets get".
+func resolveCommand(args []string) (string, []string) {
+	command := args[0]
+	actions, ok := SubCommands[command]
+	if !ok {
+		return command, args[1:]
+	}
+	if len(args) > 1 {
+		for _, action := range actions {
+			if args[1] == action {
+				return command + " " + action, args[2:]
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Missing or invalid action for command '%s'. Must be one of %s\n", command, strings.Join(actions, ","))
+	os.Exit(1)
+	return "", nil
+}
+
 func printOutputAndExit(result interface{}, err error, pretty bool) {
 	if err != nil {
 		utils.PrintJson(err.Error(), pretty)
 		os.Exit(1)
 	}
 
+	if report, ok := result.(exitCoder); ok {
+		utils.PrintJson(report, pretty)
+		os.Exit(report.ExitCode())
+	}
+
 	if result != nil {
 		utils.PrintJson(result, pretty)
 		os.Exit(0)
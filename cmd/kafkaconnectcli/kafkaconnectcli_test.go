@@ -0,0 +1,236 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"github.com/fhussonnois/kafkacli/connect"
+	"github.com/fhussonnois/kafkacli/connect/connecttest"
+	"testing"
+)
+
+// newServer returns a fake worker running the given connectors, with 2 tasks each.
+func newServer(t *testing.T, connectors ...string) (*connecttest.Server, connect.Client) {
+	server := connecttest.NewServer()
+	client := server.Client()
+	for _, conn := range connectors {
+		if _, err := client.Create(connecttest.SinkConnector(conn, 2)); err != nil {
+			server.Close()
+			t.Fatalf("unexpected error %s", err)
+		}
+	}
+	return server, client
+}
+
+func TestHandleRestartCommand(t *testing.T) {
+	server, client := newServer(t, "sink-a", "sink-b")
+	defer server.Close()
+	server.SetConnectorState("sink-a", connecttest.FAILED, "")
+	server.SetTaskState("sink-b", 0, connecttest.FAILED, "")
+
+	options := connect.RestartOptions{IncludeTasks: true, OnlyFailed: true}
+	result, err := handleRestartCommand(client, "sink-.*", options, DEFAULT_PARALLELISM)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	report := result.(Report)
+	if report.Total != 2 || report.Succeeded != 2 {
+		t.Errorf("expected 2 restarted connectors, got %v", report)
+	}
+	if _, ok := report.Results["sink-a"].Result.(connect.ConnectorStatus); !ok {
+		t.Errorf("expected the status of sink-a, got %v", report.Results["sink-a"])
+	}
+	for _, conn := range []string{"sink-a", "sink-b"} {
+		if reason := checkRunning(client, conn); reason != "" {
+			t.Errorf("expected %s to be running, got %s", conn, reason)
+		}
+	}
+
+	// Without options, workers do not return any status.
+	result, _ = handleRestartCommand(client, "sink-a", connect.RestartOptions{}, DEFAULT_PARALLELISM)
+	if single := result.(Report).Results["sink-a"]; single.Result != nil || single.Error != "" {
+		t.Errorf("expected no status, got %v", single)
+	}
+}
+
+func TestHandleStopCommand(t *testing.T) {
+	server, client := newServer(t, "sink-a", "sink-b", "other")
+	defer server.Close()
+
+	result, err := handleConnectorCommands(client, "stop", "sink-.*", DEFAULT_PARALLELISM)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if report := result.(Report); report.Total != 2 || report.Succeeded != 2 {
+		t.Errorf("expected 2 stopped connectors, got %v", report)
+	}
+	for conn, expected := range map[string]string{"sink-a": connecttest.STOPPED, "sink-b": connecttest.STOPPED, "other": connecttest.RUNNING} {
+		if status, _ := client.Status(conn); status.Connector.State != expected || (expected == connecttest.STOPPED && len(status.Tasks) != 0) {
+			t.Errorf("expected %s to be %s, got %+v", conn, expected, status)
+		}
+	}
+
+	// Stopped connectors cannot be restarted, the error is reported by connector.
+	result, _ = handleRestartCommand(client, "sink-a", connect.RestartOptions{}, DEFAULT_PARALLELISM)
+	if report := result.(Report); report.Failed != 1 || report.ExitCode() == 0 {
+		t.Errorf("expected the restart of sink-a to fail, got %v", report)
+	}
+}
+
+func TestHandleListCommandWithState(t *testing.T) {
+	server, client := newServer(t, "sink-a", "sink-b", "sink-c")
+	defer server.Close()
+	server.SetTaskState("sink-b", 1, connecttest.FAILED, "")
+	client.Pause("sink-c")
+
+	for state, expected := range map[string]string{"failed": "[sink-b]", "paused": "[sink-c]", "running": "[sink-a sink-b]", "": "[sink-a sink-b sink-c]"} {
+		result, err := handleListCommand(client, state)
+		if err != nil {
+			t.Fatalf("unexpected error %s", err)
+		}
+		if actual := fmt.Sprint(result); actual != expected {
+			t.Errorf("expected %s connectors %s, got %s", state, expected, actual)
+		}
+	}
+}
+
+func TestHandleDeleteAllCommand(t *testing.T) {
+	server, client := newServer(t, "sink-a", "sink-b")
+	defer server.Close()
+
+	result, err := handleDeleteAllCommand(client, DEFAULT_PARALLELISM)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if report := result.(Report); report.Succeeded != 2 {
+		t.Errorf("expected 2 deleted connectors, got %v", report)
+	}
+	if connectors, _ := client.List(); len(connectors) != 0 {
+		t.Errorf("expected no connector, got %v", connectors)
+	}
+}
+
+// unreachableClient panics when pausing a given connector, as the client does when the worker is unreachable.
+type unreachableClient struct {
+	connect.Client
+	connector string
+}
+
+func (c unreachableClient) Pause(connector string) error {
+	if connector == c.connector {
+		panic("dial tcp: connection refused")
+	}
+	return c.Client.Pause(connector)
+}
+
+func TestHandleConnectorCommandsPartialFailure(t *testing.T) {
+	server, client := newServer(t, "sink-a", "sink-b", "sink-c")
+	defer server.Close()
+
+	result, err := handleConnectorCommands(unreachableClient{client, "sink-b"}, "pause", "sink-.*", 1)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	report := result.(Report)
+	if report.Succeeded != 2 || report.Failed != 1 || report.ExitCode() != PARTIAL_FAILURE_EXIT_CODE {
+		t.Errorf("expected 2 paused connectors and 1 failure, got %v", report)
+	}
+	if report.Results["sink-b"].Error != "dial tcp: connection refused" {
+		t.Errorf("expected the error of sink-b, got %v", report.Results["sink-b"])
+	}
+	for conn, expected := range map[string]string{"sink-a": connecttest.PAUSED, "sink-b": connecttest.RUNNING, "sink-c": connecttest.PAUSED} {
+		if status, _ := client.Status(conn); status.Connector.State != expected {
+			t.Errorf("expected %s to be %s, got %s", conn, expected, status.Connector.State)
+		}
+	}
+}
+
+func TestHandleConnectorCommandsReturnsSingleResultAsIs(t *testing.T) {
+	server, client := newServer(t, "sink-a", "sink-b")
+	defer server.Close()
+
+	result, err := handleConnectorCommands(client, "status", "sink-a", DEFAULT_PARALLELISM)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	status, ok := result.(connect.ConnectorStatus)
+	if !ok || status.Name != "sink-a" || len(status.Tasks) != 2 {
+		t.Errorf("expected the status of sink-a, got %v", result)
+	}
+
+	result, err = handleConnectorCommands(client, "status", "sink-.*", DEFAULT_PARALLELISM)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	report, ok := result.(Report)
+	if !ok || report.Total != 2 || report.Succeeded != 2 {
+		t.Errorf("expected a report of 2 connectors, got %v", result)
+	}
+	for _, conn := range []string{"sink-a", "sink-b"} {
+		if status, ok := report.Results[conn].Result.(connect.ConnectorStatus); !ok || status.Name != conn {
+			t.Errorf("expected the status of %s, got %v", conn, report.Results[conn])
+		}
+	}
+
+	result, err = handleConnectorCommands(client, "config", "sink-.*", DEFAULT_PARALLELISM)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	for _, conn := range []string{"sink-a", "sink-b"} {
+		if config, ok := result.(Report).Results[conn].Result.(connect.ConnectorTasksConfig); !ok || config.Name != conn {
+			t.Errorf("expected the configuration of %s, got %v", conn, result.(Report).Results[conn])
+		}
+	}
+}
+
+func TestHandleConnectorCommandsDelete(t *testing.T) {
+	server, client := newServer(t, "sink-a", "sink-b", "other")
+	defer server.Close()
+
+	result, err := handleConnectorCommands(client, "delete", "sink-.*", DEFAULT_PARALLELISM)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	report := result.(Report)
+	if report.Succeeded != 2 || report.ExitCode() != 0 {
+		t.Errorf("expected 2 deleted connectors, got %v", report)
+	}
+	// The configuration of deleted connectors is returned so that they can be re-created.
+	config, ok := report.Results["sink-a"].Result.(connect.ConnectorConfig)
+	if !ok || config.Name != "sink-a" || config.Config["tasks.max"] != "2" {
+		t.Errorf("expected the configuration of sink-a, got %v", report.Results["sink-a"])
+	}
+	connectors, _ := client.List()
+	if len(connectors) != 1 || connectors[0] != "other" {
+		t.Errorf("expected [other], got %v", connectors)
+	}
+}
+
+func TestHandleConnectorCommandsRestartFailed(t *testing.T) {
+	server, client := newServer(t, "sink")
+	defer server.Close()
+	server.SetTaskState("sink", 1, connecttest.FAILED, "java.lang.NullPointerException")
+
+	result, err := handleConnectorCommands(client, "restart-failed", "sink", DEFAULT_PARALLELISM)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	restarted, ok := result.(Report).Results["sink"].Result.([]int)
+	if !ok || len(restarted) != 1 || restarted[0] != 1 {
+		t.Errorf("expected task 1 to be restarted, got %v", result)
+	}
+	if reason := checkRunning(client, "sink"); reason != "" {
+		t.Errorf("expected sink to be running, got %s", reason)
+	}
+}
@@ -0,0 +1,208 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"github.com/fhussonnois/kafkacli/connect"
+	"net"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ROOT_LOGGER is the name of the ancestor of all loggers.
+const ROOT_LOGGER = "root"
+
+// LOG_LEVELS lists the levels accepted by "loggers set".
+var LOG_LEVELS = []string{"OFF", "FATAL", "ERROR", "WARN", "INFO", "DEBUG", "TRACE"}
+
+// handleLoggersCommand executes "loggers list", "loggers get" and "loggers set" commands on each worker.
+// Return the results by worker.
+func handleLoggersCommand(client connect.Client, command string, args CommandArgs) (result interface{}, e error) {
+	clients, e := newWorkerClients(client, args)
+	if e != nil {
+		return
+	}
+	results := make(map[string]interface{})
+	switch command {
+	case "loggers list":
+		for worker, c := range clients {
+			if results[worker], e = c.GetLoggers(); e != nil {
+				return
+			}
+		}
+	case "loggers get":
+		for worker, c := range clients {
+			if results[worker], e = c.GetLogger(*args.logger); e != nil {
+				return
+			}
+		}
+	case "loggers set":
+		return handleSetLogLevel(clients, *args.logger, strings.ToUpper(*args.level), *args.scope, *args.revertAfter)
+	}
+	return results, nil
+}
+
+// handleSetLogLevel sets the level of a logger on each worker, or once for SCOPE_CLUSTER, the receiving worker
+// propagating the change to the others. If revertAfter is positive, it then waits for this duration, or until the
+// command is interrupted, before setting back the previous levels of the logger and its descendants.
+// If the level cannot be set on a worker, the workers already changed are reverted.
+func handleSetLogLevel(clients map[string]connect.Client, logger string, level string, scope string, revertAfter time.Duration) (result interface{}, e error) {
+	workers := make([]string, 0, len(clients))
+	for worker := range clients {
+		workers = append(workers, worker)
+	}
+	sort.Strings(workers)
+	if scope == connect.SCOPE_CLUSTER {
+		workers = workers[:1]
+	}
+	previous := make(map[string]map[string]string)
+	for _, worker := range workers {
+		if previous[worker], e = loggerLevels(clients[worker], logger); e != nil {
+			return
+		}
+	}
+	results := make(map[string][]string)
+	for i, worker := range workers {
+		if results[worker], e = setLogLevel(clients[worker], logger, level, scope); e != nil {
+			e = fmt.Errorf("Error while setting level of logger %s on worker %s: %s", logger, worker, e)
+			if err := revertLogLevels(clients, workers[:i], previous, scope); err != nil {
+				e = fmt.Errorf("%s, %s", e, err)
+			}
+			return nil, e
+		}
+		fmt.Fprintf(os.Stderr, "Level of logger %s set to %s on worker %s\n", logger, level, worker)
+	}
+	if revertAfter <= 0 {
+		return results, nil
+	}
+	// Levels are also reverted when the command is interrupted while waiting.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	fmt.Fprintf(os.Stderr, "Reverting level of logger %s in %s\n", logger, revertAfter)
+	var interrupted os.Signal
+	select {
+	case <-time.After(revertAfter):
+	case interrupted = <-signals:
+		fmt.Fprintf(os.Stderr, "Interrupted (%s), reverting level of logger %s\n", interrupted, logger)
+	}
+	if e = revertLogLevels(clients, workers, previous, scope); e != nil {
+		return
+	}
+	if interrupted != nil {
+		return nil, fmt.Errorf("Interrupted (%s), level of logger %s reverted", interrupted, logger)
+	}
+	return results, nil
+}
+
+// revertLogLevels sets back the previous levels of loggers on each worker, even if it fails on some of them.
+// Return an error listing the workers which have not been reverted.
+func revertLogLevels(clients map[string]connect.Client, workers []string, previous map[string]map[string]string, scope string) error {
+	var failed []string
+	for _, worker := range workers {
+		for _, logger := range ancestorsFirst(previous[worker]) {
+			level := previous[worker][logger]
+			if _, err := setLogLevel(clients[worker], logger, level, scope); err != nil {
+				failed = append(failed, worker+" ("+logger+": "+err.Error()+")")
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Level of logger %s reverted to %s on worker %s\n", logger, level, worker)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("levels of loggers not reverted on workers: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// ancestorsFirst returns the names of loggers sorted so that ancestors come before their descendants, the level
+// of a descendant being set back after it was changed along with its ancestor.
+func ancestorsFirst(levels map[string]string) []string {
+	loggers := make([]string, 0, len(levels))
+	for logger := range levels {
+		loggers = append(loggers, logger)
+	}
+	sort.Slice(loggers, func(i, j int) bool {
+		if loggers[i] == ROOT_LOGGER || loggers[j] == ROOT_LOGGER {
+			return loggers[j] != ROOT_LOGGER
+		}
+		return loggers[i] < loggers[j]
+	})
+	return loggers
+}
+
+// setLogLevel sets the level of a logger on a worker, recovering from panics of the client (e.g when the worker is
+// unreachable) as errors so that the workers already changed can be reverted.
+func setLogLevel(client connect.Client, logger string, level string, scope string) ([]string, error) {
+	res, err := safeApply(func(string) (interface{}, error) { return client.SetLogLevel(logger, level, scope) }, logger)
+	loggers, _ := res.([]string)
+	return loggers, err
+}
+
+// loggerLevels returns the levels of a logger and of its descendants having an explicit level, i.e the loggers
+// changed along with it. The logger itself is returned with its effective level.
+func loggerLevels(client connect.Client, logger string) (map[string]string, error) {
+	loggers, e := client.GetLoggers()
+	if e != nil {
+		return nil, e
+	}
+	levels := map[string]string{logger: effectiveLevel(loggers, logger)}
+	for name, l := range loggers {
+		if logger == ROOT_LOGGER || strings.HasPrefix(name, logger+".") {
+			levels[name] = l.Level
+		}
+	}
+	return levels, nil
+}
+
+// effectiveLevel returns the level of a logger, which is the one of its closest ancestor if it has no explicit level.
+func effectiveLevel(loggers map[string]connect.LoggerLevel, logger string) string {
+	for name := logger; ; {
+		if l, ok := loggers[name]; ok {
+			return l.Level
+		}
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return loggers[ROOT_LOGGER].Level
+}
+
+// newWorkerClients returns a client for each worker of the "-workers" argument, or the default client, by worker.
+func newWorkerClients(client connect.Client, args CommandArgs) (map[string]connect.Client, error) {
+	clients := make(map[string]connect.Client)
+	if *args.workers == "" {
+		clients[net.JoinHostPort(*args.host, strconv.Itoa(*args.port))] = client
+		return clients, nil
+	}
+	for _, worker := range strings.Split(*args.workers, ",") {
+		worker = strings.TrimSpace(worker)
+		host, port, err := net.SplitHostPort(worker)
+		number, errPort := strconv.Atoi(port)
+		if err != nil || errPort != nil {
+			return nil, fmt.Errorf("Invalid worker '%s', must be <host>:<port>", worker)
+		}
+		restClient := connect.NewConnectClient(host, number)
+		clients[worker] = &restClient
+	}
+	return clients, nil
+}
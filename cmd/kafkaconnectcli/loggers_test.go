@@ -0,0 +1,165 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"github.com/fhussonnois/kafkacli/connect"
+	"os"
+	"os/signal"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rejectingClient fails to set log levels.
+type rejectingClient struct {
+	connect.Client
+}
+
+func (c rejectingClient) SetLogLevel(name string, level string, scope string) ([]string, error) {
+	return nil, errors.New("Internal Server Error")
+}
+
+func loggerLevel(t *testing.T, client connect.Client, logger string) string {
+	loggers, err := client.GetLoggers()
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	return effectiveLevel(loggers, logger)
+}
+
+func TestHandleSetLogLevel(t *testing.T) {
+	server1, client1 := newServer(t)
+	defer server1.Close()
+	server2, client2 := newServer(t)
+	defer server2.Close()
+	clients := map[string]connect.Client{"worker-1": client1, "worker-2": client2}
+
+	result, err := handleSetLogLevel(clients, "root", "DEBUG", connect.SCOPE_WORKER, 0)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if results := result.(map[string][]string); len(results) != 2 {
+		t.Errorf("expected results of 2 workers, got %v", results)
+	}
+	for _, client := range []connect.Client{client1, client2} {
+		if level := loggerLevel(t, client, "org.apache.kafka"); level != "DEBUG" {
+			t.Errorf("expected level DEBUG, got %s", level)
+		}
+	}
+
+	// The level is reverted once done.
+	if _, err = handleSetLogLevel(clients, "root", "TRACE", connect.SCOPE_WORKER, 1); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	for _, client := range []connect.Client{client1, client2} {
+		if level := loggerLevel(t, client, "root"); level != "DEBUG" {
+			t.Errorf("expected level DEBUG, got %s", level)
+		}
+	}
+}
+
+func TestHandleSetLogLevelRevertsOnFailure(t *testing.T) {
+	server1, client1 := newServer(t)
+	defer server1.Close()
+	server2, client2 := newServer(t)
+	defer server2.Close()
+	clients := map[string]connect.Client{"worker-1": client1, "worker-2": rejectingClient{client2}}
+
+	_, err := handleSetLogLevel(clients, "root", "DEBUG", connect.SCOPE_WORKER, 0)
+	if err == nil || !strings.Contains(err.Error(), "worker-2") {
+		t.Fatalf("expected an error for worker-2, got %v", err)
+	}
+	if level := loggerLevel(t, client1, "root"); level != "INFO" {
+		t.Errorf("expected level of worker-1 to be reverted to INFO, got %s", level)
+	}
+}
+
+func TestHandleSetLogLevelClusterScope(t *testing.T) {
+	server1, client1 := newServer(t)
+	defer server1.Close()
+	server2, client2 := newServer(t)
+	defer server2.Close()
+	// The receiving worker propagates the change, so a failing second worker is not requested.
+	clients := map[string]connect.Client{"worker-1": client1, "worker-2": rejectingClient{client2}}
+
+	result, err := handleSetLogLevel(clients, "root", "DEBUG", connect.SCOPE_CLUSTER, 0)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if results := result.(map[string][]string); len(results) != 1 {
+		t.Errorf("expected the result of a single worker, got %v", results)
+	}
+	if level := loggerLevel(t, client1, "root"); level != "DEBUG" {
+		t.Errorf("expected level DEBUG, got %s", level)
+	}
+}
+
+func TestHandleSetLogLevelRevertsDescendants(t *testing.T) {
+	server, client := newServer(t)
+	defer server.Close()
+	client.SetLogLevel("org.apache.kafka.connect", "TRACE", connect.SCOPE_WORKER)
+	client.SetLogLevel("org.apache.kafka.connect.runtime", "ERROR", connect.SCOPE_WORKER)
+	clients := map[string]connect.Client{"worker-1": client}
+
+	for _, logger := range []string{"org.apache.kafka", ROOT_LOGGER} {
+		if _, err := handleSetLogLevel(clients, logger, "DEBUG", connect.SCOPE_WORKER, time.Millisecond); err != nil {
+			t.Fatalf("unexpected error %s", err)
+		}
+		expected := map[string]string{
+			"org.apache.kafka":                 "INFO",
+			"org.apache.kafka.connect":         "TRACE",
+			"org.apache.kafka.connect.runtime": "ERROR",
+			ROOT_LOGGER:                        "INFO",
+		}
+		for name, level := range expected {
+			if actual := loggerLevel(t, client, name); actual != level {
+				t.Errorf("expected level %s of %s to be reverted after changing %s, got %s", level, name, logger, actual)
+			}
+		}
+	}
+}
+
+func TestHandleSetLogLevelRevertsWhenInterrupted(t *testing.T) {
+	server, client := newServer(t)
+	defer server.Close()
+	clients := map[string]connect.Client{"worker-1": client}
+	// Keeps the test process from being terminated by the interruptions below.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	done := make(chan error)
+	go func() {
+		_, err := handleSetLogLevel(clients, "org.apache.kafka", "DEBUG", connect.SCOPE_WORKER, time.Hour)
+		done <- err
+	}()
+	process, _ := os.FindProcess(os.Getpid())
+	var err error
+	for waiting := true; waiting; {
+		select {
+		case err = <-done:
+			waiting = false
+		case <-time.After(20 * time.Millisecond):
+			process.Signal(os.Interrupt)
+		}
+	}
+	if err == nil || !strings.HasPrefix(err.Error(), "Interrupted") {
+		t.Errorf("expected an interruption error, got %v", err)
+	}
+	if level := loggerLevel(t, client, "org.apache.kafka"); level != "INFO" {
+		t.Errorf("expected level to be reverted to INFO, got %s", level)
+	}
+}
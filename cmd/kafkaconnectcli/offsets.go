@@ -0,0 +1,116 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fhussonnois/kafkacli/connect"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"time"
+)
+
+// handleOffsetsCommand executes "offsets get", "offsets set" and "offsets reset" commands.
+// Offsets can only be modified while the connector is STOPPED, and are backed up to a file before.
+func handleOffsetsCommand(client connect.Client, command string, args CommandArgs) (result interface{}, e error) {
+	conn, e := findSingleConnector(client, *args.connector)
+	if e != nil {
+		return
+	}
+	if command == "offsets get" {
+		return client.GetOffsets(conn)
+	}
+
+	var offsets connect.ConnectorOffsets
+	if command == "offsets set" {
+		if offsets, e = readOffsets(args); e != nil {
+			return
+		}
+	}
+	status, e := client.Status(conn)
+	if e != nil {
+		return
+	}
+	if status.Connector.State != "STOPPED" {
+		return nil, fmt.Errorf("Connector %s must be STOPPED before modifying its offsets (current state is %s), use 'stop' command first", conn, status.Connector.State)
+	}
+	if e = backupOffsets(client, conn, *args.backup); e != nil {
+		return
+	}
+	var message string
+	switch command {
+	case "offsets set":
+		message, e = client.AlterOffsets(conn, offsets)
+	case "offsets reset":
+		message, e = client.ResetOffsets(conn)
+	}
+	if e == nil {
+		result = map[string]string{"message": message}
+	}
+	return
+}
+
+// findSingleConnector returns the only connector matching the specified regex.
+func findSingleConnector(client connect.Client, connector string) (conn string, e error) {
+	connectRegex := regexp.MustCompile(connector)
+	matches, e := findMatchingConnectors(client, func(conn string) (bool, error) { return connectRegex.MatchString(conn), nil })
+	if e == nil && len(matches) != 1 {
+		e = fmt.Errorf("'%s' must match exactly one connector, found %v", connector, matches)
+	}
+	if e == nil {
+		conn = matches[0]
+	}
+	return
+}
+
+// readOffsets returns the offsets to alter, either read from a JSON file or given for a single sink topic-partition.
+func readOffsets(args CommandArgs) (offsets connect.ConnectorOffsets, e error) {
+	if *args.offsetsFile == "" {
+		offsets.Offsets = []connect.ConnectorOffset{connect.NewSinkOffset(*args.topic, *args.partition, *args.offset)}
+		return
+	}
+	file, e := ioutil.ReadFile(*args.offsetsFile)
+	if e == nil {
+		e = json.Unmarshal(file, &offsets)
+	}
+	if e != nil {
+		e = fmt.Errorf("Invalid offsets file '%s': %v", *args.offsetsFile, e)
+	}
+	return
+}
+
+// backupOffsets writes the current offsets of a connector to a new file, which can be restored with "offsets set".
+func backupOffsets(client connect.Client, conn string, file string) error {
+	offsets, e := client.GetOffsets(conn)
+	if e != nil {
+		return e
+	}
+	if file == "" {
+		file = fmt.Sprintf("%s-offsets-%s.json", conn, time.Now().Format("20060102150405"))
+	}
+	// Never overwrite an existing backup, which may be the only copy of previous offsets.
+	out, e := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if e != nil {
+		return e
+	}
+	defer out.Close()
+	content, _ := json.MarshalIndent(offsets, "", "    ")
+	if _, e = out.Write(content); e != nil {
+		return e
+	}
+	fmt.Fprintf(os.Stderr, "Current offsets of connector %s backed up to %s\n", conn, file)
+	return nil
+}
@@ -0,0 +1,144 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"github.com/fhussonnois/kafkacli/connect"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// offsetsArgs parses the arguments of the "offsets set" command.
+func offsetsArgs(args ...string) CommandArgs {
+	parser := NewArgParser("offsets set")
+	parser.withConnectorArg().withOffsetsArg().withBackupArg()
+	return parser.parse(args)
+}
+
+func sinkOffsets(t *testing.T, client connect.Client, conn string) map[int]int64 {
+	offsets, err := client.GetOffsets(conn)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	res := make(map[int]int64)
+	for _, o := range offsets.Offsets {
+		_, partition, offset, _ := o.SinkOffset()
+		res[partition] = offset
+	}
+	return res
+}
+
+func TestHandleOffsetsCommandRequiresStoppedConnector(t *testing.T) {
+	server, client := newServer(t, "sink")
+	defer server.Close()
+	dir, _ := ioutil.TempDir("", "offsets")
+	defer os.RemoveAll(dir)
+	backup := filepath.Join(dir, "backup.json")
+
+	args := offsetsArgs("-connector", "sink", "-topic", "events", "-offset", "10", "-backup", backup)
+	_, err := handleOffsetsCommand(client, "offsets set", args)
+	if err == nil || !strings.Contains(err.Error(), "must be STOPPED") {
+		t.Errorf("expected an error as the connector is running, got %v", err)
+	}
+	if _, err := os.Stat(backup); !os.IsNotExist(err) {
+		t.Errorf("expected no backup of the offsets, got %v", err)
+	}
+	if _, err := handleOffsetsCommand(client, "offsets reset", args); err == nil {
+		t.Error("expected an error when resetting the offsets of a running connector")
+	}
+	// Getting offsets does not require the connector to be stopped.
+	if _, err := handleOffsetsCommand(client, "offsets get", args); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+}
+
+func TestHandleOffsetsCommandBacksUpOffsets(t *testing.T) {
+	server, client := newServer(t, "sink")
+	defer server.Close()
+	dir, _ := ioutil.TempDir("", "offsets")
+	defer os.RemoveAll(dir)
+	backup := filepath.Join(dir, "backup.json")
+
+	server.CommitOffsets("sink", []connect.ConnectorOffset{connect.NewSinkOffset("events", 0, 42)})
+	client.Stop("sink")
+	args := offsetsArgs("-connector", "sink", "-topic", "events", "-partition", "1", "-offset", "7", "-backup", backup)
+	if _, err := handleOffsetsCommand(client, "offsets set", args); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if offsets := sinkOffsets(t, client, "sink"); len(offsets) != 2 || offsets[0] != 42 || offsets[1] != 7 {
+		t.Errorf("expected offsets 42 and 7, got %v", offsets)
+	}
+	var backedUp connect.ConnectorOffsets
+	content, _ := ioutil.ReadFile(backup)
+	if err := json.Unmarshal(content, &backedUp); err != nil || len(backedUp.Offsets) != 1 {
+		t.Errorf("expected the previous offsets in the backup, got %s", content)
+	}
+
+	// An existing backup is never overwritten, and offsets are left unchanged.
+	if _, err := handleOffsetsCommand(client, "offsets reset", args); err == nil || !os.IsExist(err) {
+		t.Errorf("expected an error as the backup file exists, got %v", err)
+	}
+	if offsets := sinkOffsets(t, client, "sink"); len(offsets) != 2 {
+		t.Errorf("expected offsets to be unchanged, got %v", offsets)
+	}
+
+	// The offsets are reset, then restored from the backup.
+	args = offsetsArgs("-connector", "sink", "-offsets.json", backup, "-backup", filepath.Join(dir, "reset.json"))
+	if _, err := handleOffsetsCommand(client, "offsets reset", args); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if offsets := sinkOffsets(t, client, "sink"); len(offsets) != 0 {
+		t.Errorf("expected no offsets, got %v", offsets)
+	}
+	args = offsetsArgs("-connector", "sink", "-offsets.json", backup, "-backup", filepath.Join(dir, "restore.json"))
+	if _, err := handleOffsetsCommand(client, "offsets set", args); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if offsets := sinkOffsets(t, client, "sink"); len(offsets) != 1 || offsets[0] != 42 {
+		t.Errorf("expected the offsets of the backup, got %v", offsets)
+	}
+}
+
+func TestHandleOffsetsCommandInvalidArguments(t *testing.T) {
+	server, client := newServer(t, "sink-a", "sink-b")
+	defer server.Close()
+	dir, _ := ioutil.TempDir("", "offsets")
+	defer os.RemoveAll(dir)
+	invalid := filepath.Join(dir, "invalid.json")
+	ioutil.WriteFile(invalid, []byte("{"), 0644)
+
+	if _, err := handleOffsetsCommand(client, "offsets get", offsetsArgs("-connector", "sink-.*")); err == nil {
+		t.Error("expected an error as several connectors match")
+	}
+	client.Stop("sink-a")
+	_, err := handleOffsetsCommand(client, "offsets set", offsetsArgs("-connector", "sink-a", "-offsets.json", invalid))
+	if err == nil || !strings.HasPrefix(err.Error(), "Invalid offsets file") {
+		t.Errorf("expected an invalid offsets file error, got %v", err)
+	}
+}
+
+func TestResolveCommand(t *testing.T) {
+	command, args := resolveCommand([]string{"offsets", "set", "-connector", "sink"})
+	if command != "offsets set" || len(args) != 2 {
+		t.Errorf("expected 'offsets set' and 2 args, got '%s' and %v", command, args)
+	}
+	command, args = resolveCommand([]string{"status", "-connector", "sink"})
+	if command != "status" || len(args) != 2 {
+		t.Errorf("expected 'status' and 2 args, got '%s' and %v", command, args)
+	}
+}
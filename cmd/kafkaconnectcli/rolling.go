@@ -0,0 +1,238 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"github.com/fhussonnois/kafkacli/connect"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ROLLING_RESTART_POLL_INTERVAL is the interval between two checks of the connectors being restarted.
+const ROLLING_RESTART_POLL_INTERVAL = 2 * time.Second
+
+// RollingRestartReport is the outcome of a "rolling-restart" command.
+type RollingRestartReport struct {
+	Total     int           `json:"total"`
+	Restarted int           `json:"restarted"`
+	Failed    int           `json:"failed"`
+	Halted    bool          `json:"halted"`
+	Duration  string        `json:"duration"`
+	Batches   []BatchReport `json:"batches"`
+	// Recovered lists the failed connectors which have got back to RUNNING later on, e.g while the rolling restart was paused.
+	// These are counted as restarted instead of failed.
+	Recovered []string `json:"recovered,omitempty"`
+	// Skipped lists the connectors which have not been restarted, either because they are paused or stopped,
+	// or because the rolling restart has been halted.
+	Skipped []string `json:"skipped"`
+}
+
+// BatchReport is the outcome of a batch of connectors restarted together.
+type BatchReport struct {
+	Connectors []string          `json:"connectors"`
+	Duration   string            `json:"duration"`
+	Failures   map[string]string `json:"failures,omitempty"`
+}
+
+// ExitCode returns 0 if all connectors have been restarted, PARTIAL_FAILURE_EXIT_CODE if some of them only, or 1 otherwise.
+func (r RollingRestartReport) ExitCode() int {
+	switch {
+	case r.Failed == 0 && !r.Halted:
+		return 0
+	case r.Restarted > 0:
+		return PARTIAL_FAILURE_EXIT_CODE
+	}
+	return 1
+}
+
+// handleRollingRestartCommand executes "rolling-restart" command. Matching connectors are restarted along with their tasks
+// by batches, each batch being restarted once the previous one is back to RUNNING or has timed out.
+// At most maxUnavailable connectors are unavailable at once, counting both the connectors being restarted and the ones
+// which have failed to get back to RUNNING. When failed connectors use up this budget, the rolling restart is paused
+// until some of them recover, and is halted if none does within timeout.
+func handleRollingRestartCommand(client connect.Client, connector string, batch int, maxUnavailable int, timeout time.Duration) (result interface{}, e error) {
+	connectRegex := regexp.MustCompile(connector)
+	connectors, e := client.ListExpanded(connect.EXPAND_STATUS)
+	if e != nil {
+		return
+	}
+	report := RollingRestartReport{Batches: []BatchReport{}, Skipped: []string{}}
+	matches := []string{}
+	for conn, expanded := range connectors {
+		if !connectRegex.MatchString(conn) {
+			continue
+		}
+		report.Total++
+		// Restarting a paused or stopped connector would leave it as is.
+		switch expanded.Status.Connector.State {
+		case "PAUSED", "STOPPED":
+			report.Skipped = append(report.Skipped, conn)
+		default:
+			matches = append(matches, conn)
+		}
+	}
+	sort.Strings(matches)
+	sort.Strings(report.Skipped)
+
+	start := time.Now()
+	unavailable := []string{}
+	for i := 0; i < len(matches); {
+		unavailable = report.checkRecovered(client, unavailable)
+		if len(unavailable) >= maxUnavailable {
+			fmt.Fprintf(os.Stderr, "Rolling restart paused, %d connector(s) failed to restart\n", len(unavailable))
+			unavailable = report.waitForRecovery(client, unavailable, maxUnavailable, timeout)
+		}
+		if len(unavailable) >= maxUnavailable {
+			report.Halted = true
+			report.Skipped = append(report.Skipped, matches[i:]...)
+			fmt.Fprintf(os.Stderr, "Rolling restart halted, %d connector(s) still not running after %s\n", len(unavailable), timeout)
+			break
+		}
+		size := batch
+		if size > maxUnavailable-len(unavailable) {
+			size = maxUnavailable - len(unavailable)
+		}
+		if size > len(matches)-i {
+			size = len(matches) - i
+		}
+		batchReport := restartBatch(client, matches[i:i+size], timeout)
+		report.Batches = append(report.Batches, batchReport)
+		report.Failed += len(batchReport.Failures)
+		report.Restarted += len(batchReport.Connectors) - len(batchReport.Failures)
+		for _, conn := range batchReport.Connectors {
+			if _, failed := batchReport.Failures[conn]; failed {
+				unavailable = append(unavailable, conn)
+			}
+		}
+		i += size
+		fmt.Fprintf(os.Stderr, "Batch %d restarted in %s: %d/%d connector(s) restarted, %d failure(s)\n",
+			len(report.Batches), batchReport.Duration, i, len(matches), len(batchReport.Failures))
+	}
+	report.Duration = time.Since(start).Round(time.Millisecond).String()
+	return report, nil
+}
+
+// checkRecovered checks the failed connectors, adding the ones which are back to RUNNING to the recovered connectors.
+// Return the connectors which are still unavailable.
+func (r *RollingRestartReport) checkRecovered(client connect.Client, unavailable []string) []string {
+	remaining := []string{}
+	for _, conn := range unavailable {
+		if checkRunning(client, conn) != "" {
+			remaining = append(remaining, conn)
+			continue
+		}
+		r.Recovered = append(r.Recovered, conn)
+		r.Failed--
+		r.Restarted++
+		fmt.Fprintf(os.Stderr, "Connector %s is back to RUNNING\n", conn)
+	}
+	return remaining
+}
+
+// waitForRecovery waits for at most timeout until less than maxUnavailable connectors are unavailable.
+// Return the connectors which are still unavailable.
+func (r *RollingRestartReport) waitForRecovery(client connect.Client, unavailable []string, maxUnavailable int, timeout time.Duration) []string {
+	deadline := time.Now().Add(timeout)
+	for len(unavailable) >= maxUnavailable && time.Now().Before(deadline) {
+		time.Sleep(ROLLING_RESTART_POLL_INTERVAL)
+		unavailable = r.checkRecovered(client, unavailable)
+	}
+	return unavailable
+}
+
+// restartBatch restarts connectors and their tasks, and waits for them to be RUNNING for at most timeout.
+func restartBatch(client connect.Client, connectors []string, timeout time.Duration) BatchReport {
+	start := time.Now()
+	report := BatchReport{Connectors: connectors, Failures: make(map[string]string)}
+	restarts := executeAll("restart", connectors, len(connectors), func(conn string) (interface{}, error) {
+		return nil, restartWithTasks(client, conn)
+	})
+	pending := []string{}
+	for _, conn := range connectors {
+		if err := restarts.Results[conn].Error; err != "" {
+			report.Failures[conn] = err
+		} else {
+			pending = append(pending, conn)
+		}
+	}
+
+	// Tasks failing at startup would still be seen as running right after being restarted.
+	deadline := start.Add(timeout)
+	for len(pending) > 0 {
+		time.Sleep(ROLLING_RESTART_POLL_INTERVAL)
+		unhealthy := make(map[string]string)
+		for _, conn := range pending {
+			if reason := checkRunning(client, conn); reason != "" {
+				unhealthy[conn] = reason
+			}
+		}
+		if len(unhealthy) == 0 || time.Now().After(deadline) {
+			for conn, reason := range unhealthy {
+				report.Failures[conn] = "Not running after " + timeout.String() + ": " + reason
+			}
+			break
+		}
+		pending = pending[:0]
+		for conn := range unhealthy {
+			pending = append(pending, conn)
+		}
+	}
+	report.Duration = time.Since(start).Round(time.Millisecond).String()
+	return report
+}
+
+// restartWithTasks restarts a connector and its tasks. Workers older than 3.0 only restart the connector,
+// in which case tasks are restarted one by one.
+func restartWithTasks(client connect.Client, conn string) error {
+	status, e := client.RestartConnector(conn, connect.RestartOptions{IncludeTasks: true})
+	if e != nil || status.Name != "" {
+		return e
+	}
+	if status, e = client.Status(conn); e != nil {
+		return e
+	}
+	for _, task := range status.Tasks {
+		if e = client.Restart(conn, task.ID); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// checkRunning returns why a connector is not healthy, or an empty string if it and all its tasks are RUNNING.
+func checkRunning(client connect.Client, conn string) (reason string) {
+	defer func() {
+		if r := recover(); r != nil {
+			reason = fmt.Sprintf("%v", r)
+		}
+	}()
+	status, e := client.Status(conn)
+	if e != nil {
+		return strings.TrimSpace(e.Error())
+	}
+	states := []string{}
+	if status.Connector.State != "RUNNING" {
+		states = append(states, "connector is "+status.Connector.State)
+	}
+	for _, task := range status.Tasks {
+		if task.State != "RUNNING" {
+			states = append(states, fmt.Sprintf("task %d is %s", task.ID, task.State))
+		}
+	}
+	return strings.Join(states, ", ")
+}
@@ -0,0 +1,154 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"github.com/fhussonnois/kafkacli/connect"
+	"testing"
+	"time"
+)
+
+// failingClient reports the given connector as FAILED, as if it failed again right after each restart.
+type failingClient struct {
+	connect.Client
+	failed string
+}
+
+func (c failingClient) Status(connector string) (connect.ConnectorStatus, error) {
+	status, err := c.Client.Status(connector)
+	if connector == c.failed {
+		status.Connector.State = "FAILED"
+	}
+	return status, err
+}
+
+func TestHandleRollingRestartCommand(t *testing.T) {
+	server, client := newServer(t, "sink-a", "sink-b", "sink-c", "paused")
+	defer server.Close()
+	client.Pause("paused")
+
+	result, err := handleRollingRestartCommand(client, ".*", 2, 5, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	report := result.(RollingRestartReport)
+	if report.Total != 4 || report.Restarted != 3 || report.Failed != 0 || report.ExitCode() != 0 {
+		t.Errorf("expected 3 restarted connectors, got %v", report)
+	}
+	if len(report.Batches) != 2 || len(report.Batches[0].Connectors) != 2 || len(report.Batches[1].Connectors) != 1 {
+		t.Errorf("expected batches of 2 and 1 connectors, got %v", report.Batches)
+	}
+	if len(report.Skipped) != 1 || report.Skipped[0] != "paused" {
+		t.Errorf("expected [paused] to be skipped, got %v", report.Skipped)
+	}
+}
+
+func TestHandleRollingRestartCommandHalts(t *testing.T) {
+	server, client := newServer(t, "sink-a", "sink-b", "sink-c")
+	defer server.Close()
+
+	result, err := handleRollingRestartCommand(failingClient{client, "sink-a"}, ".*", 1, 1, 0)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	report := result.(RollingRestartReport)
+	if !report.Halted || report.Failed != 1 || report.Restarted != 0 || report.ExitCode() != 1 {
+		t.Errorf("expected the rolling restart to be halted after the failure of sink-a, got %v", report)
+	}
+	if _, ok := report.Batches[0].Failures["sink-a"]; !ok {
+		t.Errorf("expected sink-a to be reported as failed, got %v", report.Batches)
+	}
+	if len(report.Skipped) != 2 || report.Skipped[0] != "sink-b" || report.Skipped[1] != "sink-c" {
+		t.Errorf("expected [sink-b sink-c] to be skipped, got %v", report.Skipped)
+	}
+}
+
+func TestHandleRollingRestartCommandLimitsBatchesToMaxUnavailable(t *testing.T) {
+	server, client := newServer(t, "sink-a", "sink-b", "sink-c")
+	defer server.Close()
+
+	// sink-a uses up one of the 2 unavailable connectors allowed, so the next batches are of a single connector.
+	result, err := handleRollingRestartCommand(failingClient{client, "sink-a"}, ".*", 2, 2, 0)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	report := result.(RollingRestartReport)
+	if report.Halted || report.Failed != 1 || report.Restarted != 2 || report.ExitCode() != PARTIAL_FAILURE_EXIT_CODE {
+		t.Errorf("expected 2 restarted connectors and 1 failure, got %v", report)
+	}
+	if len(report.Batches) != 2 || len(report.Batches[1].Connectors) != 1 {
+		t.Errorf("expected batches of 2 and 1 connectors, got %v", report.Batches)
+	}
+}
+
+// recoveringClient reports the given connector as FAILED until a given time, as if it recovered on its own.
+type recoveringClient struct {
+	connect.Client
+	failed string
+	until  time.Time
+}
+
+func (c recoveringClient) Status(connector string) (connect.ConnectorStatus, error) {
+	status, err := c.Client.Status(connector)
+	if connector == c.failed && time.Now().Before(c.until) {
+		status.Connector.State = "FAILED"
+	}
+	return status, err
+}
+
+func TestHandleRollingRestartCommandCountsRecoveredConnectors(t *testing.T) {
+	server, client := newServer(t, "sink-a", "sink-b", "sink-c")
+	defer server.Close()
+
+	// sink-a is still failed after its batch, pausing the rolling restart until it recovers.
+	failing := recoveringClient{client, "sink-a", time.Now().Add(ROLLING_RESTART_POLL_INTERVAL * 3 / 2)}
+	result, err := handleRollingRestartCommand(failing, ".*", 1, 1, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	report := result.(RollingRestartReport)
+	if report.Halted || report.Restarted != 3 || report.Failed != 0 || report.ExitCode() != 0 {
+		t.Errorf("expected 3 restarted connectors, got %v", report)
+	}
+	if len(report.Recovered) != 1 || report.Recovered[0] != "sink-a" {
+		t.Errorf("expected sink-a to be recovered, got %v", report.Recovered)
+	}
+	if _, ok := report.Batches[0].Failures["sink-a"]; !ok {
+		t.Errorf("expected sink-a to be reported as failed in its batch, got %v", report.Batches)
+	}
+}
+
+// unrestartableClient fails to restart connectors.
+type unrestartableClient struct {
+	connect.Client
+}
+
+func (c unrestartableClient) RestartConnector(connector string, options connect.RestartOptions) (connect.ConnectorStatus, error) {
+	return connect.ConnectorStatus{}, errors.New("Internal Server Error")
+}
+
+func TestRestartBatchDoesNotWaitForFailedRestarts(t *testing.T) {
+	server, client := newServer(t, "sink-a")
+	defer server.Close()
+
+	start := time.Now()
+	report := restartBatch(unrestartableClient{client}, []string{"sink-a"}, time.Minute)
+	if report.Failures["sink-a"] != "Internal Server Error" {
+		t.Errorf("expected the restart of sink-a to fail, got %v", report.Failures)
+	}
+	if elapsed := time.Since(start); elapsed >= ROLLING_RESTART_POLL_INTERVAL {
+		t.Errorf("expected no wait as no connector has been restarted, waited %s", elapsed)
+	}
+}
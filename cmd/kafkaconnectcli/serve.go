@@ -0,0 +1,46 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"github.com/fhussonnois/kafkacli/connect/server"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// handleServeMockCommand executes "serve-mock" command. It never returns unless the worker fails to start.
+// With a clusterSize greater than 1, connectors and tasks are spread over emulated workers whose IDs follow
+// the one of the mock worker, e.g host:8084 and host:8085 for host:8083.
+func handleServeMockCommand(listen string, clusterSize int) error {
+	workerID := listen
+	host, port, err := net.SplitHostPort(listen)
+	if err == nil && host == "" {
+		host, _ = os.Hostname()
+		workerID = net.JoinHostPort(host, port)
+	}
+	worker := server.New(workerID)
+	if number, errPort := strconv.Atoi(port); err == nil && errPort == nil && clusterSize > 1 {
+		for i := 0; i < clusterSize; i++ {
+			worker.Members = append(worker.Members, net.JoinHostPort(host, strconv.Itoa(number+i)))
+		}
+		fmt.Fprintf(os.Stderr, "Emulating a cluster of %d workers: %s\n", clusterSize, strings.Join(worker.Members, ", "))
+	}
+	fmt.Fprintf(os.Stderr, "Mock Kafka Connect worker %s listening on %s\n", workerID, listen)
+	fmt.Fprintf(os.Stderr, "Inject failures with: curl -X PUT http://%s/%s/connectors/<name>/tasks/<id>/state -d '{\"state\":\"FAILED\",\"trace\":\"...\"}'\n", workerID, server.CONTROL)
+	return http.ListenAndServe(listen, worker)
+}
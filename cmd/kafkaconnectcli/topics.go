@@ -0,0 +1,49 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"github.com/fhussonnois/kafkacli/connect"
+	"regexp"
+	"sort"
+)
+
+// handleTopicsCommand executes "topics" command.
+// Return the active topics of each matching connector, or the connectors using each topic if byTopic is set.
+func handleTopicsCommand(client connect.Client, connector string, byTopic bool) (result interface{}, e error) {
+	connectRegex := regexp.MustCompile(connector)
+	matches, e := findMatchingConnectors(client, func(conn string) (bool, error) { return connectRegex.MatchString(conn), nil })
+	if e != nil {
+		return
+	}
+	connectors := make(map[string][]string)
+	topics := make(map[string][]string)
+	for _, conn := range matches {
+		active, err := client.Topics(conn)
+		if err != nil {
+			return nil, err
+		}
+		connectors[conn] = append([]string{}, active...)
+		for _, topic := range active {
+			topics[topic] = append(topics[topic], conn)
+		}
+	}
+	if !byTopic {
+		return connectors, nil
+	}
+	for _, conns := range topics {
+		sort.Strings(conns)
+	}
+	return topics, nil
+}
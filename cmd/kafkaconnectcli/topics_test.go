@@ -0,0 +1,57 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"github.com/fhussonnois/kafkacli/connect"
+	"reflect"
+	"testing"
+)
+
+func TestHandleTopicsCommand(t *testing.T) {
+	server, client := newServer(t, "sink-a", "sink-b")
+	defer server.Close()
+	config := connect.ConnectorConfig{Name: "source", Config: map[string]string{
+		"connector.class": "FileStreamSourceConnector",
+		"topic":           "lines",
+	}}
+	if _, err := client.Create(config); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	config.Config["topic"] = "events"
+	client.Update(config)
+
+	result, err := handleTopicsCommand(client, "sink-a|source", false)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	expected := map[string][]string{"sink-a": {"events"}, "source": {"events", "lines"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+
+	result, err = handleTopicsCommand(client, ".*", true)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	expected = map[string][]string{"events": {"sink-a", "sink-b", "source"}, "lines": {"source"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+
+	result, _ = handleTopicsCommand(client, "unknown", true)
+	if topics := result.(map[string][]string); len(topics) != 0 {
+		t.Errorf("expected no topic, got %v", topics)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"github.com/fhussonnois/kafkacli/connect"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"text/tabwriter"
+)
+
+// Output formats.
+const (
+	OUTPUT_JSON  = "json"
+	OUTPUT_TABLE = "table"
+	OUTPUT_TEXT  = "text"
+)
+
+// WorkerLoad describes the connectors and tasks assigned to a worker.
+type WorkerLoad struct {
+	WorkerID    string `json:"worker_id"`
+	Connectors  int    `json:"connectors"`
+	Tasks       int    `json:"tasks"`
+	FailedTasks int    `json:"failed_tasks"`
+}
+
+// WorkersReport describes how connectors and tasks are spread across workers.
+type WorkersReport struct {
+	Workers     []WorkerLoad `json:"workers"`
+	Connectors  int          `json:"connectors"`
+	Tasks       int          `json:"tasks"`
+	FailedTasks int          `json:"failed_tasks"`
+	// UnassignedConnectors and UnassignedTasks count the connectors and tasks which are not assigned to any worker,
+	// and which are therefore not part of the workers loads.
+	UnassignedConnectors int `json:"unassigned_connectors"`
+	UnassignedTasks      int `json:"unassigned_tasks"`
+	// Skew is the ratio between the maximum and the average number of tasks per worker, 1 meaning evenly spread.
+	Skew float64 `json:"skew"`
+}
+
+// handleWorkersCommand executes "workers" command.
+// Return a WorkersReport, or nil if it has been printed as a table.
+func handleWorkersCommand(client connect.Client, connector string, output string) (result interface{}, e error) {
+	connectRegex := regexp.MustCompile(connector)
+	connectors, e := client.ListExpanded(connect.EXPAND_STATUS)
+	if e != nil {
+		return
+	}
+	loads := make(map[string]*WorkerLoad)
+	load := func(workerID string) *WorkerLoad {
+		if _, ok := loads[workerID]; !ok {
+			loads[workerID] = &WorkerLoad{WorkerID: workerID}
+		}
+		return loads[workerID]
+	}
+	report := WorkersReport{Workers: []WorkerLoad{}}
+	for conn, expanded := range connectors {
+		if !connectRegex.MatchString(conn) {
+			continue
+		}
+		report.Connectors++
+		if isUnassigned(expanded.Status.Connector.State, expanded.Status.Connector.WorkerID) {
+			report.UnassignedConnectors++
+		} else {
+			load(expanded.Status.Connector.WorkerID).Connectors++
+		}
+		for _, task := range expanded.Status.Tasks {
+			report.Tasks++
+			if isUnassigned(task.State, task.WorkerID) {
+				report.UnassignedTasks++
+				continue
+			}
+			load(task.WorkerID).Tasks++
+			if task.State == "FAILED" {
+				report.FailedTasks++
+				load(task.WorkerID).FailedTasks++
+			}
+		}
+	}
+
+	maxTasks := 0
+	for _, l := range loads {
+		report.Workers = append(report.Workers, *l)
+		if l.Tasks > maxTasks {
+			maxTasks = l.Tasks
+		}
+	}
+	sort.Slice(report.Workers, func(i, j int) bool { return report.Workers[i].WorkerID < report.Workers[j].WorkerID })
+	if assigned := report.Tasks - report.UnassignedTasks; assigned > 0 {
+		average := float64(assigned) / float64(len(report.Workers))
+		report.Skew = math.Round(float64(maxTasks)/average*100) / 100
+	}
+
+	if output == OUTPUT_TABLE {
+		printWorkersTable(report)
+		return nil, nil
+	}
+	return report, nil
+}
+
+// isUnassigned checks if a connector or a task is not assigned to any worker. The worker of an UNASSIGNED connector or
+// task, if any, is the one it was last assigned to.
+func isUnassigned(state string, workerID string) bool {
+	return state == "UNASSIGNED" || workerID == ""
+}
+
+// printWorkersTable prints a WorkersReport as a table.
+func printWorkersTable(report WorkersReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WORKER\tCONNECTORS\tTASKS\tFAILED TASKS")
+	for _, l := range report.Workers {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", l.WorkerID, l.Connectors, l.Tasks, l.FailedTasks)
+	}
+	if report.UnassignedConnectors > 0 || report.UnassignedTasks > 0 {
+		fmt.Fprintf(w, "UNASSIGNED\t%d\t%d\t-\n", report.UnassignedConnectors, report.UnassignedTasks)
+	}
+	fmt.Fprintf(w, "TOTAL\t%d\t%d\t%d\n", report.Connectors, report.Tasks, report.FailedTasks)
+	w.Flush()
+	fmt.Printf("\nSkew: %.2f (maximum / average number of tasks per worker, 1 meaning evenly spread)\n", report.Skew)
+}
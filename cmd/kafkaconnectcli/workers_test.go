@@ -0,0 +1,94 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"github.com/fhussonnois/kafkacli/connect/connecttest"
+	"testing"
+)
+
+func TestHandleWorkersCommand(t *testing.T) {
+	server := connecttest.NewServer()
+	defer server.Close()
+	server.Members = []string{"worker-1:8083", "worker-2:8083"}
+	client := server.Client()
+	// The connector and its tasks are assigned in a round-robin fashion: worker-1, worker-2, worker-1, worker-2.
+	client.Create(connecttest.SinkConnector("sink", 3))
+	server.SetTaskState("sink", 1, connecttest.FAILED, "")
+	server.SetTaskState("sink", 2, connecttest.UNASSIGNED, "")
+
+	result, err := handleWorkersCommand(client, ".*", OUTPUT_JSON)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	report := result.(WorkersReport)
+	if report.Connectors != 1 || report.Tasks != 3 || report.FailedTasks != 1 {
+		t.Errorf("expected 1 connector, 3 tasks and 1 failed task, got %v", report)
+	}
+	if report.UnassignedConnectors != 0 || report.UnassignedTasks != 1 {
+		t.Errorf("expected 1 unassigned task, got %v", report)
+	}
+	expected := []WorkerLoad{
+		{WorkerID: "worker-1:8083", Connectors: 1, Tasks: 1, FailedTasks: 1},
+		{WorkerID: "worker-2:8083", Tasks: 1},
+	}
+	if len(report.Workers) != len(expected) {
+		t.Fatalf("expected loads %v, got %v", expected, report.Workers)
+	}
+	for i := range expected {
+		if report.Workers[i] != expected[i] {
+			t.Errorf("expected loads %v, got %v", expected, report.Workers)
+		}
+	}
+	// The unassigned task is not part of the skew.
+	if report.Skew != 1 {
+		t.Errorf("expected skew 1, got %v", report.Skew)
+	}
+}
+
+func TestHandleWorkersCommandSkew(t *testing.T) {
+	server := connecttest.NewServer()
+	defer server.Close()
+	server.Members = []string{"worker-1:8083", "worker-2:8083"}
+	client := server.Client()
+	// Connector on worker-1, tasks on worker-2, worker-1, worker-2, worker-1, worker-2.
+	client.Create(connecttest.SinkConnector("sink", 5))
+
+	result, err := handleWorkersCommand(client, ".*", OUTPUT_JSON)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if skew := result.(WorkersReport).Skew; skew != 1.2 {
+		t.Errorf("expected skew 1.2, got %v", skew)
+	}
+}
+
+func TestHandleWorkersCommandFiltersConnectors(t *testing.T) {
+	server, client := newServer(t, "sink-a", "other")
+	defer server.Close()
+
+	result, err := handleWorkersCommand(client, "sink-.*", OUTPUT_JSON)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	report := result.(WorkersReport)
+	if report.Connectors != 1 || report.Tasks != 2 || len(report.Workers) != 1 || report.Workers[0].WorkerID != server.WorkerID {
+		t.Errorf("expected the connector and the 2 tasks of sink-a on a single worker, got %v", report)
+	}
+
+	result, _ = handleWorkersCommand(client, "unknown", OUTPUT_JSON)
+	if report := result.(WorkersReport); len(report.Workers) != 0 || report.Skew != 0 {
+		t.Errorf("expected an empty report, got %v", report)
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fhussonnois/kafkacli/registry"
+	"github.com/fhussonnois/kafkacli/registry/serde"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode"
+)
+
+const (
+	ENCODING_HEX    = "hex"
+	ENCODING_BASE64 = "base64"
+	ENCODING_RAW    = "raw"
+)
+
+// Encodings lists the encodings of the messages read by "decode" and written by "encode".
+var Encodings = []string{ENCODING_HEX, ENCODING_BASE64, ENCODING_RAW}
+
+// handleDecodeCommand executes "decode" command.
+// The writer schema is retrieved from the registry using the ID written in the message header.
+func handleDecodeCommand(client registry.Client, args CommandArgs) (res *serde.Message, e error) {
+	input, e := readInput(*args.input)
+	if e != nil {
+		return
+	}
+	data, e := decodeBytes(input, *args.encoding)
+	if e != nil {
+		return
+	}
+	return serde.NewDeserializer(client).Deserialize(data)
+}
+
+// handleEncodeCommand executes "encode" command.
+// The JSON value is encoded using the schema of the specified subject version and written to the standard output.
+func handleEncodeCommand(client registry.Client, args CommandArgs) error {
+	input, err := readInput(*args.input)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return errors.New("Invalid JSON value: " + err.Error())
+	}
+	version, err := client.GetSubjectVersion(*args.subject, *args.version)
+	if err != nil {
+		return err
+	}
+	data, err := serde.SerializeVersion(client, version, value)
+	if err != nil {
+		return err
+	}
+	switch *args.encoding {
+	case ENCODING_HEX:
+		fmt.Println(hex.EncodeToString(data))
+	case ENCODING_BASE64:
+		fmt.Println(base64.StdEncoding.EncodeToString(data))
+	default:
+		_, err = os.Stdout.Write(data)
+	}
+	return err
+}
+
+// readInput reads the content of a file, or the standard input for "-".
+func readInput(input string) ([]byte, error) {
+	if input == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	content, err := ioutil.ReadFile(input)
+	if err != nil {
+		return nil, errors.New("Error while reading input file " + input + " error: " + err.Error())
+	}
+	return content, nil
+}
+
+// decodeBytes decodes an hex or base64 dump, ignoring whitespaces. Raw data is returned as is.
+func decodeBytes(input []byte, encoding string) ([]byte, error) {
+	dump := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, string(input))
+	switch encoding {
+	case ENCODING_HEX:
+		return hex.DecodeString(strings.TrimPrefix(dump, "0x"))
+	case ENCODING_BASE64:
+		return base64.StdEncoding.DecodeString(dump)
+	}
+	return input, nil
+}
@@ -0,0 +1,135 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/fhussonnois/kafkacli/registry"
+	"github.com/fhussonnois/kafkacli/registry/registrytest"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecodeBytes(t *testing.T) {
+	tests := []struct {
+		input    string
+		encoding string
+		expected []byte
+	}{
+		{"00 00 00 00 01 54", ENCODING_HEX, []byte{0, 0, 0, 0, 1, 0x54}},
+		{"0x0000000001\n54\n", ENCODING_HEX, []byte{0, 0, 0, 0, 1, 0x54}},
+		{"AAAAAAFU\n", ENCODING_BASE64, []byte{0, 0, 0, 0, 1, 0x54}},
+		{"\x00\x01 ", ENCODING_RAW, []byte{0, 1, ' '}},
+	}
+	for _, test := range tests {
+		actual, err := decodeBytes([]byte(test.input), test.encoding)
+		if err != nil || !bytes.Equal(actual, test.expected) {
+			t.Errorf("expected %v for %q (%s), got %v (%v)", test.expected, test.input, test.encoding, actual, err)
+		}
+	}
+	if _, err := decodeBytes([]byte("0g"), ENCODING_HEX); err == nil {
+		t.Error("expected an error for an invalid hex dump")
+	}
+}
+
+// runEncode executes "encode" and returns what it has written to the standard output.
+func runEncode(t *testing.T, client registry.Client, input string, encoding string) (string, error) {
+	parser := NewArgParser("encode")
+	parser.withSubjectArg().withVersionArg().withCodecArg("JSON value")
+	args := parser.parse([]string{"-subject", "users-value", "-version", "latest", "-input", input, "-encoding", encoding})
+
+	out, err := ioutil.TempFile("", "encoded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(out.Name())
+	stdout := os.Stdout
+	os.Stdout = out
+	err = handleEncodeCommand(client, args)
+	os.Stdout = stdout
+	out.Close()
+	content, _ := ioutil.ReadFile(out.Name())
+	return string(content), err
+}
+
+func TestEncodeDecode(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	client.Register("orders-value", registry.Schema{Value: order})
+	client.Register("users-value", registry.Schema{Value: userV1})
+	dir, err := ioutil.TempDir("", "codec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	value := filepath.Join(dir, "value.json")
+	ioutil.WriteFile(value, []byte(`{"id": 42}`), 0644)
+
+	for _, encoding := range []string{ENCODING_HEX, ENCODING_BASE64} {
+		encoded, err := runEncode(t, client, value, encoding)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", encoding, err)
+		}
+		message := filepath.Join(dir, "message."+encoding)
+		ioutil.WriteFile(message, []byte(encoded), 0644)
+
+		parser := NewArgParser("decode")
+		parser.withCodecArg("message")
+		decoded, err := handleDecodeCommand(client, parser.parse([]string{"-input", message, "-encoding", encoding}))
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", encoding, err)
+		}
+		// The message is written with the ID of the latest version of users-value.
+		if decoded.SchemaID != 2 {
+			t.Errorf("%s: expected schema ID 2, got %d", encoding, decoded.SchemaID)
+		}
+		if actual, _ := json.Marshal(decoded.Value); string(actual) != `{"id":42}` {
+			t.Errorf("%s: expected value {\"id\":42}, got %s", encoding, actual)
+		}
+	}
+	// Magic byte, schema ID 2 and 42 as a zig-zag varint.
+	if encoded, _ := runEncode(t, client, value, ENCODING_HEX); encoded != "000000000254\n" {
+		t.Errorf("expected hex message 000000000254, got %q", encoded)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	client.Register("users-value", registry.Schema{Value: userV1})
+	dir, err := ioutil.TempDir("", "codec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	invalid := filepath.Join(dir, "invalid.json")
+	ioutil.WriteFile(invalid, []byte(`{"id": `), 0644)
+	if _, err := runEncode(t, client, invalid, ENCODING_HEX); err == nil {
+		t.Error("expected an error for an invalid JSON value")
+	}
+	mismatch := filepath.Join(dir, "mismatch.json")
+	ioutil.WriteFile(mismatch, []byte(`{"id": "a"}`), 0644)
+	if _, err := runEncode(t, client, mismatch, ENCODING_HEX); err == nil {
+		t.Error("expected an error for a value which does not match the schema")
+	}
+	if _, err := runEncode(t, client, filepath.Join(dir, "missing.json"), ENCODING_HEX); err == nil {
+		t.Error("expected an error for a missing input file")
+	}
+}
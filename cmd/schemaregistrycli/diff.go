@@ -0,0 +1,103 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fhussonnois/kafkacli/registry"
+	"github.com/fhussonnois/kafkacli/registry/avro"
+)
+
+// SchemaDiff is the result of the "diff" command.
+type SchemaDiff struct {
+	From    string        `json:"from"`
+	To      string        `json:"to"`
+	Changes []avro.Change `json:"changes"`
+}
+
+// handleDiffCommand executes "diff" command.
+func handleDiffCommand(client registry.Client, args CommandArgs) (res SchemaDiff, e error) {
+	from, label, e := readDiffSchema(client, *args.subject, *args.fromVersion, *args.fromJson, *args.fromUrl)
+	if e != nil {
+		return
+	}
+	res.From = label
+	to, label, e := readDiffSchema(client, *args.subject, *args.toVersion, *args.toJson, *args.toUrl)
+	if e != nil {
+		return
+	}
+	res.To = label
+	res.Changes = avro.Diff(from, to)
+	if res.Changes == nil {
+		res.Changes = []avro.Change{}
+	}
+	return
+}
+
+// readDiffSchema reads one side of a diff either from a file, an URL or a subject version.
+// Return the parsed schema and a label describing where it comes from.
+func readDiffSchema(client registry.Client, subject string, version string, file string, url string) (*avro.Schema, string, error) {
+	var reader SchemaReader
+	var source string
+	switch {
+	case file != "":
+		reader, source = FileSchemaReader{}, file
+	case url != "":
+		reader, source = HTTPSchemaReader{}, url
+	case subject != "" && version != "":
+		reader, source = subjectVersionReader{client: client, subject: subject}, version
+	default:
+		return nil, "", errors.New("Missing schema to compare, either a subject version or a file/url must be set")
+	}
+	schema, err := reader.Read(source)
+	if err != nil {
+		return nil, "", err
+	}
+	parsed, err := avro.Parse(schema.Value)
+	if err != nil {
+		return nil, "", errors.New("Invalid schema " + source + ": " + err.Error())
+	}
+	if _, ok := reader.(subjectVersionReader); ok {
+		source = subject + " (version " + source + ")"
+	}
+	return parsed, source, nil
+}
+
+// subjectVersionReader implementation to read Schema from a subject version.
+type subjectVersionReader struct {
+	client  registry.Client
+	subject string
+}
+
+func (reader subjectVersionReader) Read(version string) (*registry.Schema, error) {
+	res, err := reader.client.GetSubjectVersion(reader.subject, version)
+	if err != nil {
+		return nil, err
+	}
+	return &registry.Schema{Value: res.Schema}, nil
+}
+
+// printDiff prints the changes between two schemas in a human-readable form.
+func printDiff(diff SchemaDiff) {
+	fmt.Printf("--- %s\n+++ %s\n", diff.From, diff.To)
+	if len(diff.Changes) == 0 {
+		fmt.Println("No changes.")
+		return
+	}
+	for _, change := range diff.Changes {
+		fmt.Printf("%-22s %-30s %s\n", change.Kind, change.Path, change.Message)
+	}
+	fmt.Printf("%d change(s).\n", len(diff.Changes))
+}
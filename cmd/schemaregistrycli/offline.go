@@ -0,0 +1,91 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"github.com/fhussonnois/kafkacli/registry/avro"
+	"strings"
+)
+
+// OfflineCompatibility is the result of a compatibility check performed without a schema registry.
+type OfflineCompatibility struct {
+	IsCompatible bool                     `json:"is_compatible"`
+	Level        string                   `json:"level"`
+	Messages     []OfflineIncompatibility `json:"messages,omitempty"`
+}
+
+// OfflineIncompatibility describes an incompatibility with a previous schema file.
+type OfflineIncompatibility struct {
+	Against  string `json:"against"`
+	Type     string `json:"type"`
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+// handleOfflineTestCommand executes "test -offline" command.
+// The new schema is read either from the schema arguments or from the first positional argument.
+func handleOfflineTestCommand(args CommandArgs, positional []string) (res OfflineCompatibility, e error) {
+	var text string
+	switch {
+	case *args.schemaString != "" || *args.schemaJson != "" || *args.schemaUrl != "":
+		schema, err := evaluateSchemaArg(args)
+		if err != nil {
+			return res, err
+		}
+		text = schema.Value
+	case len(positional) > 0:
+		schema, err := readSchemaSource(positional[0])
+		if err != nil {
+			return res, err
+		}
+		text = schema.Value
+	default:
+		return res, errors.New("Missing new schema to test, either as argument or using [schema | schema.json | schema.url]")
+	}
+	schema, e := avro.Parse(text)
+	if e != nil {
+		return res, errors.New("Invalid new schema: " + e.Error())
+	}
+
+	files := strings.Split(*args.against, ",")
+	previous := make([]*avro.Schema, 0, len(files))
+	for _, file := range files {
+		source, err := readSchemaSource(strings.TrimSpace(file))
+		if err != nil {
+			return res, err
+		}
+		parsed, err := avro.Parse(source.Value)
+		if err != nil {
+			return res, errors.New("Invalid schema " + file + ": " + err.Error())
+		}
+		previous = append(previous, parsed)
+	}
+
+	incompatibilities, e := avro.CheckCompatibility(*args.compatibility, schema, previous)
+	if e != nil {
+		return
+	}
+	res.Level = *args.compatibility
+	res.IsCompatible = len(incompatibilities) == 0
+	for _, i := range incompatibilities {
+		res.Messages = append(res.Messages, OfflineIncompatibility{
+			Against:  strings.TrimSpace(files[i.Previous]),
+			Type:     i.Type,
+			Location: i.Location,
+			Message:  i.Message,
+		})
+	}
+	return
+}
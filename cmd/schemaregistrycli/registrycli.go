@@ -15,37 +15,59 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"github.com/fhussonnois/kafkacli/registry"
+	"github.com/fhussonnois/kafkacli/registry/avro"
+	"github.com/fhussonnois/kafkacli/registry/codegen"
 	"github.com/fhussonnois/kafkacli/utils"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"os/user"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var Commands = map[string]string{
 
-	"compatibility": "Getting subject compatibility level for a subject.",
-	"exist":         "Checking if a schema has already been registered under the specified subject",
-	"get":           "Getting a specific version of the schema registered under this subject",
+	"compatibility":        "Getting subject compatibility level for a subject.",
+	"codegen":              "Generating Go types from the schema of a subject version or from a schema file.",
+	"decode":               "Decoding a message in the Confluent wire format (magic byte, schema ID, payload) to JSON.",
+	"diff":                 "Showing the changes (fields, types, defaults, docs, enums, unions) between two schemas.",
+	"encode":               "Encoding a JSON value with the schema of a subject version into the Confluent wire format.",
+	"exist":                "Checking if a schema has already been registered under the specified subject",
+	"export":               "Exporting all subjects, versions and compatibility levels into a directory.",
+	"fingerprint":          "Computing the Parsing Canonical Form and the fingerprints (CRC-64-AVRO, MD5, SHA-256) of a schema.",
+	"get":                  "Getting a specific version of the schema registered under this subject",
 	"global-compatibility": "Getting the global compatibility level.",
+	"import":               "Importing subjects, versions and compatibility levels from a directory (preserving IDs in IMPORT mode).",
+	"mode":                 "Getting (get) or setting (set) the mode of the registry or of a subject.",
 	"register":             "Registering a new schema under the specified subject.",
+	"serve":                "Serving a lightweight local schema registry, optionally persisted into a directory.",
 	"set-compatibility":    "Setting a new compatibility level.",
 	"subjects":             "Getting the list of registered subjects.",
+	"sync":                 "Synchronizing the versions of subjects from a registry to another one, either once or continuously.",
 	"test":                 "Testing schemas for compatibility against specific versions of a subject’s schema.",
+	"validate-data":        "Validating JSON records (e.g JSON lines) against the Avro or JSON schema of a subject version.",
 	"versions":             "Getting a list of versions registered under the specified subject.",
 }
 
+// SubCommands lists the actions accepted by commands of the form "command action [arguments]".
+var SubCommands = map[string][]string{
+	"mode": {"get", "set"},
+}
+
 // Display commands usage and exit with return code 1.
 func usage() {
-	fmt.Println("A simple Command line interface (CLI) to manage Confluent Schema Registry.\n")
+	fmt.Print("A simple Command line interface (CLI) to manage Confluent Schema Registry.\n\n")
 	fmt.Fprintf(os.Stdin, "Usage of %s: command [arguments] \n", os.Args[0])
-	fmt.Println("The commands are : \n")
+	fmt.Print("The commands are : \n\n")
 	keys := []string{}
 	for k := range Commands {
 		keys = append(keys, k)
@@ -78,6 +100,32 @@ type CommandArgs struct {
 	schemaUrl     *string
 	compatibility *string
 	force         *bool
+	mode          *string
+	schemaId      *int
+	schemaVersion *int
+	against       *string
+	normalize     *bool
+	fromVersion   *string
+	fromJson      *string
+	fromUrl       *string
+	toVersion     *string
+	toJson        *string
+	toUrl         *string
+	output        *string
+	dir           *string
+	sourceUrl     *string
+	targetUrl     *string
+	interval      *time.Duration
+	once          *bool
+	lang          *string
+	pkg           *string
+	out           *string
+	input         *string
+	encoding      *string
+	cache         *bool
+	cacheTtl      *time.Duration
+	cacheStats    *bool
+	listen        *string
 }
 
 type ArgParser struct {
@@ -174,6 +222,11 @@ func (p *ArgParser) withSchemaArg() *ArgParser {
 	return p
 }
 
+func (p *ArgParser) withNormalizeArg() *ArgParser {
+	p.Args.normalize = p.Flag.Bool("normalize", false, "Normalize the Avro schema (full names, attributes order, no whitespaces) before sending it.")
+	return p
+}
+
 func (p *ArgParser) withIsSchemaArg() *ArgParser {
 	p.Args.isSchema = p.Flag.Bool("schema", false, "Retrieve only the json schema from the version.")
 	return p
@@ -184,6 +237,96 @@ func (p *ArgParser) withForceArg() *ArgParser {
 	return p
 }
 
+func (p *ArgParser) withOptionalSubjectArg() *ArgParser {
+	p.Args.subject = p.Flag.String("subject", "", "The name of the subject. If not set, the global value is used.")
+	return p
+}
+
+func (p *ArgParser) withModeArg() *ArgParser {
+	values := []string{registry.MODE_READWRITE, registry.MODE_READONLY, registry.MODE_IMPORT}
+	p.Args.mode = p.Flag.String("mode", "", "The new mode. Must be one of "+strings.Join(values, ",")+" (Required)")
+	p.Args.force = p.Flag.Bool("force", false, "Force the IMPORT mode even if schemas have already been registered.")
+	p.addValidators(CheckNotNull{name: "mode", arg: func(args CommandArgs) string { return *args.mode }})
+	p.addValidators(CheckValueIn{name: "mode", arg: func(args CommandArgs) string { return *args.mode }, values: values})
+	return p
+}
+
+func (p *ArgParser) withImportArg() *ArgParser {
+	p.Args.schemaId = p.Flag.Int("id", 0, "The ID of the schema. Requires the registry or subject to be in IMPORT mode.")
+	p.Args.schemaVersion = p.Flag.Int("version", 0, "The version of the schema. Requires the registry or subject to be in IMPORT mode.")
+	return p
+}
+
+func (p *ArgParser) withOfflineArg() *ArgParser {
+	p.Flag.Bool("offline", false, "Check compatibility locally, without any schema registry.")
+	p.Args.against = p.Flag.String("against", "", "<files> Comma-separated list of previous schema files, from the oldest to the latest (Required).")
+	p.addValidators(CheckNotNull{name: "against", arg: func(args CommandArgs) string { return *args.against }})
+	p.Args.compatibility = p.Flag.String("level", avro.BACKWARD, "The compatibility level. Must be one of "+strings.Join(avro.CompatibilityLevels, ","))
+	p.addValidators(CheckValueIn{name: "level", arg: func(args CommandArgs) string { return *args.compatibility }, values: avro.CompatibilityLevels})
+	return p
+}
+
+func (p *ArgParser) withDiffArg() *ArgParser {
+	p.Args.fromVersion = p.Flag.String("from", "", "The version of the subject to compare from, or the string \"latest\".")
+	p.Args.fromJson = p.Flag.String("from.json", "", "<file> The Avro schema json file to compare from.")
+	p.Args.fromUrl = p.Flag.String("from.url", "", "<url> The Avro schema json url to compare from.")
+	p.Args.toVersion = p.Flag.String("to", DEFAULT_VERSION, "The version of the subject to compare to, or the string \"latest\".")
+	p.Args.toJson = p.Flag.String("to.json", "", "<file> The Avro schema json file to compare to.")
+	p.Args.toUrl = p.Flag.String("to.url", "", "<url> The Avro schema json url to compare to.")
+	return p
+}
+
+func (p *ArgParser) withOutputArg(def string, values ...string) *ArgParser {
+	p.Args.output = p.Flag.String("output", def, "The output format. Must be one of "+strings.Join(values, ","))
+	p.addValidators(CheckValueIn{name: "output", arg: func(args CommandArgs) string { return *args.output }, values: values})
+	return p
+}
+
+func (p *ArgParser) withDirArg(name string, usage string) *ArgParser {
+	p.Args.dir = p.Flag.String(name, "", usage)
+	p.addValidators(CheckNotNull{name: name, arg: func(args CommandArgs) string { return *args.dir }})
+	return p
+}
+
+func (p *ArgParser) withSyncArg() *ArgParser {
+	p.Args.sourceUrl = p.Flag.String("from", "", "<url> The source schema registry url, e.g http://localhost:8081 (Required).")
+	p.Args.targetUrl = p.Flag.String("to", "", "<url> The target schema registry url (Required).")
+	p.Args.subject = p.Flag.String("subject", ".*", "The regex of the subjects to synchronize.")
+	p.Args.interval = p.Flag.Duration("interval", 30*time.Second, "The interval between two synchronizations.")
+	p.Args.once = p.Flag.Bool("once", false, "Synchronize only once and exit.")
+	p.addValidators(CheckNotNull{name: "from", arg: func(args CommandArgs) string { return *args.sourceUrl }})
+	p.addValidators(CheckNotNull{name: "to", arg: func(args CommandArgs) string { return *args.targetUrl }})
+	return p
+}
+
+func (p *ArgParser) withCodegenArg() *ArgParser {
+	p.Args.lang = p.Flag.String("lang", codegen.LANG_GO, "The target language. Must be one of "+strings.Join(codegen.Languages, ","))
+	p.Args.pkg = p.Flag.String("package", "", "The name of the generated package (Required).")
+	p.Args.out = p.Flag.String("out", "", "<file> The file to write the generated code to, instead of the standard output.")
+	p.addValidators(CheckValueIn{name: "lang", arg: func(args CommandArgs) string { return *args.lang }, values: codegen.Languages})
+	p.addValidators(CheckNotNull{name: "package", arg: func(args CommandArgs) string { return *args.pkg }})
+	return p
+}
+
+func (p *ArgParser) withCodecArg(input string) *ArgParser {
+	p.Args.input = p.Flag.String("input", "-", "<file> The "+input+" to read, or \"-\" for the standard input.")
+	p.Args.encoding = p.Flag.String("encoding", ENCODING_HEX, "The encoding of the message. Must be one of "+strings.Join(Encodings, ","))
+	p.addValidators(CheckValueIn{name: "encoding", arg: func(args CommandArgs) string { return *args.encoding }, values: Encodings})
+	return p
+}
+
+func (p *ArgParser) withDataArg() *ArgParser {
+	p.Args.input = p.Flag.String("data", "", "<file> The JSON records to validate, or \"-\" for the standard input (Required).")
+	p.addValidators(CheckNotNull{name: "data", arg: func(args CommandArgs) string { return *args.input }})
+	return p
+}
+
+func (p *ArgParser) withServeArg() *ArgParser {
+	p.Args.listen = p.Flag.String("listen", ":"+DEFAULT_PORT, "The address to listen on.")
+	p.Args.dir = p.Flag.String("data", "", "<dir> The directory to save the registry into. If not set, data are kept in memory.")
+	return p
+}
+
 func (p *ArgParser) withCompatibilityArg() *ArgParser {
 	values := []string{"NONE", "FULL", "FORWARD", "BACKWARD"}
 	p.Args.compatibility = p.Flag.String("level", "", "The new compatibility level. Must be one of "+strings.Join(values, ",")+" (Required)")
@@ -197,8 +340,15 @@ func (p *ArgParser) parse(args []string) CommandArgs {
 	return p.Args
 }
 
+func (p *ArgParser) withCacheArg() *ArgParser {
+	p.Args.cache = p.Flag.Bool("cache", false, "Cache the registry responses, immutable schemas by ID being stored under ~/.kafkacli/cache.")
+	p.Args.cacheTtl = p.Flag.Duration("cache.ttl", time.Minute, "The time to live of cached latest versions, subjects, compatibility levels and modes.")
+	p.Args.cacheStats = p.Flag.Bool("cache.stats", false, "Print the cache statistics to the standard error.")
+	return p
+}
+
 func (p *ArgParser) withCommonArgs() *ArgParser {
-	p.withHostArg().withPortArg().withPrettyArg()
+	p.withHostArg().withPortArg().withPrettyArg().withCacheArg()
 	return p
 }
 
@@ -215,10 +365,40 @@ func main() {
 	SubjectArgParser.withCommonArgs().withSubjectArg()
 
 	RegisterArgParser := NewArgParser("RegisterArgParser")
-	RegisterArgParser.withCommonArgs().withSubjectArg().withSchemaArg().withForceArg()
+	RegisterArgParser.withCommonArgs().withSubjectArg().withSchemaArg().withForceArg().withImportArg().withNormalizeArg()
 
 	ExistArgParser := NewArgParser("ExistArgParser")
-	ExistArgParser.withCommonArgs().withSubjectArg().withSchemaArg()
+	ExistArgParser.withCommonArgs().withSubjectArg().withSchemaArg().withNormalizeArg()
+
+	CodegenArgParser := NewArgParser("CodegenArgParser")
+	CodegenArgParser.withCommonArgs().withSchemaArg().withOptionalSubjectArg().withVersionArg().withCodegenArg()
+
+	DecodeArgParser := NewArgParser("DecodeArgParser")
+	DecodeArgParser.withCommonArgs().withCodecArg("message")
+
+	EncodeArgParser := NewArgParser("EncodeArgParser")
+	EncodeArgParser.withCommonArgs().withSubjectArg().withVersionArg().withCodecArg("JSON value")
+
+	ValidateDataArgParser := NewArgParser("ValidateDataArgParser")
+	ValidateDataArgParser.withCommonArgs().withSubjectArg().withVersionArg().withDataArg()
+
+	DiffArgParser := NewArgParser("DiffArgParser")
+	DiffArgParser.withCommonArgs().withOptionalSubjectArg().withDiffArg().withOutputArg("text", "text", "json")
+
+	ExportArgParser := NewArgParser("ExportArgParser")
+	ExportArgParser.withCommonArgs().withDirArg("out", "<dir> The directory to export the registry to. (Required)")
+
+	ImportArgParser := NewArgParser("ImportArgParser")
+	ImportArgParser.withCommonArgs().withDirArg("in", "<dir> The directory to import the registry from. (Required)")
+
+	ServeArgParser := NewArgParser("ServeArgParser")
+	ServeArgParser.withServeArg()
+
+	SyncArgParser := NewArgParser("SyncArgParser")
+	SyncArgParser.withPrettyArg().withSyncArg()
+
+	FingerprintArgParser := NewArgParser("FingerprintArgParser")
+	FingerprintArgParser.withCommonArgs().withSchemaArg().withOptionalSubjectArg().withVersionArg()
 
 	SchemaArgParser := NewArgParser("SchemaArgParser")
 	SchemaArgParser.withCommonArgs().withSubjectArg().withVersionArg().withIsSchemaArg()
@@ -229,7 +409,16 @@ func main() {
 	TestCompatibilityArgParser := NewArgParser("TestCompatibilityArgParser")
 	TestCompatibilityArgParser.withCommonArgs().withSubjectArg().withVersionArg().withSchemaArg()
 
-	command := os.Args[1]
+	OfflineTestArgParser := NewArgParser("OfflineTestArgParser")
+	OfflineTestArgParser.withCommonArgs().withSchemaArg().withOfflineArg()
+
+	ModeArgParser := NewArgParser("ModeArgParser")
+	ModeArgParser.withCommonArgs().withOptionalSubjectArg()
+
+	SetModeArgParser := NewArgParser("SetModeArgParser")
+	SetModeArgParser.withCommonArgs().withOptionalSubjectArg().withModeArg()
+
+	command, commandArgs := resolveCommand(os.Args[1:])
 	var commandArgParser ArgParser
 	switch command {
 	case "subjects", "global-compatibility":
@@ -245,7 +434,35 @@ func main() {
 	case "register":
 		commandArgParser = RegisterArgParser
 	case "test":
-		commandArgParser = TestCompatibilityArgParser
+		if hasFlag(commandArgs, "offline") {
+			commandArgParser = OfflineTestArgParser
+		} else {
+			commandArgParser = TestCompatibilityArgParser
+		}
+	case "codegen":
+		commandArgParser = CodegenArgParser
+	case "decode":
+		commandArgParser = DecodeArgParser
+	case "encode":
+		commandArgParser = EncodeArgParser
+	case "validate-data":
+		commandArgParser = ValidateDataArgParser
+	case "diff":
+		commandArgParser = DiffArgParser
+	case "export":
+		commandArgParser = ExportArgParser
+	case "import":
+		commandArgParser = ImportArgParser
+	case "serve":
+		commandArgParser = ServeArgParser
+	case "sync":
+		commandArgParser = SyncArgParser
+	case "fingerprint":
+		commandArgParser = FingerprintArgParser
+	case "mode get":
+		commandArgParser = ModeArgParser
+	case "mode set":
+		commandArgParser = SetModeArgParser
 	case "help":
 		if len(os.Args) < 3 {
 			usage()
@@ -267,6 +484,33 @@ func main() {
 			CompatibilityArgParser.Flag.PrintDefaults()
 		case "test":
 			TestCompatibilityArgParser.Flag.PrintDefaults()
+			fmt.Println("\nThe arguments of 'test -offline [arguments] <file>' are :")
+			OfflineTestArgParser.Flag.PrintDefaults()
+		case "codegen":
+			CodegenArgParser.Flag.PrintDefaults()
+		case "decode":
+			DecodeArgParser.Flag.PrintDefaults()
+		case "encode":
+			EncodeArgParser.Flag.PrintDefaults()
+		case "validate-data":
+			ValidateDataArgParser.Flag.PrintDefaults()
+		case "diff":
+			DiffArgParser.Flag.PrintDefaults()
+		case "export":
+			ExportArgParser.Flag.PrintDefaults()
+		case "import":
+			ImportArgParser.Flag.PrintDefaults()
+		case "serve":
+			ServeArgParser.Flag.PrintDefaults()
+		case "sync":
+			SyncArgParser.Flag.PrintDefaults()
+		case "fingerprint":
+			FingerprintArgParser.Flag.PrintDefaults()
+		case "mode":
+			fmt.Println("\nThe arguments of 'mode get' are :")
+			ModeArgParser.Flag.PrintDefaults()
+			fmt.Println("\nThe arguments of 'mode set' are :")
+			SetModeArgParser.Flag.PrintDefaults()
 		default:
 			fmt.Fprint(os.Stderr, "Unknown help command `"+subCommand+"`.  Run '"+os.Args[0]+" help'.\n")
 		}
@@ -275,10 +519,13 @@ func main() {
 		usage()
 	}
 
-	args := commandArgParser.parse(os.Args[2:])
+	args := commandArgParser.parse(commandArgs)
 	commandArgParser.Validates()
 
-	client := registry.NewRegistryClient(*args.host, *args.port)
+	var client registry.Client
+	if args.host != nil {
+		client = newClient(args)
+	}
 
 	if CommonArgParser.Flag.Parsed() {
 		switch command {
@@ -304,7 +551,13 @@ func main() {
 	if RegisterArgParser.Flag.Parsed() {
 		switch command {
 		case "register":
-			schema := evaluateSchemaArg(args)
+			schema, err := evaluateSchemaArg(args)
+			if err != nil {
+				printOutput(nil, err, *args.pretty)
+				os.Exit(1)
+			}
+			schema.ID = *args.schemaId
+			schema.Version = *args.schemaVersion
 			var compatibilityLevel string
 			if *args.force {
 				compatibility, err := client.GetSubjectCompatibility(*args.subject)
@@ -327,7 +580,11 @@ func main() {
 	if ExistArgParser.Flag.Parsed() {
 		switch command {
 		case "exists":
-			schema := evaluateSchemaArg(args)
+			schema, err := evaluateSchemaArg(args)
+			if err != nil {
+				printOutput(nil, err, *args.pretty)
+				os.Exit(1)
+			}
 			res, err := client.Exists(*args.subject, schema)
 			printOutput(res, err, *args.pretty)
 		}
@@ -349,20 +606,191 @@ func main() {
 	if TestCompatibilityArgParser.Flag.Parsed() {
 		switch command {
 		case "test":
-			schema := evaluateSchemaArg(args)
+			schema, err := evaluateSchemaArg(args)
+			if err != nil {
+				printOutput(nil, err, *args.pretty)
+				os.Exit(1)
+			}
 			res, err := client.CheckSubjectCompatibility(*args.subject, *args.version, schema)
 			printOutput(res, err, *args.pretty)
 		}
 	}
+	if OfflineTestArgParser.Flag.Parsed() {
+		res, err := handleOfflineTestCommand(args, OfflineTestArgParser.Flag.Args())
+		printOutput(res, err, *args.pretty)
+		if err != nil || !res.IsCompatible {
+			os.Exit(1)
+		}
+	}
 	if CompatibilityArgParser.Flag.Parsed() {
 		res, err := handleCompatibilityCommand(client, command, *args.subject, *args.compatibility)
 		printOutput(res, err, *args.pretty)
 	}
+	if CodegenArgParser.Flag.Parsed() {
+		err := handleCodegenCommand(client, args)
+		if err != nil {
+			printOutput(nil, err, *args.pretty)
+			os.Exit(1)
+		}
+	}
+	if DecodeArgParser.Flag.Parsed() {
+		res, err := handleDecodeCommand(client, args)
+		printOutput(res, err, *args.pretty)
+		if err != nil {
+			os.Exit(1)
+		}
+	}
+	if EncodeArgParser.Flag.Parsed() {
+		err := handleEncodeCommand(client, args)
+		if err != nil {
+			printOutput(nil, err, *args.pretty)
+			os.Exit(1)
+		}
+	}
+	if ValidateDataArgParser.Flag.Parsed() {
+		res, err := handleValidateDataCommand(client, args)
+		printOutput(res, err, *args.pretty)
+		if err != nil || res.Invalid > 0 {
+			os.Exit(1)
+		}
+	}
+	if DiffArgParser.Flag.Parsed() {
+		res, err := handleDiffCommand(client, args)
+		if err == nil && *args.output == "text" {
+			printDiff(res)
+		} else {
+			printOutput(res, err, *args.pretty)
+		}
+	}
+	if ExportArgParser.Flag.Parsed() {
+		res, err := handleExportCommand(client, *args.dir)
+		printOutput(res, err, *args.pretty)
+	}
+	if ImportArgParser.Flag.Parsed() {
+		res, err := handleImportCommand(client, *args.dir)
+		printOutput(res, err, *args.pretty)
+		if err != nil || res.Conflicts > 0 || res.Failed > 0 {
+			os.Exit(1)
+		}
+	}
+	if ServeArgParser.Flag.Parsed() {
+		err := handleServeCommand(args)
+		printOutput(nil, err, false)
+		os.Exit(1)
+	}
+	if SyncArgParser.Flag.Parsed() {
+		res, err := handleSyncCommand(args)
+		printOutput(res, err, *args.pretty)
+		if err != nil || res.Conflicts > 0 || res.Failed > 0 {
+			os.Exit(1)
+		}
+	}
+	if FingerprintArgParser.Flag.Parsed() {
+		res, err := handleFingerprintCommand(client, args)
+		printOutput(res, err, *args.pretty)
+	}
+	if ModeArgParser.Flag.Parsed() || SetModeArgParser.Flag.Parsed() {
+		res, err := handleModeCommand(client, command, args)
+		printOutput(res, err, *args.pretty)
+	}
+	if cached, ok := client.(*registry.CachedClient); ok && *args.cacheStats {
+		stats, _ := json.Marshal(cached.Stats())
+		fmt.Fprintln(os.Stderr, string(stats))
+	}
 	os.Exit(0)
 }
 
+// newClient creates the registry client, wrapped into a CachedClient if the cache is enabled.
+// The disk cache is stored in a directory per registry, as schema IDs are only unique within a registry.
+func newClient(args CommandArgs) registry.Client {
+	client := registry.NewRegistryClient(*args.host, *args.port)
+	if !*args.cache {
+		return &client
+	}
+	dir := ""
+	usr, err := user.Current()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error while getting current user, disk cache is disabled -  %s \n", err)
+	} else {
+		dir = filepath.Join(usr.HomeDir, ".kafkacli", "cache", *args.host+"_"+strconv.Itoa(*args.port))
+	}
+	return registry.NewCachedClient(&client, *args.cacheTtl, dir)
+}
+
+// handleFingerprintCommand executes "fingerprint" command.
+// The schema is read from the schema arguments or, if none is set, from the specified subject version.
+func handleFingerprintCommand(client registry.Client, args CommandArgs) (res interface{}, e error) {
+	schema, _, e := evaluateSchemaOrSubjectArg(client, args)
+	if e != nil {
+		return
+	}
+	return registry.Fingerprint(schema)
+}
+
+// handleCodegenCommand executes "codegen" command.
+func handleCodegenCommand(client registry.Client, args CommandArgs) error {
+	schema, source, err := evaluateSchemaOrSubjectArg(client, args)
+	if err != nil {
+		return err
+	}
+	parsed, err := avro.Parse(schema.Value)
+	if err != nil {
+		return err
+	}
+	code, err := codegen.Generate(parsed, codegen.Options{Language: *args.lang, Package: *args.pkg, Source: source})
+	if err != nil {
+		return err
+	}
+	if *args.out != "" {
+		return ioutil.WriteFile(*args.out, code, 0644)
+	}
+	_, err = os.Stdout.Write(code)
+	return err
+}
+
+// resolveCommand returns the command to execute and its remaining arguments.
+// Commands declared in SubCommands are returned along with their action, e.g "mode get".
+func resolveCommand(args []string) (string, []string) {
+	command := args[0]
+	actions, ok := SubCommands[command]
+	if !ok {
+		return command, args[1:]
+	}
+	if len(args) > 1 {
+		for _, action := range actions {
+			if args[1] == action {
+				return command + " " + action, args[2:]
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Missing or invalid action for command '%s'. Must be one of %s\n", command, strings.Join(actions, ","))
+	os.Exit(1)
+	return "", nil
+}
+
+// handleModeCommand executes either "mode get" or "mode set" commands.
+func handleModeCommand(client registry.Client, command string, args CommandArgs) (res interface{}, e error) {
+	subject := *args.subject
+	switch command {
+	case "mode get":
+		if subject != "" {
+			res, e = client.GetSubjectMode(subject)
+		} else {
+			res, e = client.GetMode()
+		}
+	case "mode set":
+		mode := registry.Mode{Value: *args.mode}
+		if subject != "" {
+			res, e = client.SetSubjectMode(subject, mode, *args.force)
+		} else {
+			res, e = client.SetMode(mode, *args.force)
+		}
+	}
+	return
+}
+
 // handleCompatibilityCommand execute "set-compatibility" command.
-func handleCompatibilityCommand(client registry.SchemaRegistryRestClient, command string, subject string, compatibility string) (res interface{}, e error) {
+func handleCompatibilityCommand(client registry.Client, command string, subject string, compatibility string) (res interface{}, e error) {
 	switch command {
 	case "set-compatibility":
 		res, e = client.UpdateSubjectCompatibility(subject, registry.Compatibility{Value: compatibility})
@@ -426,7 +854,47 @@ func (reader HTTPSchemaReader) Read(source string) (*registry.Schema, error) {
 	return reader.JsonReader.Read(string(body))
 }
 
-func evaluateSchemaArg(args CommandArgs) registry.Schema {
+// readSchemaSource reads a schema from either an URL or a file.
+func readSchemaSource(source string) (*registry.Schema, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return HTTPSchemaReader{}.Read(source)
+	}
+	return FileSchemaReader{}.Read(source)
+}
+
+// hasFlag checks if the boolean flag is set in the given arguments.
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		switch strings.TrimLeft(arg, "-") {
+		case name, name + "=true":
+			return strings.HasPrefix(arg, "-")
+		}
+	}
+	return false
+}
+
+// evaluateSchemaOrSubjectArg reads the schema from the schema arguments or, if none is set, from the specified subject version.
+// Return the schema and a description of its source.
+func evaluateSchemaOrSubjectArg(client registry.Client, args CommandArgs) (registry.Schema, string, error) {
+	switch {
+	case *args.schemaString != "":
+		schema, err := evaluateSchemaArg(args)
+		return schema, "json string", err
+	case *args.schemaJson != "" || *args.schemaUrl != "":
+		schema, err := evaluateSchemaArg(args)
+		return schema, *args.schemaJson + *args.schemaUrl, err
+	case *args.subject != "":
+		version, err := client.GetSubjectVersion(*args.subject, *args.version)
+		if err != nil {
+			return registry.Schema{}, "", err
+		}
+		source := "subject " + *args.subject + " (version " + strconv.Itoa(version.Version) + ")"
+		return registry.Schema{Value: version.Schema, SchemaType: version.SchemaType, References: version.References}, source, nil
+	}
+	return registry.Schema{}, "", errors.New("Missing schema, either [schema | schema.json | schema.url] or subject must be set")
+}
+
+func evaluateSchemaArg(args CommandArgs) (registry.Schema, error) {
 
 	var source string
 	var reader SchemaReader
@@ -442,10 +910,16 @@ func evaluateSchemaArg(args CommandArgs) registry.Schema {
 		reader = HTTPSchemaReader{}
 		source = *args.schemaUrl
 	}
+	if reader == nil {
+		return registry.Schema{}, errors.New("Missing schema, one of [schema | schema.json | schema.url] must be set")
+	}
 
 	schema, err := reader.Read(source)
 	if err != nil {
-		panic(err)
+		return registry.Schema{}, err
+	}
+	if args.normalize != nil && *args.normalize {
+		return registry.Normalize(*schema)
 	}
-	return *schema
+	return *schema, nil
 }
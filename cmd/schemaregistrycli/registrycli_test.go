@@ -0,0 +1,61 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"testing"
+)
+
+const (
+	userV1 = `{"type":"record","name":"User","fields":[{"name":"id","type":"long"}]}`
+	userV2 = `{"type":"record","name":"User","fields":[{"name":"id","type":"long"},{"name":"email","type":"string","default":""}]}`
+	order  = `{"type":"record","name":"Order","fields":[{"name":"id","type":"long"}]}`
+)
+
+func parseSchemaArgs(args ...string) CommandArgs {
+	parser := NewArgParser("register")
+	parser.withSchemaArg().withNormalizeArg()
+	return parser.parse(args)
+}
+
+func TestEvaluateSchemaArg(t *testing.T) {
+	schema, err := evaluateSchemaArg(parseSchemaArgs("-schema", userV1))
+	if err != nil || schema.Value != userV1 {
+		t.Errorf("expected schema %s, got %v (%v)", userV1, schema, err)
+	}
+}
+
+func TestEvaluateSchemaArgNormalize(t *testing.T) {
+	spaced := `{"name": "User", "type": "record", "fields": [{"type": "long", "name": "id"}]}`
+	normalized := `{"name":"User","type":"record","fields":[{"name":"id","type":"long"}]}`
+	schema, err := evaluateSchemaArg(parseSchemaArgs("-schema", spaced, "-normalize"))
+	if err != nil || schema.Value != normalized {
+		t.Errorf("expected schema %s, got %v (%v)", normalized, schema, err)
+	}
+}
+
+func TestEvaluateSchemaArgNormalizeInvalidSchema(t *testing.T) {
+	if _, err := evaluateSchemaArg(parseSchemaArgs("-schema", `{"type":"record","name":"User"}`, "-normalize")); err == nil {
+		t.Error("expected an error for an invalid schema")
+	}
+	if _, err := evaluateSchemaArg(parseSchemaArgs("-schema", `{"type":`, "-normalize")); err == nil {
+		t.Error("expected an error for an invalid JSON")
+	}
+}
+
+func TestEvaluateSchemaArgMissingSchema(t *testing.T) {
+	if _, err := evaluateSchemaArg(parseSchemaArgs()); err == nil {
+		t.Error("expected an error when no schema argument is set")
+	}
+}
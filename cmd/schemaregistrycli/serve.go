@@ -0,0 +1,36 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"github.com/fhussonnois/kafkacli/registry/server"
+	"net/http"
+	"os"
+)
+
+// handleServeCommand executes "serve" command.
+// The registry is kept in memory, and saved into the data directory if set. It never returns unless it fails to start.
+func handleServeCommand(args CommandArgs) error {
+	r, err := server.New(*args.dir)
+	if err != nil {
+		return err
+	}
+	if *args.dir != "" {
+		fmt.Fprintf(os.Stderr, "Schema registry listening on %s, data are saved into %s\n", *args.listen, *args.dir)
+	} else {
+		fmt.Fprintf(os.Stderr, "Schema registry listening on %s, data are kept in memory\n", *args.listen)
+	}
+	return http.ListenAndServe(*args.listen, r)
+}
@@ -0,0 +1,122 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fhussonnois/kafkacli/registry"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// SyncReport summarizes one synchronization between two registries.
+type SyncReport struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Time     string `json:"time"`
+	Subjects int    `json:"subjects"`
+	CopyReport
+}
+
+// handleSyncCommand executes "sync" command.
+// Unless once is true, the synchronization is repeated at the given interval and never returns, even if a
+// registry is unreachable for a while.
+func handleSyncCommand(args CommandArgs) (res SyncReport, e error) {
+	source, e := registry.NewRegistryClientFromURL(*args.sourceUrl)
+	if e != nil {
+		return
+	}
+	target, e := registry.NewRegistryClientFromURL(*args.targetUrl)
+	if e != nil {
+		return
+	}
+	subjectRegex, e := regexp.Compile(*args.subject)
+	if e != nil {
+		return res, errors.New("Invalid subject regex: " + e.Error())
+	}
+	for {
+		res, e = safeSyncRegistries(&source, &target, subjectRegex)
+		res.From, res.To = *args.sourceUrl, *args.targetUrl
+		if *args.once {
+			return
+		}
+		printOutput(res, e, *args.pretty)
+		time.Sleep(*args.interval)
+	}
+}
+
+// safeSyncRegistries synchronizes the registries, recovering from panics of the clients (e.g when a registry is
+// unreachable) as errors so that the next synchronization can still take place.
+func safeSyncRegistries(source registry.Client, target registry.Client, subjectRegex *regexp.Regexp) (res SyncReport, e error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e = fmt.Errorf("Synchronization failed: %v", r)
+		}
+	}()
+	return syncRegistries(source, target, subjectRegex)
+}
+
+// syncRegistries registers, in the target registry, the versions of the matching subjects
+// which only exist in the source registry. Missing versions are registered in the order of their IDs.
+func syncRegistries(source registry.Client, target registry.Client, subjectRegex *regexp.Regexp) (res SyncReport, e error) {
+	res.Time = time.Now().Format(time.RFC3339)
+	subjects, e := source.Subjects()
+	if e != nil {
+		return
+	}
+
+	var missing []registry.SchemaVersion
+	for _, subject := range subjects {
+		if !subjectRegex.MatchString(subject) {
+			continue
+		}
+		res.Subjects++
+		sourceVersions, err := source.Versions(subject)
+		if err != nil {
+			return res, errors.New("Error while listing versions of subject " + subject + ": " + err.Error())
+		}
+		// an error means the subject does not exist yet in the target registry.
+		targetVersions, _ := target.Versions(subject)
+		existing := make(map[int]bool)
+		for _, v := range targetVersions {
+			existing[v] = true
+		}
+		for _, v := range sourceVersions {
+			if existing[v] {
+				continue
+			}
+			version, err := source.GetSubjectVersion(subject, strconv.Itoa(v))
+			if err != nil {
+				return res, errors.New("Error while reading version " + strconv.Itoa(v) + " of subject " + subject + ": " + err.Error())
+			}
+			if version.Subject == "" {
+				version.Subject = subject
+			}
+			missing = append(missing, version)
+		}
+	}
+	sortVersions(missing)
+
+	modes := subjectModes{client: target}
+	res.PreservedIDs = true
+	res.Results = []CopyResult{}
+	for _, version := range missing {
+		preserve := modes.isImport(version.Subject)
+		res.PreservedIDs = res.PreservedIDs && preserve
+		res.add(copyVersion(target, version, preserve))
+	}
+	return
+}
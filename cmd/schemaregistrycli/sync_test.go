@@ -0,0 +1,80 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"github.com/fhussonnois/kafkacli/registry"
+	"github.com/fhussonnois/kafkacli/registry/registrytest"
+	"github.com/fhussonnois/kafkacli/registry/server"
+	"net"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestSyncRegistries(t *testing.T) {
+	source, sourceClient := newSourceRegistry(t)
+	defer source.Close()
+	target := registrytest.NewServer()
+	defer target.Close()
+	targetClient := target.Client()
+	targetClient.Register("users-value", registry.Schema{Value: userV1})
+
+	res, err := syncRegistries(sourceClient, targetClient, regexp.MustCompile("users-.*"))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if res.Subjects != 1 || res.Registered != 1 || res.PreservedIDs {
+		t.Errorf("expected version 2 of users-value to be registered with a new ID, got %v", res)
+	}
+	if versions, _ := targetClient.Versions("users-value"); len(versions) != 2 {
+		t.Errorf("expected 2 versions, got %v", versions)
+	}
+	if _, err := targetClient.Versions("orders-value"); err == nil {
+		t.Error("expected orders-value not to be synchronized")
+	}
+}
+
+func TestSafeSyncRegistriesRecoversFromUnreachableRegistry(t *testing.T) {
+	source, sourceClient := newSourceRegistry(t)
+	defer source.Close()
+	target := registrytest.NewServer()
+	address := target.Listener.Addr().String()
+	targetClient := target.Client()
+	target.Close()
+
+	if _, err := safeSyncRegistries(sourceClient, targetClient, regexp.MustCompile(".*")); err == nil {
+		t.Fatal("expected an error while the target registry is unreachable")
+	}
+
+	// The target registry is back on the same address.
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Skipf("cannot listen on %s again: %s", address, err)
+	}
+	r, _ := server.New("")
+	back := httptest.NewUnstartedServer(r)
+	back.Listener.Close()
+	back.Listener = listener
+	back.Start()
+	defer back.Close()
+
+	res, err := safeSyncRegistries(sourceClient, targetClient, regexp.MustCompile(".*"))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if res.Registered != 3 {
+		t.Errorf("expected 3 versions to be registered, got %v", res)
+	}
+}
@@ -0,0 +1,311 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/fhussonnois/kafkacli/registry"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	EXPORT_GLOBAL_FILE  = "global.json"
+	EXPORT_SUBJECTS_DIR = "subjects"
+	EXPORT_SUBJECT_FILE = "subject.json"
+)
+
+// Status of a schema version copied into a registry.
+const (
+	STATUS_REGISTERED = "REGISTERED"
+	STATUS_SKIPPED    = "SKIPPED"
+	STATUS_CONFLICT   = "CONFLICT"
+	STATUS_FAILED     = "FAILED"
+)
+
+// ExportedGlobalConfig is the global configuration of an exported registry.
+type ExportedGlobalConfig struct {
+	Compatibility string `json:"compatibility,omitempty"`
+	Mode          string `json:"mode,omitempty"`
+}
+
+// ExportedSubject is the configuration of an exported subject.
+type ExportedSubject struct {
+	Subject       string `json:"subject"`
+	Compatibility string `json:"compatibility,omitempty"`
+	Versions      []int  `json:"versions"`
+}
+
+// ExportReport summarizes the result of the "export" command.
+type ExportReport struct {
+	Directory string `json:"directory"`
+	Subjects  int    `json:"subjects"`
+	Versions  int    `json:"versions"`
+}
+
+// CopyResult describes the result of copying a schema version into a registry.
+type CopyResult struct {
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+	ID      int    `json:"id"`
+	NewID   int    `json:"new_id,omitempty"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// CopyReport summarizes the schema versions copied into a registry.
+type CopyReport struct {
+	PreservedIDs bool         `json:"preserved_ids"`
+	Registered   int          `json:"registered"`
+	Skipped      int          `json:"skipped"`
+	Conflicts    int          `json:"conflicts"`
+	Failed       int          `json:"failed"`
+	Results      []CopyResult `json:"results"`
+}
+
+func (r *CopyReport) add(result CopyResult) {
+	switch result.Status {
+	case STATUS_REGISTERED:
+		r.Registered++
+	case STATUS_SKIPPED:
+		r.Skipped++
+	case STATUS_CONFLICT:
+		r.Conflicts++
+	case STATUS_FAILED:
+		r.Failed++
+	}
+	r.Results = append(r.Results, result)
+}
+
+// handleExportCommand executes "export" command.
+// Each subject is written in its own directory containing one file per version.
+func handleExportCommand(client registry.Client, dir string) (res ExportReport, e error) {
+	res.Directory = dir
+	subjects, e := client.Subjects()
+	if e != nil {
+		return
+	}
+
+	global := ExportedGlobalConfig{}
+	if config, err := client.GetGlobalCompatibility(); err == nil {
+		var level registry.CompatibilityLevel
+		if json.Unmarshal([]byte(config), &level) == nil {
+			global.Compatibility = level.Value
+		}
+	}
+	if mode, err := client.GetMode(); err == nil {
+		global.Mode = mode.Value
+	}
+	if e = writeJsonFile(filepath.Join(dir, EXPORT_GLOBAL_FILE), global); e != nil {
+		return
+	}
+
+	for _, subject := range subjects {
+		versions, err := client.Versions(subject)
+		if err != nil {
+			return res, errors.New("Error while listing versions of subject " + subject + ": " + err.Error())
+		}
+		exported := ExportedSubject{Subject: subject, Versions: versions}
+		if compatibility, err := client.GetSubjectCompatibility(subject); err == nil {
+			exported.Compatibility = compatibility.Value
+		}
+		subjectDir := filepath.Join(dir, EXPORT_SUBJECTS_DIR, url.PathEscape(subject))
+		for _, v := range versions {
+			version, err := client.GetSubjectVersion(subject, strconv.Itoa(v))
+			if err != nil {
+				return res, errors.New("Error while reading version " + strconv.Itoa(v) + " of subject " + subject + ": " + err.Error())
+			}
+			if e = writeJsonFile(filepath.Join(subjectDir, strconv.Itoa(v)+".json"), version); e != nil {
+				return
+			}
+			res.Versions++
+		}
+		if e = writeJsonFile(filepath.Join(subjectDir, EXPORT_SUBJECT_FILE), exported); e != nil {
+			return
+		}
+		res.Subjects++
+	}
+	return
+}
+
+// handleImportCommand executes "import" command.
+// Versions are registered in the order of their IDs, so that referenced schemas are imported first.
+// IDs are preserved if the target registry is in IMPORT mode. The global compatibility level is restored
+// but not the global mode, the target registry being usually put in IMPORT mode for the duration of the import.
+func handleImportCommand(client registry.Client, dir string) (res CopyReport, e error) {
+	entries, e := ioutil.ReadDir(filepath.Join(dir, EXPORT_SUBJECTS_DIR))
+	if e != nil {
+		return
+	}
+	global := ExportedGlobalConfig{}
+	if err := readJsonFile(filepath.Join(dir, EXPORT_GLOBAL_FILE), &global); err != nil && !os.IsNotExist(err) {
+		return res, err
+	}
+	var subjects []ExportedSubject
+	var versions []registry.SchemaVersion
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		subjectDir := filepath.Join(dir, EXPORT_SUBJECTS_DIR, entry.Name())
+		var subject ExportedSubject
+		if e = readJsonFile(filepath.Join(subjectDir, EXPORT_SUBJECT_FILE), &subject); e != nil {
+			return
+		}
+		subjects = append(subjects, subject)
+		for _, v := range subject.Versions {
+			var version registry.SchemaVersion
+			if e = readJsonFile(filepath.Join(subjectDir, strconv.Itoa(v)+".json"), &version); e != nil {
+				return
+			}
+			if version.Subject == "" {
+				version.Subject = subject.Subject
+			}
+			versions = append(versions, version)
+		}
+	}
+	sortVersions(versions)
+
+	modes := subjectModes{client: client}
+	res.PreservedIDs = true
+	for _, version := range versions {
+		preserve := modes.isImport(version.Subject)
+		res.PreservedIDs = res.PreservedIDs && preserve
+		res.add(copyVersion(client, version, preserve))
+	}
+	if global.Compatibility != "" {
+		_, err := client.UpdateGlobalCompatibility(registry.Compatibility{Value: global.Compatibility})
+		if err != nil {
+			res.add(CopyResult{Status: STATUS_FAILED, Message: "Error while setting global compatibility: " + err.Error()})
+		}
+	}
+	for _, subject := range subjects {
+		if subject.Compatibility == "" {
+			continue
+		}
+		_, err := client.UpdateSubjectCompatibility(subject.Subject, registry.Compatibility{Value: subject.Compatibility})
+		if err != nil {
+			res.add(CopyResult{Subject: subject.Subject, Status: STATUS_FAILED, Message: "Error while setting compatibility: " + err.Error()})
+		}
+	}
+	return
+}
+
+// copyVersion registers a schema version into the target registry, unless it is already registered.
+// If preserve is true, the version is registered with its original ID and version.
+func copyVersion(client registry.Client, version registry.SchemaVersion, preserve bool) CopyResult {
+	res := CopyResult{Subject: version.Subject, Version: version.Version, ID: version.ID}
+	schema := registry.Schema{Value: version.Schema, SchemaType: version.SchemaType, References: version.References}
+
+	if existing, err := client.Exists(version.Subject, schema); err == nil {
+		if preserve && existing.ID != version.ID {
+			res.Status = STATUS_CONFLICT
+			res.NewID = existing.ID
+			res.Message = "Schema already registered with ID " + strconv.Itoa(existing.ID) + " (version " + strconv.Itoa(existing.Version) + ")"
+		} else {
+			res.Status = STATUS_SKIPPED
+			res.Message = "Schema already registered"
+		}
+		return res
+	}
+
+	if preserve {
+		schema.ID = version.ID
+		schema.Version = version.Version
+	}
+	id, err := client.Register(version.Subject, schema)
+	if err != nil {
+		res.Status = STATUS_FAILED
+		if preserve && strings.Contains(err.Error(), "already") {
+			res.Status = STATUS_CONFLICT
+		}
+		res.Message = err.Error()
+		return res
+	}
+	res.Status = STATUS_REGISTERED
+	if id.Value != version.ID {
+		res.NewID = id.Value
+		res.Message = "Schema registered with a new ID"
+	}
+	return res
+}
+
+// subjectModes resolves, and caches, the mode of subjects of a registry.
+type subjectModes struct {
+	client registry.Client
+	global *registry.Mode
+	modes  map[string]string
+}
+
+// isImport checks if the subject, or the registry if the subject has no mode, is in IMPORT mode.
+func (m *subjectModes) isImport(subject string) bool {
+	if m.modes == nil {
+		m.modes = make(map[string]string)
+	}
+	mode, ok := m.modes[subject]
+	if !ok {
+		if subjectMode, err := m.client.GetSubjectMode(subject); err == nil {
+			mode = subjectMode.Value
+		} else {
+			if m.global == nil {
+				global, _ := m.client.GetMode()
+				m.global = &global
+			}
+			mode = m.global.Value
+		}
+		m.modes[subject] = mode
+	}
+	return mode == registry.MODE_IMPORT
+}
+
+// sortVersions sorts schema versions by ID, then subject and version.
+func sortVersions(versions []registry.SchemaVersion) {
+	sort.Slice(versions, func(i, j int) bool {
+		if versions[i].ID != versions[j].ID {
+			return versions[i].ID < versions[j].ID
+		}
+		if versions[i].Subject != versions[j].Subject {
+			return versions[i].Subject < versions[j].Subject
+		}
+		return versions[i].Version < versions[j].Version
+	})
+}
+
+func writeJsonFile(file string, o interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(o, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, content, 0644)
+}
+
+func readJsonFile(file string, o interface{}) error {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(content, o); err != nil {
+		return errors.New("Invalid file " + file + ": " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"github.com/fhussonnois/kafkacli/registry"
+	"github.com/fhussonnois/kafkacli/registry/registrytest"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// newSourceRegistry returns a registry with 2 versions of "users-value" (IDs 2 and 3) and 1 of "orders-value" (ID 1).
+func newSourceRegistry(t *testing.T) (*registrytest.Server, registry.Client) {
+	server := registrytest.NewServer()
+	client := server.Client()
+	for _, s := range []struct{ subject, schema string }{{"orders-value", order}, {"users-value", userV1}, {"users-value", userV2}} {
+		if _, err := client.Register(s.subject, registry.Schema{Value: s.schema}); err != nil {
+			server.Close()
+			t.Fatalf("unexpected error %s", err)
+		}
+	}
+	return server, client
+}
+
+func newImportRegistry(t *testing.T) (*registrytest.Server, registry.Client) {
+	server := registrytest.NewServer()
+	client := server.Client()
+	if _, err := client.SetMode(registry.Mode{Value: registry.MODE_IMPORT}, false); err != nil {
+		server.Close()
+		t.Fatalf("unexpected error %s", err)
+	}
+	return server, client
+}
+
+func TestExportImport(t *testing.T) {
+	source, sourceClient := newSourceRegistry(t)
+	defer source.Close()
+	sourceClient.UpdateGlobalCompatibility(registry.Compatibility{Value: "FULL"})
+	sourceClient.UpdateSubjectCompatibility("users-value", registry.Compatibility{Value: "NONE"})
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	exported, err := handleExportCommand(sourceClient, dir)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if exported.Subjects != 2 || exported.Versions != 3 {
+		t.Errorf("expected 2 subjects and 3 versions, got %v", exported)
+	}
+
+	target, targetClient := newImportRegistry(t)
+	defer target.Close()
+	imported, err := handleImportCommand(targetClient, dir)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if !imported.PreservedIDs || imported.Registered != 3 || imported.Failed != 0 {
+		t.Errorf("expected 3 versions registered with their IDs, got %v", imported)
+	}
+	for _, v := range []struct {
+		subject string
+		version string
+		id      int
+	}{{"orders-value", "1", 1}, {"users-value", "1", 2}, {"users-value", "2", 3}} {
+		version, err := targetClient.GetSubjectVersion(v.subject, v.version)
+		if err != nil || version.ID != v.id {
+			t.Errorf("expected version %s of %s with ID %d, got %v (%v)", v.version, v.subject, v.id, version, err)
+		}
+	}
+
+	config, _ := targetClient.GetGlobalCompatibility()
+	var global registry.CompatibilityLevel
+	json.Unmarshal([]byte(config), &global)
+	if global.Value != "FULL" {
+		t.Errorf("expected global compatibility FULL, got %s", config)
+	}
+	if compatibility, _ := targetClient.GetSubjectCompatibility("users-value"); compatibility.Value != "NONE" {
+		t.Errorf("expected compatibility NONE for users-value, got %s", compatibility.Value)
+	}
+
+	// Importing twice skips the versions already registered.
+	imported, err = handleImportCommand(targetClient, dir)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if imported.Skipped != 3 || imported.Registered != 0 {
+		t.Errorf("expected 3 skipped versions, got %v", imported)
+	}
+}
+
+func TestImportReportsConflicts(t *testing.T) {
+	source, sourceClient := newSourceRegistry(t)
+	defer source.Close()
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if _, err = handleExportCommand(sourceClient, dir); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	target := registrytest.NewServer()
+	defer target.Close()
+	targetClient := target.Client()
+	// userV1 gets ID 1 in the target registry, which is ID 2 in the source registry.
+	targetClient.Register("users-value", registry.Schema{Value: userV1})
+	targetClient.SetMode(registry.Mode{Value: registry.MODE_IMPORT}, true)
+
+	imported, err := handleImportCommand(targetClient, dir)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if imported.Conflicts == 0 {
+		t.Errorf("expected conflicts, got %v", imported)
+	}
+}
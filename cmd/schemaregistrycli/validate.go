@@ -0,0 +1,115 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/fhussonnois/kafkacli/registry"
+	"github.com/fhussonnois/kafkacli/registry/avro"
+	"github.com/fhussonnois/kafkacli/registry/jsonschema"
+	"io"
+	"strconv"
+)
+
+// DataValidation is the result of the "validate-data" command.
+type DataValidation struct {
+	Subject    string        `json:"subject"`
+	Version    int           `json:"version"`
+	SchemaType string        `json:"schemaType"`
+	Records    int           `json:"records"`
+	Invalid    int           `json:"invalid"`
+	Errors     []RecordError `json:"errors"`
+}
+
+// RecordError describes why a record does not conform to the schema. Records are numbered from 1.
+type RecordError struct {
+	Record  int    `json:"record"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// handleValidateDataCommand executes "validate-data" command.
+// The data is a sequence of JSON values, e.g JSON lines, each being validated as a record.
+func handleValidateDataCommand(client registry.Client, args CommandArgs) (res DataValidation, e error) {
+	version, e := client.GetSubjectVersion(*args.subject, *args.version)
+	if e != nil {
+		return
+	}
+	validate, e := newDataValidator(version)
+	if e != nil {
+		return
+	}
+	data, e := readInput(*args.input)
+	if e != nil {
+		return
+	}
+
+	res = DataValidation{Subject: version.Subject, Version: version.Version, SchemaType: version.SchemaType, Errors: []RecordError{}}
+	if res.SchemaType == "" {
+		res.SchemaType = "AVRO"
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	for {
+		var record interface{}
+		err := decoder.Decode(&record)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return res, errors.New("Invalid JSON after record " + strconv.Itoa(res.Records) + ": " + err.Error())
+		}
+		res.Records++
+		errs := validate(record)
+		if len(errs) > 0 {
+			res.Invalid++
+		}
+		for _, err := range errs {
+			res.Errors = append(res.Errors, RecordError{Record: res.Records, Path: err.Path, Message: err.Message})
+		}
+	}
+	return
+}
+
+// newDataValidator parses the schema of the version.
+// Return a function validating a record against either the Avro or the JSON schema.
+func newDataValidator(version registry.SchemaVersion) (func(interface{}) []RecordError, error) {
+	switch version.SchemaType {
+	case "", "AVRO":
+		schema, err := avro.Parse(version.Schema)
+		if err != nil {
+			return nil, err
+		}
+		return func(record interface{}) (res []RecordError) {
+			for _, err := range avro.Validate(schema, record) {
+				res = append(res, RecordError{Path: err.Path, Message: err.Message})
+			}
+			return
+		}, nil
+	case "JSON":
+		schema, err := jsonschema.Parse(version.Schema)
+		if err != nil {
+			return nil, err
+		}
+		return func(record interface{}) (res []RecordError) {
+			for _, err := range schema.Validate(record) {
+				res = append(res, RecordError{Path: err.Path, Message: err.Message})
+			}
+			return
+		}, nil
+	}
+	return nil, errors.New("Unsupported schema type '" + version.SchemaType + "'")
+}
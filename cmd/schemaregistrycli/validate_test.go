@@ -0,0 +1,101 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package main
+
+import (
+	"github.com/fhussonnois/kafkacli/registry"
+	"github.com/fhussonnois/kafkacli/registry/registrytest"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// validateData executes "validate-data" with the given records on the latest version of the subject.
+func validateData(t *testing.T, client registry.Client, subject string, records string) (DataValidation, error) {
+	dir, err := ioutil.TempDir("", "validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	data := filepath.Join(dir, "records.json")
+	ioutil.WriteFile(data, []byte(records), 0644)
+
+	parser := NewArgParser("validate-data")
+	parser.withSubjectArg().withVersionArg().withDataArg()
+	return handleValidateDataCommand(client, parser.parse([]string{"-subject", subject, "-version", "latest", "-data", data}))
+}
+
+func TestHandleValidateDataCommandAvro(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	client.Register("users-value", registry.Schema{Value: userV2})
+
+	res, err := validateData(t, client, "users-value", "{\"id\": 1}\n{\"id\": \"a\", \"email\": 1}\n{\"id\": 2, \"email\": \"b\"}\n")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if res.SchemaType != "AVRO" || res.Version != 1 || res.Records != 3 || res.Invalid != 1 {
+		t.Errorf("expected 1 invalid record out of 3, got %v", res)
+	}
+	expected := []RecordError{
+		{Record: 2, Path: "$.id", Message: "expected long but got string"},
+		{Record: 2, Path: "$.email", Message: "expected string but got number"},
+	}
+	if !reflect.DeepEqual(res.Errors, expected) {
+		t.Errorf("expected errors %v, got %v", expected, res.Errors)
+	}
+
+	if _, err = validateData(t, client, "users-value", "{\"id\": 1}\n{\"id\": "); err == nil {
+		t.Error("expected an error for invalid JSON data")
+	}
+	if _, err = validateData(t, client, "missing-value", "{}"); err == nil {
+		t.Error("expected an error for a missing subject")
+	}
+}
+
+func TestHandleValidateDataCommandJSONSchema(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	// Properties named after keywords, such as default, are validated as any other property.
+	schema := `{"type":"object","properties":{"default":{"type":"string","pattern":"^a"},"code":{"$ref":"#/definitions/code"}},
+		"definitions":{"code":{"type":"string","pattern":"^[A-Z]+$"}}}`
+	if _, err := client.Register("settings-value", registry.Schema{Value: schema, SchemaType: "JSON"}); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	res, err := validateData(t, client, "settings-value", `{"default": "abc", "code": "AB"} {"default": "bc", "code": "ab"}`)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if res.SchemaType != "JSON" || res.Records != 2 || res.Invalid != 1 {
+		t.Errorf("expected 1 invalid record out of 2, got %v", res)
+	}
+	expected := []RecordError{
+		{Record: 2, Path: "$.code", Message: "value 'ab' does not match pattern '^[A-Z]+$'"},
+		{Record: 2, Path: "$.default", Message: "value 'bc' does not match pattern '^a'"},
+	}
+	if !reflect.DeepEqual(res.Errors, expected) {
+		t.Errorf("expected errors %v, got %v", expected, res.Errors)
+	}
+}
+
+func TestNewDataValidatorUnsupportedType(t *testing.T) {
+	if _, err := newDataValidator(registry.SchemaVersion{Schema: "syntax = \"proto3\";", SchemaType: "PROTOBUF"}); err == nil {
+		t.Error("expected an error for an unsupported schema type")
+	}
+}
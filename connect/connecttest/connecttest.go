@@ -0,0 +1,67 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package connecttest provides an in-memory fake Kafka Connect worker to test code using the connect package
+// without a live cluster.
+package connecttest
+
+import (
+	"github.com/fhussonnois/kafkacli/connect"
+	"github.com/fhussonnois/kafkacli/connect/server"
+	"net"
+	"net/http/httptest"
+	"strconv"
+)
+
+// Connector and task states.
+const (
+	RUNNING    = server.RUNNING
+	PAUSED     = server.PAUSED
+	FAILED     = server.FAILED
+	UNASSIGNED = server.UNASSIGNED
+	STOPPED    = server.STOPPED
+)
+
+// Server is an in-memory Kafka Connect worker served through an httptest.Server.
+// See the server package for the supported REST API.
+type Server struct {
+	*httptest.Server
+	*server.Worker
+}
+
+// Create and start a new Server. It must be closed once done.
+func NewServer() *Server {
+	worker := server.New("")
+	s := &Server{Server: httptest.NewServer(worker), Worker: worker}
+	worker.WorkerID = s.Listener.Addr().String()
+	return s
+}
+
+// Client returns a client connected to the server.
+func (s *Server) Client() *connect.ConnectRestClient {
+	host, port, _ := net.SplitHostPort(s.Listener.Addr().String())
+	number, _ := strconv.Atoi(port)
+	client := connect.NewConnectClient(host, number)
+	return &client
+}
+
+// SinkConnector returns the configuration of a FileStreamSinkConnector, installed on any new Server, running the
+// given number of tasks on the "events" topic.
+func SinkConnector(name string, tasksMax int) connect.ConnectorConfig {
+	return connect.ConnectorConfig{Name: name, Config: map[string]string{
+		"connector.class": "org.apache.kafka.connect.file.FileStreamSinkConnector",
+		"tasks.max":       strconv.Itoa(tasksMax),
+		"topics":          "events",
+	}}
+}
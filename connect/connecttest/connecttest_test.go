@@ -0,0 +1,256 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package connecttest
+
+import (
+	"github.com/fhussonnois/kafkacli/connect"
+	"github.com/fhussonnois/kafkacli/connect/server"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// states returns the state of the connector followed by the states of its tasks.
+func states(t *testing.T, client connect.Client, name string) []string {
+	status, err := client.Status(name)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	res := []string{status.Connector.State}
+	for _, task := range status.Tasks {
+		res = append(res, task.State)
+	}
+	return res
+}
+
+func assertStates(t *testing.T, client connect.Client, name string, expected ...string) {
+	t.Helper()
+	actual := states(t, client, name)
+	if len(actual) != len(expected) {
+		t.Fatalf("expected states %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Fatalf("expected states %v, got %v", expected, actual)
+		}
+	}
+}
+
+func TestConnectorLifecycle(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	if _, err := client.Create(SinkConnector("sink", 2)); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if _, err := client.Create(SinkConnector("sink", 2)); err == nil {
+		t.Error("expected an error when creating an existing connector")
+	}
+	assertStates(t, client, "sink", RUNNING, RUNNING, RUNNING)
+
+	client.Pause("sink")
+	assertStates(t, client, "sink", PAUSED, PAUSED, PAUSED)
+	client.Resume("sink")
+	assertStates(t, client, "sink", RUNNING, RUNNING, RUNNING)
+
+	client.Update(SinkConnector("sink", 3))
+	assertStates(t, client, "sink", RUNNING, RUNNING, RUNNING, RUNNING)
+	config, _ := client.GetConfig("sink")
+	if config.Config["tasks.max"] != "3" || len(config.Tasks) != 3 {
+		t.Errorf("expected 3 tasks, got %+v", config)
+	}
+
+	if err := client.Delete("sink"); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if list, _ := client.List(); len(list) != 0 {
+		t.Errorf("expected no connectors, got %v", list)
+	}
+	if _, err := client.Status("sink"); err == nil {
+		t.Error("expected an error for a deleted connector")
+	}
+}
+
+func TestFailuresAndTaskRestarts(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := server.Client()
+	client.Create(SinkConnector("sink", 2))
+
+	if err := server.SetTaskState("sink", 1, FAILED, "java.lang.IllegalStateException: boom"); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if err := server.SetTaskState("sink", 5, FAILED, ""); err == nil {
+		t.Error("expected an error for an unknown task")
+	}
+	status, _ := client.Status("sink")
+	if status.Tasks[1].State != FAILED || status.Tasks[1].Trace != "java.lang.IllegalStateException: boom" {
+		t.Errorf("expected task 1 to be failed with its trace, got %+v", status.Tasks[1])
+	}
+	// Failed tasks stay failed when the connector is paused and resumed.
+	client.Pause("sink")
+	client.Resume("sink")
+	assertStates(t, client, "sink", RUNNING, RUNNING, FAILED)
+
+	client.Restart("sink", 1)
+	assertStates(t, client, "sink", RUNNING, RUNNING, RUNNING)
+}
+
+func TestStopAndResume(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := server.Client()
+	client.Create(SinkConnector("sink", 2))
+
+	if err := client.Stop("sink"); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	assertStates(t, client, "sink", STOPPED)
+	if _, err := client.RestartConnector("sink", connect.RestartOptions{}); err == nil {
+		t.Error("expected an error when restarting a stopped connector")
+	}
+	client.Resume("sink")
+	assertStates(t, client, "sink", RUNNING, RUNNING, RUNNING)
+	if err := client.Stop("unknown"); err == nil {
+		t.Error("expected an error when stopping an unknown connector")
+	}
+}
+
+func TestRestartConnectorOptions(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := server.Client()
+	client.Create(SinkConnector("sink", 2))
+
+	// Without any option, only the connector is restarted and no status is returned.
+	server.SetConnectorState("sink", FAILED, "")
+	server.SetTaskState("sink", 0, FAILED, "")
+	status, err := client.RestartConnector("sink", connect.RestartOptions{})
+	if err != nil || status.Name != "" {
+		t.Fatalf("expected no status, got %+v %v", status, err)
+	}
+	assertStates(t, client, "sink", RUNNING, FAILED, RUNNING)
+
+	server.SetConnectorState("sink", FAILED, "")
+	status, err = client.RestartConnector("sink", connect.RestartOptions{IncludeTasks: true, OnlyFailed: true})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if status.Connector.State != "RESTARTING" || status.Tasks[0].State != "RESTARTING" || status.Tasks[1].State != RUNNING {
+		t.Errorf("expected the failed instances to be restarting, got %+v", status)
+	}
+	assertStates(t, client, "sink", RUNNING, RUNNING, RUNNING)
+
+	// Running instances are restarted too unless onlyFailed is set.
+	status, _ = client.RestartConnector("sink", connect.RestartOptions{IncludeTasks: true})
+	if status.Connector.State != "RESTARTING" || status.Tasks[1].State != "RESTARTING" {
+		t.Errorf("expected all instances to be restarting, got %+v", status)
+	}
+}
+
+func TestTopics(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := server.Client()
+	client.Create(SinkConnector("sink", 1))
+
+	if topics, err := client.Topics("sink"); err != nil || len(topics) != 1 || topics[0] != "events" {
+		t.Errorf("expected active topic events, got %v %v", topics, err)
+	}
+	if err := client.ResetTopics("sink"); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if topics, _ := client.Topics("sink"); len(topics) != 0 {
+		t.Errorf("expected no active topic, got %v", topics)
+	}
+	if _, err := client.Topics("unknown"); err == nil {
+		t.Error("expected an error for an unknown connector")
+	}
+}
+
+func TestListExpanded(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := server.Client()
+	client.Create(SinkConnector("a", 1))
+	client.Create(SinkConnector("b", 2))
+
+	connectors, err := client.ListExpanded(connect.EXPAND_STATUS, connect.EXPAND_INFO)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if len(connectors) != 2 || len(connectors["b"].Status.Tasks) != 2 || connectors["a"].Info.Config["topics"] != "events" {
+		t.Errorf("unexpected expanded connectors %+v", connectors)
+	}
+	if status := connectors["a"].Status; status.Tasks[0].WorkerID != server.WorkerID {
+		t.Errorf("expected task assigned to %s, got %s", server.WorkerID, status.Tasks[0].WorkerID)
+	}
+}
+
+// newOldServer returns a Server emulating a worker older than 2.3, which ignores the expand parameter.
+// The onList function is called each time connectors are listed.
+func newOldServer(onList func(worker *server.Worker)) *Server {
+	worker := server.New("")
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		listing := strings.TrimSuffix(r.URL.Path, "/") == "/connectors" && r.URL.Query().Get("expand") != ""
+		r.URL.RawQuery = ""
+		worker.ServeHTTP(w, r)
+		if listing {
+			onList(worker)
+		}
+	})
+	s := &Server{Server: httptest.NewServer(handler), Worker: worker}
+	worker.WorkerID = s.Listener.Addr().String()
+	return s
+}
+
+func TestListExpandedOnOldWorkers(t *testing.T) {
+	server := newOldServer(func(*server.Worker) {})
+	defer server.Close()
+	client := server.Client()
+	client.Create(SinkConnector("a", 1))
+	client.Create(SinkConnector("b", 2))
+
+	connectors, err := client.ListExpanded(connect.EXPAND_STATUS, connect.EXPAND_INFO)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if len(connectors) != 2 || len(connectors["b"].Status.Tasks) != 2 || connectors["a"].Info.Config["topics"] != "events" {
+		t.Errorf("unexpected expanded connectors %+v", connectors)
+	}
+	if connectors, _ := client.ListExpanded(connect.EXPAND_STATUS); connectors["a"].Info != nil || connectors["a"].Status == nil {
+		t.Errorf("expected the status only, got %+v", connectors["a"])
+	}
+}
+
+func TestListExpandedSkipsDeletedConnectorsOnOldWorkers(t *testing.T) {
+	// The connector b is deleted right after the connectors are listed.
+	server := newOldServer(func(worker *server.Worker) {
+		worker.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/connectors/b", nil))
+	})
+	defer server.Close()
+	client := server.Client()
+	client.Create(SinkConnector("a", 1))
+	client.Create(SinkConnector("b", 2))
+
+	connectors, err := client.ListExpanded(connect.EXPAND_INFO)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if _, ok := connectors["a"]; len(connectors) != 1 || !ok {
+		t.Errorf("expected connector a only, got %+v", connectors)
+	}
+}
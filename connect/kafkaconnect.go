@@ -32,6 +32,7 @@ type ConnectorTasksConfig struct {
 		Connector string `json:"connector"`
 		Task      int    `json:"task"`
 	} `json:"tasks"`
+	Type string `json:"type,omitempty"`
 }
 
 // ConnectorStatus describes the states of a connector and its tasks.
@@ -40,6 +41,7 @@ type ConnectorStatus struct {
 	Connector struct {
 		State    string `json:"state"`
 		WorkerID string `json:"worker_id"`
+		Trace    string `json:"trace,omitempty"`
 	} `json:"connector"`
 	Tasks []struct {
 		State    string `json:"state"`
@@ -49,6 +51,102 @@ type ConnectorStatus struct {
 	} `json:"tasks"`
 }
 
+// Expansions of ListExpanded.
+const (
+	EXPAND_STATUS = "status"
+	EXPAND_INFO   = "info"
+)
+
+// ConnectorExpanded describes a connector listed by ListExpanded. Only requested expansions are set.
+type ConnectorExpanded struct {
+	Status *ConnectorStatus      `json:"status,omitempty"`
+	Info   *ConnectorTasksConfig `json:"info,omitempty"`
+}
+
+// RestartOptions selects the instances restarted by RestartConnector.
+type RestartOptions struct {
+	// IncludeTasks restarts the tasks along with the connector.
+	IncludeTasks bool
+	// OnlyFailed only restarts the connector and tasks which are in the FAILED state.
+	OnlyFailed bool
+}
+
+// Keys of the partitions and offsets of sink connectors, which are Kafka topic-partitions and consumer offsets.
+const (
+	KAFKA_TOPIC     = "kafka_topic"
+	KAFKA_PARTITION = "kafka_partition"
+	KAFKA_OFFSET    = "kafka_offset"
+)
+
+// ConnectorOffset describes the offset of a connector for a partition.
+// For source connectors, both are defined by the connector plugin, e.g {"filename": "test.txt"} and {"position": 30}.
+// For sink connectors, see NewSinkOffset. A nil Offset resets the partition when altering offsets.
+type ConnectorOffset struct {
+	Partition map[string]interface{} `json:"partition"`
+	Offset    map[string]interface{} `json:"offset"`
+}
+
+// ConnectorOffsets describes the offsets of a connector.
+type ConnectorOffsets struct {
+	Offsets []ConnectorOffset `json:"offsets"`
+}
+
+// NewSinkOffset returns the offset of a sink connector for the specified Kafka topic-partition.
+func NewSinkOffset(topic string, partition int, offset int64) ConnectorOffset {
+	return ConnectorOffset{
+		Partition: map[string]interface{}{KAFKA_TOPIC: topic, KAFKA_PARTITION: partition},
+		Offset:    map[string]interface{}{KAFKA_OFFSET: offset},
+	}
+}
+
+// SinkOffset returns the Kafka topic-partition and the consumer offset of a sink connector offset.
+// Return false if this is not a sink connector offset, and an offset of -1 if the offset is nil.
+func (o ConnectorOffset) SinkOffset() (topic string, partition int, offset int64, ok bool) {
+	topic, ok = o.Partition[KAFKA_TOPIC].(string)
+	p, isInt := toInt64(o.Partition[KAFKA_PARTITION])
+	if !ok || !isInt {
+		return "", 0, 0, false
+	}
+	offset = -1
+	if o.Offset != nil {
+		if offset, isInt = toInt64(o.Offset[KAFKA_OFFSET]); !isInt {
+			return "", 0, 0, false
+		}
+	}
+	return topic, int(p), offset, true
+}
+
+// toInt64 converts a number decoded from JSON.
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int64(v), v == float64(int64(v))
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// Scopes of a log level change.
+const (
+	// SCOPE_WORKER only changes the level on the worker receiving the request.
+	SCOPE_WORKER = "worker"
+	// SCOPE_CLUSTER changes the level on all the workers of the cluster, requires workers 3.7 or later.
+	SCOPE_CLUSTER = "cluster"
+)
+
+// LoggerLevel describes the level of a logger.
+type LoggerLevel struct {
+	Level string `json:"level"`
+	// LastModified is the time in milliseconds of the last level change, or nil if it has never been changed.
+	LastModified *int64 `json:"last_modified,omitempty"`
+}
+
 type ConnectorConfig struct {
 	Name   string            `json:"name"`
 	Config map[string]string `json:"config"`
@@ -59,6 +157,33 @@ const (
 	CONNECTORS = "/connectors/"
 )
 
+// Client is the method set of ConnectRestClient, so that it can be mocked.
+type Client interface {
+	Version() (string, error)
+	Plugins() (string, error)
+	List() ([]string, error)
+	ListExpanded(expand ...string) (map[string]ConnectorExpanded, error)
+	Status(connector string) (ConnectorStatus, error)
+	Tasks(connector string) (string, error)
+	GetConfig(connector string) (ConnectorTasksConfig, error)
+	Pause(connector string) error
+	Delete(connector string) error
+	Resume(connector string) error
+	Restart(connector string, id int) error
+	RestartConnector(connector string, options RestartOptions) (ConnectorStatus, error)
+	Stop(connector string) error
+	GetOffsets(connector string) (ConnectorOffsets, error)
+	AlterOffsets(connector string, offsets ConnectorOffsets) (string, error)
+	ResetOffsets(connector string) (string, error)
+	Topics(connector string) ([]string, error)
+	ResetTopics(connector string) error
+	GetLoggers() (map[string]LoggerLevel, error)
+	GetLogger(name string) (LoggerLevel, error)
+	SetLogLevel(name string, level string, scope string) ([]string, error)
+	Create(config ConnectorConfig) (string, error)
+	Update(config ConnectorConfig) (string, error)
+}
+
 // ConnectRestClient is a simple http-client to interact with a connector instances.
 type ConnectRestClient struct {
 	host string
@@ -116,6 +241,81 @@ func (client *ConnectRestClient) List() (r []string, e error) {
 	return
 }
 
+// ListExpanded lists all active connectors on a worker along with their status (EXPAND_STATUS) and/or
+// their configuration (EXPAND_INFO) in a single request, requires workers 2.3 or later.
+// Older workers only return the connector names, in which case each connector is requested separately.
+// Return a map of ConnectorExpanded by connector name.
+func (client *ConnectRestClient) ListExpanded(expand ...string) (r map[string]ConnectorExpanded, e error) {
+	query := ""
+	for i, expansion := range expand {
+		if i == 0 {
+			query += "?"
+		} else {
+			query += "&"
+		}
+		query += "expand=" + expansion
+	}
+	response, e := requestAndGetResponse("GET", client.connectEndPoint()+query, nil)
+	if e != nil {
+		return
+	}
+	if len(expand) > 0 && !bytes.HasPrefix(bytes.TrimSpace(response), []byte("[")) {
+		err := json.Unmarshal(response, &r)
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	var names []string
+	err := json.Unmarshal(response, &names)
+	if err != nil {
+		panic(err)
+	}
+	r = make(map[string]ConnectorExpanded)
+	for _, name := range names {
+		connector, err := client.expandConnector(name, expand)
+		if isNotFound(err) {
+			// The connector has been deleted since it was listed.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		r[name] = connector
+	}
+	return
+}
+
+// expandConnector requests the expansions of a single connector, for workers not supporting ListExpanded.
+func (client *ConnectRestClient) expandConnector(name string, expand []string) (r ConnectorExpanded, e error) {
+	for _, expansion := range expand {
+		switch expansion {
+		case EXPAND_STATUS:
+			status, e := client.Status(name)
+			if e != nil {
+				return r, e
+			}
+			r.Status = &status
+		case EXPAND_INFO:
+			info, e := client.GetConfig(name)
+			if e != nil {
+				return r, e
+			}
+			r.Info = &info
+		}
+	}
+	return
+}
+
+// isNotFound returns whether an error is the 404 response of a worker, e.g for an unknown connector.
+func isNotFound(e error) bool {
+	var body struct {
+		Code int `json:"error_code"`
+	}
+	return e != nil && json.Unmarshal([]byte(e.Error()), &body) == nil && body.Code == http.StatusNotFound
+}
+
 // Status gets status for a specified connector name.
 // Return a new ConnectorStatus struct.
 func (client *ConnectRestClient) Status(connector string) (r ConnectorStatus, e error) {
@@ -177,6 +377,143 @@ func (client *ConnectRestClient) Restart(connector string, id int) error {
 	return e
 }
 
+// RestartConnector restarts the specified connector and, depending on options, its tasks.
+// If any option is set, the worker returns the status of the connector in which the instances being restarted
+// are in the RESTARTING state. Otherwise, or with workers older than 3.0, only the connector is restarted
+// and a zero ConnectorStatus is returned.
+func (client *ConnectRestClient) RestartConnector(connector string, options RestartOptions) (r ConnectorStatus, e error) {
+	query := "?includeTasks=" + strconv.FormatBool(options.IncludeTasks) + "&onlyFailed=" + strconv.FormatBool(options.OnlyFailed)
+	response, e := requestAndGetResponse("POST", client.connectEndPoint()+connector+"/restart"+query, nil)
+	if e == nil && len(response) > 0 {
+		err := json.Unmarshal(response, &r)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return
+}
+
+// Stop stops the specified connector and shuts down its tasks, requires workers 3.5 or later.
+// Unlike a paused connector, a stopped connector does not use any resource and can be resumed.
+func (client *ConnectRestClient) Stop(connector string) error {
+	_, e := requestAndGetResponse("PUT", client.connectEndPoint()+connector+"/stop", nil)
+	return e
+}
+
+// GetOffsets gets the current offsets of the specified connector, requires workers 3.5 or later.
+// Return a new ConnectorOffsets struct.
+func (client *ConnectRestClient) GetOffsets(connector string) (r ConnectorOffsets, e error) {
+	response, e := requestAndGetResponse("GET", client.connectEndPoint()+connector+"/offsets", nil)
+	if e == nil {
+		err := json.Unmarshal(response, &r)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return
+}
+
+// AlterOffsets modifies the offsets of the specified connector for the given partitions, the other ones are left unchanged.
+// The connector must be stopped first, requires workers 3.6 or later.
+// Return the message of the worker.
+func (client *ConnectRestClient) AlterOffsets(connector string, offsets ConnectorOffsets) (r string, e error) {
+	bytes, _ := json.Marshal(offsets)
+	body := string(bytes)
+	response, e := requestAndGetResponse("PATCH", client.connectEndPoint()+connector+"/offsets", &body)
+	if e == nil {
+		r = readMessage(response)
+	}
+	return
+}
+
+// ResetOffsets resets all the offsets of the specified connector.
+// The connector must be stopped first, requires workers 3.6 or later.
+// Return the message of the worker.
+func (client *ConnectRestClient) ResetOffsets(connector string) (r string, e error) {
+	response, e := requestAndGetResponse("DELETE", client.connectEndPoint()+connector+"/offsets", nil)
+	if e == nil {
+		r = readMessage(response)
+	}
+	return
+}
+
+// Topics gets the topics the specified connector has been using (i.e reading from or writing to) since its creation
+// or the last reset of its active topics, requires workers 2.5 or later.
+// Return the topic names as an array of string.
+func (client *ConnectRestClient) Topics(connector string) (r []string, e error) {
+	response, e := requestAndGetResponse("GET", client.connectEndPoint()+connector+"/topics", nil)
+	if e == nil {
+		var topics map[string]struct {
+			Topics []string `json:"topics"`
+		}
+		err := json.Unmarshal(response, &topics)
+		if err != nil {
+			panic(err)
+		}
+		r = topics[connector].Topics
+	}
+	return
+}
+
+// ResetTopics empties the set of active topics of the specified connector.
+func (client *ConnectRestClient) ResetTopics(connector string) error {
+	_, e := requestAndGetResponse("PUT", client.connectEndPoint()+connector+"/topics/reset", nil)
+	return e
+}
+
+// GetLoggers lists the loggers of the worker which have an explicit level, requires workers 2.4 or later.
+// Return a map of LoggerLevel by logger name.
+func (client *ConnectRestClient) GetLoggers() (r map[string]LoggerLevel, e error) {
+	response, e := requestAndGetResponse("GET", client.hostname()+"/admin/loggers", nil)
+	if e == nil {
+		err := json.Unmarshal(response, &r)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return
+}
+
+// GetLogger gets the level of the specified logger.
+// Return a new LoggerLevel struct.
+func (client *ConnectRestClient) GetLogger(name string) (r LoggerLevel, e error) {
+	response, e := requestAndGetResponse("GET", client.hostname()+"/admin/loggers/"+name, nil)
+	if e == nil {
+		err := json.Unmarshal(response, &r)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return
+}
+
+// SetLogLevel changes the level of the specified logger and of its descendants, either SCOPE_WORKER or SCOPE_CLUSTER.
+// Return the names of the loggers which have been changed, which are unknown for SCOPE_CLUSTER.
+func (client *ConnectRestClient) SetLogLevel(name string, level string, scope string) (r []string, e error) {
+	bytes, _ := json.Marshal(LoggerLevel{Level: level})
+	body := string(bytes)
+	response, e := requestAndGetResponse("PUT", client.hostname()+"/admin/loggers/"+name+"?scope="+scope, &body)
+	if e == nil && len(response) > 0 {
+		err := json.Unmarshal(response, &r)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return
+}
+
+// Return the message of a worker response.
+func readMessage(response []byte) string {
+	var message struct {
+		Message string `json:"message"`
+	}
+	err := json.Unmarshal(response, &message)
+	if err != nil {
+		panic(err)
+	}
+	return message.Message
+}
+
 // Create submit a new connector configuration.
 // Return a JSON string describing the new connector configuration.
 func (client *ConnectRestClient) Create(config ConnectorConfig) (r string, e error) {
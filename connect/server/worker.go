@@ -0,0 +1,667 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package server implements an in-memory Kafka Connect worker emulating the connectors REST API.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fhussonnois/kafkacli/connect"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Connector and task states.
+const (
+	RUNNING    = "RUNNING"
+	PAUSED     = "PAUSED"
+	FAILED     = "FAILED"
+	UNASSIGNED = "UNASSIGNED"
+	STOPPED    = "STOPPED"
+	RESTARTING = "RESTARTING"
+)
+
+const (
+	SOURCE  = "source"
+	SINK    = "sink"
+	VERSION = "2.0.0"
+	// ROOT_LOGGER is the name of the ancestor of all loggers.
+	ROOT_LOGGER = "root"
+	// CONTROL is the path prefix of the endpoints used to inject failures, which are not part of the Connect REST API.
+	CONTROL = "mock"
+)
+
+// Plugin is a connector plugin installed on the worker.
+type Plugin struct {
+	Class   string `json:"class"`
+	Type    string `json:"type"`
+	Version string `json:"version"`
+}
+
+// DefaultPlugins lists the plugins installed on a new Worker.
+var DefaultPlugins = []Plugin{
+	{Class: "org.apache.kafka.connect.file.FileStreamSinkConnector", Type: SINK, Version: VERSION},
+	{Class: "org.apache.kafka.connect.file.FileStreamSourceConnector", Type: SOURCE, Version: VERSION},
+}
+
+// Worker is an http.Handler emulating a Kafka Connect worker: connectors can be created, updated, paused, resumed,
+// stopped, restarted and deleted, and each connector runs as many tasks as its "tasks.max" configuration.
+// Running tasks are deemed to use the topics of the "topics" (sink) or "topic" (source) configuration.
+// Log levels can be changed through the /admin/loggers endpoints, without any effect.
+// To emulate a cluster, connectors and tasks can be assigned in a round-robin fashion to several Members.
+// As on a real worker, failed connectors and tasks stay failed until they are restarted.
+//
+// Failures are injected, and offsets committed, through the control endpoints:
+//
+//	PUT /mock/connectors/{name}/state            {"state": "FAILED", "trace": "..."}
+//	PUT /mock/connectors/{name}/tasks/{id}/state {"state": "FAILED", "trace": "..."}
+//	PUT /mock/connectors/{name}/offsets          {"offsets": [{"partition": {...}, "offset": {...}}]}
+type Worker struct {
+	WorkerID string
+	// Members are the IDs of the workers of the emulated cluster, none meaning WorkerID only.
+	Members    []string
+	Plugins    []Plugin
+	lock       sync.Mutex
+	connectors map[string]*connector
+	loggers    map[string]connect.LoggerLevel
+	// assigned is the number of connectors and tasks assigned so far, used to pick the next member.
+	assigned int
+}
+
+type connector struct {
+	config  map[string]string
+	state   string
+	trace   string
+	member  int
+	tasks   []*task
+	offsets []connect.ConnectorOffset
+	topics  map[string]bool
+}
+
+type task struct {
+	state  string
+	trace  string
+	member int
+}
+
+// Create a new Worker, identified by the given ID in statuses (e.g host:port).
+func New(workerID string) *Worker {
+	return &Worker{
+		WorkerID:   workerID,
+		Plugins:    DefaultPlugins,
+		connectors: make(map[string]*connector),
+		loggers:    map[string]connect.LoggerLevel{ROOT_LOGGER: {Level: "INFO"}},
+	}
+}
+
+// SetConnectorState changes the state of a connector, e.g to simulate a failure along with its trace.
+func (w *Worker) SetConnectorState(name string, state string, trace string) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	conn, ok := w.connectors[name]
+	if !ok {
+		return fmt.Errorf("Connector %s not found", name)
+	}
+	if err := checkState(state); err != nil {
+		return err
+	}
+	conn.state, conn.trace = state, trace
+	return nil
+}
+
+// SetTaskState changes the state of a task, e.g to simulate a failure along with its trace.
+func (w *Worker) SetTaskState(name string, id int, state string, trace string) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	conn, ok := w.connectors[name]
+	if !ok {
+		return fmt.Errorf("Connector %s not found", name)
+	}
+	if id < 0 || id >= len(conn.tasks) {
+		return fmt.Errorf("Task %s-%d not found", name, id)
+	}
+	if err := checkState(state); err != nil {
+		return err
+	}
+	conn.tasks[id].state, conn.tasks[id].trace = state, trace
+	return nil
+}
+
+// CommitOffsets changes the offsets of a connector for the given partitions, as if they were committed by its tasks.
+func (w *Worker) CommitOffsets(name string, offsets []connect.ConnectorOffset) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	conn, ok := w.connectors[name]
+	if !ok {
+		return fmt.Errorf("Connector %s not found", name)
+	}
+	if err := w.checkOffsets(conn, offsets); err != nil {
+		return err
+	}
+	conn.mergeOffsets(offsets)
+	return nil
+}
+
+func checkState(state string) error {
+	switch state {
+	case RUNNING, PAUSED, FAILED, UNASSIGNED, STOPPED:
+		return nil
+	}
+	return fmt.Errorf("Invalid state %s", state)
+}
+
+// restError is the body of error responses.
+type restError struct {
+	Code    int    `json:"error_code"`
+	Message string `json:"message"`
+}
+
+func newError(code int, format string, args ...interface{}) *restError {
+	return &restError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// response is a successful response, with an optional body.
+type response struct {
+	status int
+	body   interface{}
+}
+
+func ok(body interface{}) (response, *restError) {
+	return response{status: http.StatusOK, body: body}, nil
+}
+
+// ServeHTTP implements the REST API.
+func (w *Worker) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	var segments []string
+	for _, segment := range strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/") {
+		segment, _ = url.PathUnescape(segment)
+		segments = append(segments, segment)
+	}
+
+	var res response
+	var err *restError
+	if segments[0] == CONTROL {
+		err = w.control(r, segments[1:])
+		res.status = http.StatusNoContent
+	} else {
+		w.lock.Lock()
+		res, err = w.route(r, segments)
+		w.lock.Unlock()
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		rw.WriteHeader(err.Code)
+		json.NewEncoder(rw).Encode(err)
+		return
+	}
+	rw.WriteHeader(res.status)
+	if res.body != nil {
+		json.NewEncoder(rw).Encode(res.body)
+	}
+}
+
+// control implements the endpoints used to inject failures and commit offsets.
+func (w *Worker) control(r *http.Request, segments []string) *restError {
+	var state struct {
+		State string `json:"state"`
+		Trace string `json:"trace"`
+	}
+	if r.Method != "PUT" || len(segments) < 3 || segments[0] != "connectors" {
+		return newError(http.StatusNotFound, "HTTP 404 Not Found")
+	}
+	if len(segments) == 3 && segments[2] == "offsets" {
+		var offsets connect.ConnectorOffsets
+		if err := json.NewDecoder(r.Body).Decode(&offsets); err != nil {
+			return newError(http.StatusBadRequest, "Invalid offsets: %s", err)
+		}
+		if err := w.CommitOffsets(segments[1], offsets.Offsets); err != nil {
+			return newError(http.StatusBadRequest, "%s", err)
+		}
+		return nil
+	}
+	if segments[len(segments)-1] != "state" {
+		return newError(http.StatusNotFound, "HTTP 404 Not Found")
+	}
+	if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+		return newError(http.StatusBadRequest, "Invalid state: %s", err)
+	}
+	var err error
+	switch len(segments) {
+	case 3:
+		err = w.SetConnectorState(segments[1], state.State, state.Trace)
+	case 5:
+		id, e := strconv.Atoi(segments[3])
+		if e != nil || segments[2] != "tasks" {
+			return newError(http.StatusNotFound, "HTTP 404 Not Found")
+		}
+		err = w.SetTaskState(segments[1], id, state.State, state.Trace)
+	default:
+		return newError(http.StatusNotFound, "HTTP 404 Not Found")
+	}
+	if err != nil {
+		return newError(http.StatusBadRequest, "%s", err)
+	}
+	return nil
+}
+
+func (w *Worker) route(r *http.Request, segments []string) (response, *restError) {
+	n := len(segments)
+	path := strings.Join(segments, "/")
+	switch {
+	case path == "" && r.Method == "GET":
+		return ok(map[string]string{"version": VERSION, "commit": "mock", "kafka_cluster_id": "mock"})
+	case path == "connector-plugins" && r.Method == "GET":
+		return ok(w.Plugins)
+	case path == "admin/loggers" && r.Method == "GET":
+		return ok(w.loggers)
+	case n == 3 && segments[0] == "admin" && segments[1] == "loggers":
+		return w.logger(r, segments[2])
+	case path == "connectors" && r.Method == "GET":
+		if expand := r.URL.Query()["expand"]; len(expand) > 0 {
+			return ok(w.expand(expand))
+		}
+		return ok(w.list())
+	case path == "connectors" && r.Method == "POST":
+		var config connect.ConnectorConfig
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil || config.Name == "" || config.Config == nil {
+			return response{}, newError(http.StatusBadRequest, "Invalid connector configuration")
+		}
+		if _, exists := w.connectors[config.Name]; exists {
+			return response{}, newError(http.StatusConflict, "Connector %s already exists", config.Name)
+		}
+		return w.put(config.Name, config.Config)
+	case n < 2 || segments[0] != "connectors":
+		return response{}, newError(http.StatusNotFound, "HTTP 404 Not Found")
+	}
+
+	name := segments[1]
+	action := strings.Join(segments[2:], "/")
+	if action == "config" && r.Method == "PUT" {
+		var config map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil || config == nil {
+			return response{}, newError(http.StatusBadRequest, "Invalid connector configuration")
+		}
+		return w.put(name, config)
+	}
+	conn, exists := w.connectors[name]
+	if !exists {
+		return response{}, newError(http.StatusNotFound, "Connector %s not found", name)
+	}
+	switch {
+	case action == "" && r.Method == "GET":
+		return ok(w.info(name, conn))
+	case action == "" && r.Method == "DELETE":
+		delete(w.connectors, name)
+		return response{status: http.StatusNoContent}, nil
+	case action == "config" && r.Method == "GET":
+		return ok(conn.config)
+	case action == "status" && r.Method == "GET":
+		return ok(w.status(name, conn))
+	case action == "tasks" && r.Method == "GET":
+		tasks := []map[string]interface{}{}
+		for i := range conn.tasks {
+			tasks = append(tasks, map[string]interface{}{"id": taskID{name, i}, "config": conn.config})
+		}
+		return ok(tasks)
+	case action == "pause" && r.Method == "PUT":
+		conn.setTargetState(PAUSED, w.assign)
+		return response{status: http.StatusAccepted}, nil
+	case action == "resume" && r.Method == "PUT":
+		conn.setTargetState(RUNNING, w.assign)
+		return response{status: http.StatusAccepted}, nil
+	case action == "stop" && r.Method == "PUT":
+		conn.state, conn.trace, conn.tasks = STOPPED, "", nil
+		return response{status: http.StatusAccepted}, nil
+	case action == "offsets" && r.Method == "GET":
+		return ok(connect.ConnectorOffsets{Offsets: append([]connect.ConnectorOffset{}, conn.offsets...)})
+	case action == "offsets" && (r.Method == "PATCH" || r.Method == "DELETE"):
+		return w.updateOffsets(name, conn, r)
+	case action == "topics" && r.Method == "GET":
+		topics := []string{}
+		for topic := range conn.topics {
+			topics = append(topics, topic)
+		}
+		sort.Strings(topics)
+		return ok(map[string]interface{}{name: map[string][]string{"topics": topics}})
+	case action == "topics/reset" && r.Method == "PUT":
+		conn.topics = nil
+		return response{status: http.StatusOK}, nil
+	case action == "restart" && r.Method == "POST":
+		query := r.URL.Query()
+		return w.restart(name, conn, query.Get("includeTasks") == "true", query.Get("onlyFailed") == "true")
+	case n == 5 && segments[2] == "tasks":
+		id, err := strconv.Atoi(segments[3])
+		if err != nil || id < 0 || id >= len(conn.tasks) {
+			return response{}, newError(http.StatusNotFound, "Task %s-%s not found", name, segments[3])
+		}
+		t := conn.tasks[id]
+		switch {
+		case segments[4] == "status" && r.Method == "GET":
+			return ok(taskStatus{ID: id, State: t.state, WorkerID: w.memberID(t.member), Trace: t.trace})
+		case segments[4] == "restart" && r.Method == "POST":
+			t.state, t.trace = conn.runningState(), ""
+			return response{status: http.StatusNoContent}, nil
+		}
+	}
+	return response{}, newError(http.StatusNotFound, "HTTP 404 Not Found")
+}
+
+// restart restarts the connector and, if includeTasks is set, its tasks. Without any option, the response has no content.
+// Otherwise, the response is the status in which restarted instances are RESTARTING, these being restarted right after.
+func (w *Worker) restart(name string, conn *connector, includeTasks bool, onlyFailed bool) (response, *restError) {
+	if conn.state == STOPPED {
+		return response{}, newError(http.StatusConflict, "Cannot restart connector %s as it is stopped", name)
+	}
+	status := w.status(name, conn)
+	if !onlyFailed || conn.state == FAILED {
+		status.Connector.State, status.Connector.Trace = RESTARTING, ""
+		conn.state, conn.trace = conn.runningState(), ""
+	}
+	for i, t := range conn.tasks {
+		if includeTasks && (!onlyFailed || t.state == FAILED) {
+			status.Tasks[i].State, status.Tasks[i].Trace = RESTARTING, ""
+			t.state, t.trace = conn.runningState(), ""
+		}
+	}
+	if !includeTasks && !onlyFailed {
+		return response{status: http.StatusNoContent}, nil
+	}
+	return response{status: http.StatusAccepted, body: status}, nil
+}
+
+// updateOffsets alters (PATCH) or resets (DELETE) the offsets of a connector, which must be stopped.
+func (w *Worker) updateOffsets(name string, conn *connector, r *http.Request) (response, *restError) {
+	if conn.state != STOPPED {
+		return response{}, newError(http.StatusBadRequest, "Connectors must be in the STOPPED state before their offsets can be modified. "+
+			"This can be done for the specified connector by issuing a 'PUT' request to the '/connectors/%s/stop' endpoint", name)
+	}
+	if r.Method == "DELETE" {
+		conn.offsets = nil
+		return ok(map[string]string{"message": "The offsets for this connector have been reset successfully"})
+	}
+	var offsets connect.ConnectorOffsets
+	if err := json.NewDecoder(r.Body).Decode(&offsets); err != nil || len(offsets.Offsets) == 0 {
+		return response{}, newError(http.StatusBadRequest, "The offsets to be modified must not be null or empty")
+	}
+	if err := w.checkOffsets(conn, offsets.Offsets); err != nil {
+		return response{}, newError(http.StatusBadRequest, "%s", err)
+	}
+	conn.mergeOffsets(offsets.Offsets)
+	return ok(map[string]string{"message": "The offsets for this connector have been altered successfully"})
+}
+
+// checkOffsets checks that partitions are defined, and are Kafka topic-partitions for sink connectors.
+func (w *Worker) checkOffsets(conn *connector, offsets []connect.ConnectorOffset) error {
+	sink := w.pluginType(conn.config["connector.class"]) == SINK
+	for _, o := range offsets {
+		if o.Partition == nil {
+			return fmt.Errorf("Partitions must not be null")
+		}
+		if _, _, _, isSink := o.SinkOffset(); sink && !isSink {
+			return fmt.Errorf("Sink connector partitions must contain the keys '%s' and '%s', and offsets the key '%s'",
+				connect.KAFKA_TOPIC, connect.KAFKA_PARTITION, connect.KAFKA_OFFSET)
+		}
+	}
+	return nil
+}
+
+// logger gets (GET) or sets (PUT) the level of a logger. The level of a logger is also set on its descendants.
+func (w *Worker) logger(r *http.Request, name string) (response, *restError) {
+	if r.Method == "GET" {
+		level, exists := w.loggers[name]
+		if !exists {
+			return response{}, newError(http.StatusNotFound, "Logger %s not found.", name)
+		}
+		return ok(level)
+	}
+	if r.Method != "PUT" {
+		return response{}, newError(http.StatusNotFound, "HTTP 404 Not Found")
+	}
+	var body connect.LoggerLevel
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Level == "" {
+		return response{}, newError(http.StatusBadRequest, "Desired 'level' parameter was not specified in request.")
+	}
+	level := strings.ToUpper(body.Level)
+	switch level {
+	case "OFF", "FATAL", "ERROR", "WARN", "INFO", "DEBUG", "TRACE":
+	default:
+		return response{}, newError(http.StatusNotFound, "invalid log level '%s'.", body.Level)
+	}
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	w.loggers[name] = connect.LoggerLevel{}
+	affected := []string{}
+	for logger := range w.loggers {
+		if logger == name || name == ROOT_LOGGER || strings.HasPrefix(logger, name+".") {
+			w.loggers[logger] = connect.LoggerLevel{Level: level, LastModified: &now}
+			affected = append(affected, logger)
+		}
+	}
+	sort.Strings(affected)
+	if r.URL.Query().Get("scope") == connect.SCOPE_CLUSTER {
+		return response{status: http.StatusNoContent}, nil
+	}
+	return ok(affected)
+}
+
+func (w *Worker) list() []string {
+	res := make([]string, 0, len(w.connectors))
+	for name := range w.connectors {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// expand returns the status and/or the info of each connector, by connector name.
+func (w *Worker) expand(expand []string) map[string]map[string]interface{} {
+	res := make(map[string]map[string]interface{})
+	for name, conn := range w.connectors {
+		res[name] = make(map[string]interface{})
+		for _, expansion := range expand {
+			switch expansion {
+			case connect.EXPAND_STATUS:
+				res[name][expansion] = w.status(name, conn)
+			case connect.EXPAND_INFO:
+				res[name][expansion] = w.info(name, conn)
+			}
+		}
+	}
+	return res
+}
+
+// put creates or updates a connector. As on a real worker, tasks are restarted with the new configuration.
+func (w *Worker) put(name string, config map[string]string) (response, *restError) {
+	class := config["connector.class"]
+	if class == "" {
+		return response{}, newError(http.StatusBadRequest, "Connector config {%s} contains no connector type", name)
+	}
+	if w.pluginType(class) == "" {
+		return response{}, newError(http.StatusBadRequest, "Failed to find any class that implements Connector and which name matches %s", class)
+	}
+	if value, ok := config["tasks.max"]; ok {
+		if n, err := strconv.Atoi(value); err != nil || n < 1 {
+			return response{}, newError(http.StatusBadRequest, "Invalid value %s for configuration tasks.max", value)
+		}
+	}
+	stored := make(map[string]string)
+	for k, v := range config {
+		stored[k] = v
+	}
+	stored["name"] = name
+
+	status := http.StatusOK
+	conn, exists := w.connectors[name]
+	if !exists {
+		status = http.StatusCreated
+		conn = &connector{state: RUNNING, member: w.assign()}
+		w.connectors[name] = conn
+	}
+	conn.config = stored
+	conn.startTasks(w.assign)
+	return response{status: status, body: w.info(name, conn)}, nil
+}
+
+func (w *Worker) pluginType(class string) string {
+	for _, p := range w.Plugins {
+		if p.Class == class || strings.HasSuffix(p.Class, "."+class) {
+			return p.Type
+		}
+	}
+	return ""
+}
+
+// runningState returns the state of a started task, which depends on whether the connector is paused.
+func (conn *connector) runningState() string {
+	if conn.state == PAUSED {
+		return PAUSED
+	}
+	return RUNNING
+}
+
+// assign returns the member to assign a new connector or task to.
+func (w *Worker) assign() int {
+	w.assigned++
+	return w.assigned - 1
+}
+
+// memberID returns the ID of an assigned member.
+func (w *Worker) memberID(member int) string {
+	if len(w.Members) == 0 {
+		return w.WorkerID
+	}
+	return w.Members[member%len(w.Members)]
+}
+
+// startTasks (re)starts as many tasks as the "tasks.max" configuration, unless the connector is stopped.
+func (conn *connector) startTasks(assign func() int) {
+	conn.tasks = nil
+	if conn.state == STOPPED {
+		return
+	}
+	tasksMax, err := strconv.Atoi(conn.config["tasks.max"])
+	if err != nil {
+		tasksMax = 1
+	}
+	for i := 0; i < tasksMax; i++ {
+		conn.tasks = append(conn.tasks, &task{state: conn.runningState(), member: assign()})
+	}
+	conn.trackTopics()
+}
+
+// trackTopics adds the topics used by the tasks to the active topics of the connector.
+func (conn *connector) trackTopics() {
+	if conn.topics == nil {
+		conn.topics = make(map[string]bool)
+	}
+	for _, key := range []string{"topics", "topic"} {
+		for _, topic := range strings.Split(conn.config[key], ",") {
+			if topic = strings.TrimSpace(topic); topic != "" {
+				conn.topics[topic] = true
+			}
+		}
+	}
+}
+
+// mergeOffsets replaces the offsets of the given partitions. Partitions with a nil offset are removed.
+func (conn *connector) mergeOffsets(offsets []connect.ConnectorOffset) {
+	for _, o := range offsets {
+		key, _ := json.Marshal(o.Partition)
+		i := 0
+		for ; i < len(conn.offsets); i++ {
+			if existing, _ := json.Marshal(conn.offsets[i].Partition); string(existing) == string(key) {
+				break
+			}
+		}
+		switch {
+		case o.Offset == nil && i < len(conn.offsets):
+			conn.offsets = append(conn.offsets[:i], conn.offsets[i+1:]...)
+		case o.Offset != nil && i < len(conn.offsets):
+			conn.offsets[i] = o
+		case o.Offset != nil:
+			conn.offsets = append(conn.offsets, o)
+		}
+	}
+}
+
+// setTargetState pauses or resumes the connector and its tasks. Failed ones are left unchanged,
+// while the tasks of a stopped connector are started again.
+func (conn *connector) setTargetState(state string, assign func() int) {
+	if conn.state == STOPPED {
+		conn.state = state
+		conn.startTasks(assign)
+		return
+	}
+	if conn.state != FAILED {
+		conn.state = state
+	}
+	for _, t := range conn.tasks {
+		if t.state != FAILED {
+			t.state = state
+		}
+	}
+}
+
+type taskID struct {
+	Connector string `json:"connector"`
+	Task      int    `json:"task"`
+}
+
+type connectorInfo struct {
+	Name   string            `json:"name"`
+	Config map[string]string `json:"config"`
+	Tasks  []taskID          `json:"tasks"`
+	Type   string            `json:"type"`
+}
+
+func (w *Worker) info(name string, conn *connector) connectorInfo {
+	res := connectorInfo{Name: name, Config: conn.config, Tasks: []taskID{}, Type: w.pluginType(conn.config["connector.class"])}
+	for i := range conn.tasks {
+		res.Tasks = append(res.Tasks, taskID{name, i})
+	}
+	return res
+}
+
+type taskStatus struct {
+	ID       int    `json:"id"`
+	State    string `json:"state"`
+	WorkerID string `json:"worker_id"`
+	Trace    string `json:"trace,omitempty"`
+}
+
+type connectorStatus struct {
+	Name      string `json:"name"`
+	Connector struct {
+		State    string `json:"state"`
+		WorkerID string `json:"worker_id"`
+		Trace    string `json:"trace,omitempty"`
+	} `json:"connector"`
+	Tasks []taskStatus `json:"tasks"`
+	Type  string       `json:"type"`
+}
+
+func (w *Worker) status(name string, conn *connector) connectorStatus {
+	res := connectorStatus{Name: name, Tasks: []taskStatus{}, Type: w.pluginType(conn.config["connector.class"])}
+	res.Connector.State, res.Connector.WorkerID, res.Connector.Trace = conn.state, w.memberID(conn.member), conn.trace
+	for i, t := range conn.tasks {
+		res.Tasks = append(res.Tasks, taskStatus{ID: i, State: t.state, WorkerID: w.memberID(t.member), Trace: t.trace})
+	}
+	return res
+}
@@ -0,0 +1,240 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	SINK_CONFIG   = `{"connector.class":"FileStreamSinkConnector","tasks.max":"2","topics":"events"}`
+	SOURCE_CONFIG = `{"connector.class":"org.apache.kafka.connect.file.FileStreamSourceConnector","topic":"lines"}`
+)
+
+// call sends a request to the worker and returns the status and the raw body of the response.
+func call(w *Worker, method string, path string, body string) (int, string) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, req)
+	return rec.Code, strings.TrimSpace(rec.Body.String())
+}
+
+func expect(t *testing.T, w *Worker, method string, path string, body string, status int, response string) {
+	t.Helper()
+	code, res := call(w, method, path, body)
+	if code != status || res != response {
+		t.Errorf("%s %s: got %d %s, expected %d %s", method, path, code, res, status, response)
+	}
+}
+
+// expectStatus only checks the HTTP status of the response.
+func expectStatus(t *testing.T, w *Worker, method string, path string, body string, status int) {
+	t.Helper()
+	if code, res := call(w, method, path, body); code != status {
+		t.Errorf("%s %s: got %d %s, expected %d", method, path, code, res, status)
+	}
+}
+
+func newWorker(t *testing.T, connectors ...string) *Worker {
+	w := New("worker:8083")
+	for _, name := range connectors {
+		expectStatus(t, w, "POST", "/connectors", `{"name":"`+name+`","config":`+SINK_CONFIG+`}`, 201)
+	}
+	return w
+}
+
+func TestConnectorLifecycle(t *testing.T) {
+	w := newWorker(t)
+	expect(t, w, "GET", "/connectors", "", 200, `[]`)
+	expect(t, w, "POST", "/connectors", `{"name":"sink","config":`+SINK_CONFIG+`}`, 201,
+		`{"name":"sink","config":{"connector.class":"FileStreamSinkConnector","name":"sink","tasks.max":"2","topics":"events"},`+
+			`"tasks":[{"connector":"sink","task":0},{"connector":"sink","task":1}],"type":"sink"}`)
+	expectStatus(t, w, "POST", "/connectors", `{"name":"sink","config":`+SINK_CONFIG+`}`, 409)
+	expect(t, w, "PUT", "/connectors/source/config", SOURCE_CONFIG, 201,
+		`{"name":"source","config":{"connector.class":"org.apache.kafka.connect.file.FileStreamSourceConnector","name":"source","topic":"lines"},`+
+			`"tasks":[{"connector":"source","task":0}],"type":"source"}`)
+	expect(t, w, "GET", "/connectors", "", 200, `["sink","source"]`)
+	expect(t, w, "GET", "/connectors/sink/config", "", 200, `{"connector.class":"FileStreamSinkConnector","name":"sink","tasks.max":"2","topics":"events"}`)
+	expect(t, w, "GET", "/connectors/source/tasks", "", 200,
+		`[{"config":{"connector.class":"org.apache.kafka.connect.file.FileStreamSourceConnector","name":"source","topic":"lines"},"id":{"connector":"source","task":0}}]`)
+
+	// Updating the configuration restarts the tasks.
+	expectStatus(t, w, "PUT", "/connectors/sink/config", `{"connector.class":"FileStreamSinkConnector","tasks.max":"1","topics":"events"}`, 200)
+	expect(t, w, "GET", "/connectors/sink/status", "", 200,
+		`{"name":"sink","connector":{"state":"RUNNING","worker_id":"worker:8083"},"tasks":[{"id":0,"state":"RUNNING","worker_id":"worker:8083"}],"type":"sink"}`)
+
+	expect(t, w, "DELETE", "/connectors/sink", "", 204, ``)
+	expect(t, w, "GET", "/connectors/sink", "", 404, `{"error_code":404,"message":"Connector sink not found"}`)
+	expectStatus(t, w, "DELETE", "/connectors/sink", "", 404)
+	expect(t, w, "GET", "/connectors", "", 200, `["source"]`)
+}
+
+func TestInvalidConfigurations(t *testing.T) {
+	w := newWorker(t)
+	expectStatus(t, w, "POST", "/connectors", `{"name":"sink"}`, 400)
+	expectStatus(t, w, "POST", "/connectors", `{`, 400)
+	expect(t, w, "PUT", "/connectors/sink/config", `{"tasks.max":"1"}`, 400, `{"error_code":400,"message":"Connector config {sink} contains no connector type"}`)
+	expectStatus(t, w, "PUT", "/connectors/sink/config", `{"connector.class":"UnknownConnector"}`, 400)
+	expectStatus(t, w, "PUT", "/connectors/sink/config", `{"connector.class":"FileStreamSinkConnector","tasks.max":"0"}`, 400)
+	expect(t, w, "GET", "/connectors", "", 200, `[]`)
+}
+
+func TestPauseResumeAndStop(t *testing.T) {
+	w := newWorker(t, "sink")
+	expect(t, w, "PUT", "/connectors/sink/pause", "", 202, ``)
+	expect(t, w, "GET", "/connectors/sink/tasks/1/status", "", 200, `{"id":1,"state":"PAUSED","worker_id":"worker:8083"}`)
+	// Restarting a task of a paused connector leaves it paused.
+	expect(t, w, "POST", "/connectors/sink/tasks/1/restart", "", 204, ``)
+	expect(t, w, "GET", "/connectors/sink/tasks/1/status", "", 200, `{"id":1,"state":"PAUSED","worker_id":"worker:8083"}`)
+	expectStatus(t, w, "PUT", "/connectors/sink/resume", "", 202)
+	expect(t, w, "GET", "/connectors/sink/tasks/1/status", "", 200, `{"id":1,"state":"RUNNING","worker_id":"worker:8083"}`)
+
+	expectStatus(t, w, "PUT", "/connectors/sink/stop", "", 202)
+	expect(t, w, "GET", "/connectors/sink/status", "", 200,
+		`{"name":"sink","connector":{"state":"STOPPED","worker_id":"worker:8083"},"tasks":[],"type":"sink"}`)
+	expectStatus(t, w, "POST", "/connectors/sink/restart", "", 409)
+	expectStatus(t, w, "GET", "/connectors/sink/tasks/0/status", "", 404)
+	// Resuming a stopped connector starts its tasks again.
+	expectStatus(t, w, "PUT", "/connectors/sink/resume", "", 202)
+	expect(t, w, "GET", "/connectors/sink/status", "", 200,
+		`{"name":"sink","connector":{"state":"RUNNING","worker_id":"worker:8083"},"tasks":[`+
+			`{"id":0,"state":"RUNNING","worker_id":"worker:8083"},{"id":1,"state":"RUNNING","worker_id":"worker:8083"}],"type":"sink"}`)
+}
+
+func TestFailuresAndRestarts(t *testing.T) {
+	w := newWorker(t, "sink")
+	expect(t, w, "PUT", "/mock/connectors/sink/state", `{"state":"FAILED","trace":"boom"}`, 204, ``)
+	expect(t, w, "PUT", "/mock/connectors/sink/tasks/1/state", `{"state":"FAILED","trace":"task boom"}`, 204, ``)
+	expect(t, w, "GET", "/connectors/sink/status", "", 200,
+		`{"name":"sink","connector":{"state":"FAILED","worker_id":"worker:8083","trace":"boom"},"tasks":[`+
+			`{"id":0,"state":"RUNNING","worker_id":"worker:8083"},{"id":1,"state":"FAILED","worker_id":"worker:8083","trace":"task boom"}],"type":"sink"}`)
+
+	// Failed instances stay failed when paused and resumed.
+	expectStatus(t, w, "PUT", "/connectors/sink/pause", "", 202)
+	expectStatus(t, w, "PUT", "/connectors/sink/resume", "", 202)
+	expect(t, w, "GET", "/connectors/sink/tasks/1/status", "", 200, `{"id":1,"state":"FAILED","worker_id":"worker:8083","trace":"task boom"}`)
+
+	// Only the failed instances are restarted, and reported as RESTARTING.
+	expect(t, w, "POST", "/connectors/sink/restart?includeTasks=true&onlyFailed=true", "", 202,
+		`{"name":"sink","connector":{"state":"RESTARTING","worker_id":"worker:8083"},"tasks":[`+
+			`{"id":0,"state":"RUNNING","worker_id":"worker:8083"},{"id":1,"state":"RESTARTING","worker_id":"worker:8083"}],"type":"sink"}`)
+	expect(t, w, "GET", "/connectors/sink/tasks/1/status", "", 200, `{"id":1,"state":"RUNNING","worker_id":"worker:8083"}`)
+
+	// Without any option, only the connector is restarted.
+	expect(t, w, "PUT", "/mock/connectors/sink/tasks/0/state", `{"state":"FAILED"}`, 204, ``)
+	expect(t, w, "POST", "/connectors/sink/restart", "", 204, ``)
+	expect(t, w, "GET", "/connectors/sink/tasks/0/status", "", 200, `{"id":0,"state":"FAILED","worker_id":"worker:8083"}`)
+	expect(t, w, "POST", "/connectors/sink/tasks/0/restart", "", 204, ``)
+	expect(t, w, "GET", "/connectors/sink/tasks/0/status", "", 200, `{"id":0,"state":"RUNNING","worker_id":"worker:8083"}`)
+	expectStatus(t, w, "POST", "/connectors/sink/tasks/2/restart", "", 404)
+}
+
+func TestControlEndpoints(t *testing.T) {
+	w := newWorker(t, "sink")
+	expect(t, w, "PUT", "/mock/connectors/sink/state", `{"state":"BROKEN"}`, 400, `{"error_code":400,"message":"Invalid state BROKEN"}`)
+	expect(t, w, "PUT", "/mock/connectors/unknown/state", `{"state":"FAILED"}`, 400, `{"error_code":400,"message":"Connector unknown not found"}`)
+	expect(t, w, "PUT", "/mock/connectors/sink/tasks/2/state", `{"state":"FAILED"}`, 400, `{"error_code":400,"message":"Task sink-2 not found"}`)
+	expectStatus(t, w, "PUT", "/mock/connectors/sink/tasks/x/state", `{"state":"FAILED"}`, 404)
+	expectStatus(t, w, "GET", "/mock/connectors/sink/state", "", 404)
+	expectStatus(t, w, "PUT", "/mock/connectors/sink", `{"state":"FAILED"}`, 404)
+	expectStatus(t, w, "PUT", "/mock/connectors/sink/state", `{`, 400)
+}
+
+func TestOffsets(t *testing.T) {
+	w := newWorker(t, "sink")
+	committed := `{"offsets":[{"partition":{"kafka_partition":0,"kafka_topic":"events"},"offset":{"kafka_offset":42}}]}`
+	expect(t, w, "PUT", "/mock/connectors/sink/offsets", committed, 204, ``)
+	expect(t, w, "GET", "/connectors/sink/offsets", "", 200, committed)
+	expect(t, w, "PUT", "/mock/connectors/sink/offsets", `{"offsets":[{"partition":{"file":"a.txt"},"offset":{"position":1}}]}`, 400,
+		`{"error_code":400,"message":"Sink connector partitions must contain the keys 'kafka_topic' and 'kafka_partition', and offsets the key 'kafka_offset'"}`)
+
+	altered := `{"offsets":[{"partition":{"kafka_partition":1,"kafka_topic":"events"},"offset":{"kafka_offset":7}}]}`
+	expectStatus(t, w, "PATCH", "/connectors/sink/offsets", altered, 400)
+	expectStatus(t, w, "DELETE", "/connectors/sink/offsets", "", 400)
+	expectStatus(t, w, "PUT", "/connectors/sink/stop", "", 202)
+	expectStatus(t, w, "PATCH", "/connectors/sink/offsets", `{"offsets":[]}`, 400)
+	expectStatus(t, w, "PATCH", "/connectors/sink/offsets", `{"offsets":[{"offset":{"kafka_offset":7}}]}`, 400)
+	expect(t, w, "PATCH", "/connectors/sink/offsets", altered, 200, `{"message":"The offsets for this connector have been altered successfully"}`)
+	expect(t, w, "GET", "/connectors/sink/offsets", "", 200,
+		`{"offsets":[{"partition":{"kafka_partition":0,"kafka_topic":"events"},"offset":{"kafka_offset":42}},`+
+			`{"partition":{"kafka_partition":1,"kafka_topic":"events"},"offset":{"kafka_offset":7}}]}`)
+
+	// A null offset removes the partition.
+	expectStatus(t, w, "PATCH", "/connectors/sink/offsets", `{"offsets":[{"partition":{"kafka_partition":0,"kafka_topic":"events"},"offset":null}]}`, 200)
+	expect(t, w, "GET", "/connectors/sink/offsets", "", 200, altered)
+	expect(t, w, "DELETE", "/connectors/sink/offsets", "", 200, `{"message":"The offsets for this connector have been reset successfully"}`)
+	expect(t, w, "GET", "/connectors/sink/offsets", "", 200, `{"offsets":[]}`)
+}
+
+func TestTopics(t *testing.T) {
+	w := newWorker(t, "sink")
+	expect(t, w, "GET", "/connectors/sink/topics", "", 200, `{"sink":{"topics":["events"]}}`)
+	expectStatus(t, w, "PUT", "/connectors/sink/config", `{"connector.class":"FileStreamSinkConnector","topics":"orders, users"}`, 200)
+	expect(t, w, "GET", "/connectors/sink/topics", "", 200, `{"sink":{"topics":["events","orders","users"]}}`)
+	expect(t, w, "PUT", "/connectors/sink/topics/reset", "", 200, ``)
+	expect(t, w, "GET", "/connectors/sink/topics", "", 200, `{"sink":{"topics":[]}}`)
+}
+
+func TestExpand(t *testing.T) {
+	w := newWorker(t, "sink")
+	expect(t, w, "GET", "/connectors?expand=status", "", 200,
+		`{"sink":{"status":{"name":"sink","connector":{"state":"RUNNING","worker_id":"worker:8083"},"tasks":[`+
+			`{"id":0,"state":"RUNNING","worker_id":"worker:8083"},{"id":1,"state":"RUNNING","worker_id":"worker:8083"}],"type":"sink"}}}`)
+	code, res := call(w, "GET", "/connectors?expand=status&expand=info", "")
+	if code != 200 || !strings.HasPrefix(res, `{"sink":{"info":{"name":"sink",`) || !strings.Contains(res, `"status":{"name":"sink",`) {
+		t.Errorf("unexpected expanded connectors: %d %s", code, res)
+	}
+}
+
+func TestMembers(t *testing.T) {
+	w := newWorker(t)
+	w.Members = []string{"worker-1:8083", "worker-2:8083", "worker-3:8083"}
+	expectStatus(t, w, "POST", "/connectors", `{"name":"sink","config":`+SINK_CONFIG+`}`, 201)
+	expect(t, w, "GET", "/connectors/sink/status", "", 200,
+		`{"name":"sink","connector":{"state":"RUNNING","worker_id":"worker-1:8083"},"tasks":[`+
+			`{"id":0,"state":"RUNNING","worker_id":"worker-2:8083"},{"id":1,"state":"RUNNING","worker_id":"worker-3:8083"}],"type":"sink"}`)
+	// Restarted tasks are assigned to the next members.
+	expectStatus(t, w, "PUT", "/connectors/sink/config", SINK_CONFIG, 200)
+	expect(t, w, "GET", "/connectors/sink/tasks/0/status", "", 200, `{"id":0,"state":"RUNNING","worker_id":"worker-1:8083"}`)
+	expect(t, w, "GET", "/connectors/sink/tasks/1/status", "", 200, `{"id":1,"state":"RUNNING","worker_id":"worker-2:8083"}`)
+}
+
+func TestLoggers(t *testing.T) {
+	w := newWorker(t)
+	expect(t, w, "GET", "/admin/loggers", "", 200, `{"root":{"level":"INFO"}}`)
+	expectStatus(t, w, "GET", "/admin/loggers/org.apache.kafka", "", 404)
+	expect(t, w, "PUT", "/admin/loggers/org.apache.kafka.connect", `{"level":"debug"}`, 200, `["org.apache.kafka.connect"]`)
+	expect(t, w, "PUT", "/admin/loggers/org.apache.kafka", `{"level":"WARN"}`, 200, `["org.apache.kafka","org.apache.kafka.connect"]`)
+	code, res := call(w, "GET", "/admin/loggers/org.apache.kafka.connect", "")
+	if code != 200 || !strings.HasPrefix(res, `{"level":"WARN","last_modified":`) {
+		t.Errorf("unexpected logger level: %d %s", code, res)
+	}
+	expect(t, w, "PUT", "/admin/loggers/org.apache.kafka.connect?scope=cluster", `{"level":"ERROR"}`, 204, ``)
+	expect(t, w, "PUT", "/admin/loggers/root", `{"level":"TRACE"}`, 200, `["org.apache.kafka","org.apache.kafka.connect","root"]`)
+	expectStatus(t, w, "PUT", "/admin/loggers/root", `{"level":"VERBOSE"}`, 404)
+	expectStatus(t, w, "PUT", "/admin/loggers/root", `{}`, 400)
+	expectStatus(t, w, "DELETE", "/admin/loggers/root", "", 404)
+}
+
+func TestRootAndPlugins(t *testing.T) {
+	w := newWorker(t)
+	expect(t, w, "GET", "/", "", 200, `{"commit":"mock","kafka_cluster_id":"mock","version":"2.0.0"}`)
+	expect(t, w, "GET", "/connector-plugins", "", 200,
+		`[{"class":"org.apache.kafka.connect.file.FileStreamSinkConnector","type":"sink","version":"2.0.0"},`+
+			`{"class":"org.apache.kafka.connect.file.FileStreamSourceConnector","type":"source","version":"2.0.0"}]`)
+	expectStatus(t, w, "GET", "/unknown", "", 404)
+	expectStatus(t, w, "GET", "/connectors/sink/unknown", "", 404)
+}
@@ -0,0 +1,348 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package avro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Encode writes a value using the Avro binary encoding.
+// The value is expected to be a JSON decoded value, as accepted by Validate. Missing record fields
+// are encoded using their default value.
+func Encode(s *Schema, value interface{}) ([]byte, error) {
+	if errs := Validate(s, value); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid value at '%s': %s", errs[0].Path, errs[0].Message)
+	}
+	e := encoder{}
+	if err := e.encode(s, value, false); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// Decode reads a value written using the Avro binary encoding with the given schema.
+// Records and maps are decoded as map[string]interface{}, arrays as []interface{}, enums as string,
+// bytes and fixed as []byte, and unions as the value of the written type.
+func Decode(s *Schema, data []byte) (interface{}, error) {
+	d := decoder{data: data}
+	value, err := d.decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("%d unexpected trailing bytes", len(d.data)-d.pos)
+	}
+	return value, nil
+}
+
+type encoder struct {
+	buf bytes.Buffer
+}
+
+func (e *encoder) long(n int64) {
+	var b [binary.MaxVarintLen64]byte
+	e.buf.Write(b[:binary.PutVarint(b[:], n)])
+}
+
+func (e *encoder) bytes(b []byte) {
+	e.long(int64(len(b)))
+	e.buf.Write(b)
+}
+
+// encode writes the value. Default values of fields are encoded with isDefault set,
+// for which unions always use their first type.
+func (e *encoder) encode(s *Schema, value interface{}, isDefault bool) error {
+	switch s.Type {
+	case NULL:
+	case BOOLEAN:
+		if value.(bool) {
+			e.buf.WriteByte(1)
+		} else {
+			e.buf.WriteByte(0)
+		}
+	case INT, LONG:
+		n, err := toInt64(value)
+		if err != nil {
+			return err
+		}
+		e.long(n)
+	case FLOAT:
+		f, _ := toFloat(value)
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], math.Float32bits(float32(f)))
+		e.buf.Write(b[:])
+	case DOUBLE:
+		f, _ := toFloat(value)
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(f))
+		e.buf.Write(b[:])
+	case STRING:
+		e.bytes(toBytes(value, false))
+	case BYTES:
+		e.bytes(toBytes(value, true))
+	case FIXED:
+		e.buf.Write(toBytes(value, true))
+	case ENUM:
+		for i, symbol := range s.Symbols {
+			if symbol == value.(string) {
+				e.long(int64(i))
+			}
+		}
+	case ARRAY:
+		items := value.([]interface{})
+		if len(items) > 0 {
+			e.long(int64(len(items)))
+			for _, item := range items {
+				if err := e.encode(s.Items, item, isDefault); err != nil {
+					return err
+				}
+			}
+		}
+		e.long(0)
+	case MAP:
+		entries := value.(map[string]interface{})
+		if len(entries) > 0 {
+			e.long(int64(len(entries)))
+			for _, k := range sortedKeys(entries) {
+				e.bytes([]byte(k))
+				if err := e.encode(s.Values, entries[k], isDefault); err != nil {
+					return err
+				}
+			}
+		}
+		e.long(0)
+	case RECORD:
+		entries := value.(map[string]interface{})
+		for _, f := range s.Fields {
+			v, ok := entries[f.Name]
+			var err error
+			if ok {
+				err = e.encode(f.Type, v, isDefault)
+			} else {
+				err = e.encode(f.Type, f.Default, true)
+			}
+			if err != nil {
+				return fmt.Errorf("field '%s': %s", f.Name, err)
+			}
+		}
+	case UNION:
+		index := 0
+		if !isDefault {
+			index, value = resolveUnion(s, value)
+			if index < 0 {
+				return fmt.Errorf("value does not match any type of union %s", s.TypeName())
+			}
+		}
+		e.long(int64(index))
+		return e.encode(s.Types[index], value, isDefault)
+	}
+	return nil
+}
+
+func toInt64(value interface{}) (int64, error) {
+	switch n := value.(type) {
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := n.Float64()
+		return int64(f), err
+	case int:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	}
+	f, ok := toFloat(value)
+	if !ok {
+		return 0, fmt.Errorf("expected number but got %s", jsonType(value))
+	}
+	return int64(f), nil
+}
+
+// toBytes converts a string or []byte value. Strings representing bytes in JSON (e.g default values)
+// use one character per byte, as defined by the Avro specification.
+func toBytes(value interface{}, latin1 bool) []byte {
+	switch b := value.(type) {
+	case []byte:
+		return b
+	case string:
+		if !latin1 {
+			return []byte(b)
+		}
+		res := make([]byte, 0, len(b))
+		for _, r := range b {
+			res = append(res, byte(r))
+		}
+		return res
+	}
+	return nil
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+var errShortBuffer = errors.New("unexpected end of data")
+
+func (d *decoder) long() (int64, error) {
+	n, size := binary.Varint(d.data[d.pos:])
+	if size <= 0 {
+		return 0, errShortBuffer
+	}
+	d.pos += size
+	return n, nil
+}
+
+func (d *decoder) next(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.data) {
+		return nil, errShortBuffer
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *decoder) bytes() ([]byte, error) {
+	n, err := d.long()
+	if err != nil {
+		return nil, err
+	}
+	b, err := d.next(int(n))
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), b...), nil
+}
+
+func (d *decoder) decode(s *Schema) (interface{}, error) {
+	switch s.Type {
+	case NULL:
+		return nil, nil
+	case BOOLEAN:
+		b, err := d.next(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+	case INT:
+		n, err := d.long()
+		return int32(n), err
+	case LONG:
+		return d.long()
+	case FLOAT:
+		b, err := d.next(4)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b)), nil
+	case DOUBLE:
+		b, err := d.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case STRING:
+		b, err := d.bytes()
+		return string(b), err
+	case BYTES:
+		return d.bytes()
+	case FIXED:
+		b, err := d.next(s.Size)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case ENUM:
+		n, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 || int(n) >= len(s.Symbols) {
+			return nil, fmt.Errorf("invalid index %d for enum %s", n, s.Name)
+		}
+		return s.Symbols[n], nil
+	case ARRAY:
+		items := make([]interface{}, 0)
+		err := d.blocks(func() error {
+			item, err := d.decode(s.Items)
+			items = append(items, item)
+			return err
+		})
+		return items, err
+	case MAP:
+		entries := make(map[string]interface{})
+		err := d.blocks(func() error {
+			k, err := d.bytes()
+			if err != nil {
+				return err
+			}
+			entries[string(k)], err = d.decode(s.Values)
+			return err
+		})
+		return entries, err
+	case RECORD:
+		entries := make(map[string]interface{})
+		for _, f := range s.Fields {
+			v, err := d.decode(f.Type)
+			if err != nil {
+				return nil, fmt.Errorf("field '%s': %s", f.Name, err)
+			}
+			entries[f.Name] = v
+		}
+		return entries, nil
+	case UNION:
+		n, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 || int(n) >= len(s.Types) {
+			return nil, fmt.Errorf("invalid index %d for union %s", n, s.TypeName())
+		}
+		return d.decode(s.Types[n])
+	}
+	return nil, fmt.Errorf("unsupported type '%s'", s.Type)
+}
+
+// blocks reads the blocks of an array or a map, calling item for each item.
+func (d *decoder) blocks(item func() error) error {
+	for {
+		count, err := d.long()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		if count < 0 {
+			// a negative count is followed by the size of the block in bytes.
+			count = -count
+			if _, err := d.long(); err != nil {
+				return err
+			}
+		}
+		for i := int64(0); i < count; i++ {
+			if err := item(); err != nil {
+				return err
+			}
+		}
+	}
+}
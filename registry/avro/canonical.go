@@ -0,0 +1,189 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// CanonicalForm returns the Parsing Canonical Form of the schema, as defined by the Avro specification.
+// Attributes which are irrelevant to parsing data (doc, aliases, defaults, logical types...) are stripped.
+func (s *Schema) CanonicalForm() string {
+	w := schemaWriter{canonical: true, written: make(map[*Schema]bool)}
+	w.write(s)
+	return w.buf.String()
+}
+
+// NormalizedForm returns a compact representation of the schema with full names and attributes written
+// in the same order as the Parsing Canonical Form, but which retains docs, aliases, defaults, logical types
+// and custom properties. Two schemas differing only by whitespaces, attributes order or namespace
+// declarations have the same normalized form.
+func (s *Schema) NormalizedForm() string {
+	w := schemaWriter{canonical: false, written: make(map[*Schema]bool)}
+	w.write(s)
+	return w.buf.String()
+}
+
+// String returns the normalized form of the schema.
+func (s *Schema) String() string {
+	return s.NormalizedForm()
+}
+
+type schemaWriter struct {
+	canonical bool
+	written   map[*Schema]bool
+	buf       bytes.Buffer
+}
+
+func (w *schemaWriter) write(s *Schema) {
+	if s.IsNamed() && w.written[s] {
+		w.value(s.Name)
+		return
+	}
+	switch s.Type {
+	case UNION:
+		w.buf.WriteByte('[')
+		for i, t := range s.Types {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			w.write(t)
+		}
+		w.buf.WriteByte(']')
+		return
+	case NULL, BOOLEAN, INT, LONG, FLOAT, DOUBLE, BYTES, STRING:
+		if w.canonical || (s.LogicalType == "" && len(s.Props) == 0) {
+			w.value(s.Type)
+			return
+		}
+	}
+	if s.IsNamed() {
+		w.written[s] = true
+	}
+
+	w.buf.WriteByte('{')
+	first := true
+	attr := func(name string) {
+		if !first {
+			w.buf.WriteByte(',')
+		}
+		first = false
+		w.value(name)
+		w.buf.WriteByte(':')
+	}
+	if s.IsNamed() {
+		attr("name")
+		w.value(s.Name)
+	}
+	attr("type")
+	w.value(s.Type)
+	if !w.canonical && s.Doc != "" {
+		attr("doc")
+		w.value(s.Doc)
+	}
+	switch s.Type {
+	case RECORD:
+		attr("fields")
+		w.buf.WriteByte('[')
+		for i, f := range s.Fields {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			w.field(f)
+		}
+		w.buf.WriteByte(']')
+	case ENUM:
+		attr("symbols")
+		w.value(s.Symbols)
+		if !w.canonical && s.EnumDefault != "" {
+			attr("default")
+			w.value(s.EnumDefault)
+		}
+	case ARRAY:
+		attr("items")
+		w.write(s.Items)
+	case MAP:
+		attr("values")
+		w.write(s.Values)
+	case FIXED:
+		attr("size")
+		w.buf.WriteString(strconv.Itoa(s.Size))
+	}
+	if !w.canonical {
+		if s.LogicalType != "" {
+			attr("logicalType")
+			w.value(s.LogicalType)
+		}
+		if s.Precision > 0 {
+			attr("precision")
+			w.buf.WriteString(strconv.Itoa(s.Precision))
+		}
+		if s.Scale > 0 {
+			attr("scale")
+			w.buf.WriteString(strconv.Itoa(s.Scale))
+		}
+		if len(s.Aliases) > 0 {
+			attr("aliases")
+			w.value(s.Aliases)
+		}
+		for _, k := range sortedKeys(s.Props) {
+			attr(k)
+			w.value(s.Props[k])
+		}
+	}
+	w.buf.WriteByte('}')
+}
+
+func (w *schemaWriter) field(f *Field) {
+	w.buf.WriteString(`{"name":`)
+	w.value(f.Name)
+	w.buf.WriteString(`,"type":`)
+	w.write(f.Type)
+	if !w.canonical {
+		if f.Doc != "" {
+			w.buf.WriteString(`,"doc":`)
+			w.value(f.Doc)
+		}
+		if f.HasDefault {
+			w.buf.WriteString(`,"default":`)
+			w.value(f.Default)
+		}
+		if f.Order != "" {
+			w.buf.WriteString(`,"order":`)
+			w.value(f.Order)
+		}
+		if len(f.Aliases) > 0 {
+			w.buf.WriteString(`,"aliases":`)
+			w.value(f.Aliases)
+		}
+		for _, k := range sortedKeys(f.Props) {
+			w.buf.WriteByte(',')
+			w.value(k)
+			w.buf.WriteByte(':')
+			w.value(f.Props[k])
+		}
+	}
+	w.buf.WriteByte('}')
+}
+
+// value writes a JSON value without escaping HTML characters, as required by the canonical form.
+func (w *schemaWriter) value(v interface{}) {
+	encoder := json.NewEncoder(&w.buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(v)
+	// remove the trailing newline added by the encoder.
+	w.buf.Truncate(w.buf.Len() - 1)
+}
@@ -0,0 +1,60 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package avro
+
+import "testing"
+
+func TestCanonicalForm(t *testing.T) {
+	tests := []struct {
+		name      string
+		schema    string
+		canonical string
+	}{
+		{"primitive", `{"type": "int"}`, `"int"`},
+		{"logical type", `{"type": "long", "logicalType": "timestamp-millis"}`, `"long"`},
+		{"array", `{"items": "int", "type": "array"}`, `{"type":"array","items":"int"}`},
+		{"map", `{"values": {"type": "string"}, "type": "map"}`, `{"type":"map","values":"string"}`},
+		{"union", `["null", {"type": "string"}]`, `["null","string"]`},
+		{"fixed", `{"size": 4, "type": "fixed", "name": "Hash", "aliases": ["Digest"]}`, `{"name":"Hash","type":"fixed","size":4}`},
+		{"enum", `{"type": "enum", "name": "Kind", "namespace": "com.example", "doc": "d", "symbols": ["A", "B"]}`,
+			`{"name":"com.example.Kind","type":"enum","symbols":["A","B"]}`},
+		{"record", `{"type": "record", "name": "User", "namespace": "com.example", "doc": "A user",
+			"fields": [{"name": "id", "type": "long", "doc": "The ID", "default": 0, "order": "descending"}]}`,
+			`{"name":"com.example.User","type":"record","fields":[{"name":"id","type":"long"}]}`},
+		{"inherited namespace and reused named type", `{"type": "record", "name": "com.example.User", "fields": [
+			{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A"]}},
+			{"name": "previous", "type": "Kind"}]}`,
+			`{"name":"com.example.User","type":"record","fields":[{"name":"kind","type":{"name":"com.example.Kind","type":"enum","symbols":["A"]}},{"name":"previous","type":"com.example.Kind"}]}`},
+		{"recursive record", `{"type": "record", "name": "Node", "fields": [{"name": "next", "type": ["null", "Node"]}]}`,
+			`{"name":"Node","type":"record","fields":[{"name":"next","type":["null","Node"]}]}`},
+	}
+	for _, test := range tests {
+		if canonical := MustParse(test.schema).CanonicalForm(); canonical != test.canonical {
+			t.Errorf("%s: expected %s, got %s", test.name, test.canonical, canonical)
+		}
+	}
+}
+
+func TestNormalizedForm(t *testing.T) {
+	a := MustParse(`{"type": "record", "name": "User", "namespace": "com.example", "doc": "A user",
+		"fields": [{"name": "id", "type": "long", "default": 0}]}`)
+	b := MustParse(`{"doc": "A user", "name": "com.example.User", "type": "record",
+		"fields": [{"default": 0, "type": "long", "name": "id"}]}`)
+	if a.NormalizedForm() != b.NormalizedForm() {
+		t.Errorf("expected same normalized forms, got %s and %s", a.NormalizedForm(), b.NormalizedForm())
+	}
+	if a.NormalizedForm() == a.CanonicalForm() {
+		t.Errorf("expected the normalized form to retain docs and defaults, got %s", a.NormalizedForm())
+	}
+}
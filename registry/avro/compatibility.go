@@ -0,0 +1,301 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package avro
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Compatibility levels, as defined by the schema registry.
+const (
+	NONE                = "NONE"
+	BACKWARD            = "BACKWARD"
+	BACKWARD_TRANSITIVE = "BACKWARD_TRANSITIVE"
+	FORWARD             = "FORWARD"
+	FORWARD_TRANSITIVE  = "FORWARD_TRANSITIVE"
+	FULL                = "FULL"
+	FULL_TRANSITIVE     = "FULL_TRANSITIVE"
+)
+
+// CompatibilityLevels lists all supported compatibility levels.
+var CompatibilityLevels = []string{NONE, BACKWARD, BACKWARD_TRANSITIVE, FORWARD, FORWARD_TRANSITIVE, FULL, FULL_TRANSITIVE}
+
+// Incompatibility types, following the Avro schema resolution rules.
+const (
+	NAME_MISMATCH                      = "NAME_MISMATCH"
+	FIXED_SIZE_MISMATCH                = "FIXED_SIZE_MISMATCH"
+	MISSING_ENUM_SYMBOLS               = "MISSING_ENUM_SYMBOLS"
+	READER_FIELD_MISSING_DEFAULT_VALUE = "READER_FIELD_MISSING_DEFAULT_VALUE"
+	TYPE_MISMATCH                      = "TYPE_MISMATCH"
+	MISSING_UNION_BRANCH               = "MISSING_UNION_BRANCH"
+)
+
+// Incompatibility describes why data written with one schema cannot be read with another one.
+type Incompatibility struct {
+	// Previous is the index of the previous schema the new one has been checked against.
+	Previous int    `json:"previous"`
+	Type     string `json:"type"`
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+// CheckCompatibility checks a new schema against previous versions, ordered from the oldest to the latest,
+// for the given compatibility level. Non-transitive levels only check the latest previous schema.
+// Return all incompatibilities found, or an error if the level is unknown.
+func CheckCompatibility(level string, schema *Schema, previous []*Schema) ([]Incompatibility, error) {
+	var backward, forward, transitive bool
+	switch level {
+	case NONE:
+		return nil, nil
+	case BACKWARD:
+		backward = true
+	case BACKWARD_TRANSITIVE:
+		backward, transitive = true, true
+	case FORWARD:
+		forward = true
+	case FORWARD_TRANSITIVE:
+		forward, transitive = true, true
+	case FULL:
+		backward, forward = true, true
+	case FULL_TRANSITIVE:
+		backward, forward, transitive = true, true, true
+	default:
+		return nil, errors.New("Unknown compatibility level '" + level + "'. Must be one of " + strings.Join(CompatibilityLevels, ","))
+	}
+	first := 0
+	if !transitive && len(previous) > 0 {
+		first = len(previous) - 1
+	}
+	var res []Incompatibility
+	for i := first; i < len(previous); i++ {
+		if backward {
+			res = append(res, CanRead(schema, previous[i], i)...)
+		}
+		if forward {
+			res = append(res, CanBeRead(schema, previous[i], i)...)
+		}
+	}
+	return res, nil
+}
+
+// CanRead checks that data written with the previous schema can be read with the new one (backward compatibility).
+func CanRead(schema *Schema, previous *Schema, index int) []Incompatibility {
+	c := checker{readerIsNew: true, previous: index, visited: make(map[[2]*Schema]bool)}
+	c.check(schema, previous, "")
+	return c.incompatibilities
+}
+
+// CanBeRead checks that data written with the new schema can be read with the previous one (forward compatibility).
+func CanBeRead(schema *Schema, previous *Schema, index int) []Incompatibility {
+	c := checker{readerIsNew: false, previous: index, visited: make(map[[2]*Schema]bool)}
+	c.check(previous, schema, "")
+	return c.incompatibilities
+}
+
+type checker struct {
+	readerIsNew       bool
+	previous          int
+	visited           map[[2]*Schema]bool
+	incompatibilities []Incompatibility
+}
+
+func (c *checker) fail(kind string, location string, format string, args ...interface{}) {
+	if location == "" {
+		location = "/"
+	}
+	c.incompatibilities = append(c.incompatibilities, Incompatibility{
+		Previous: c.previous,
+		Type:     kind,
+		Location: location,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// oldAndNew returns the given reader and writer values ordered as (old, new).
+func (c *checker) oldAndNew(reader interface{}, writer interface{}) (interface{}, interface{}) {
+	if c.readerIsNew {
+		return writer, reader
+	}
+	return reader, writer
+}
+
+func (c *checker) typeMismatch(reader *Schema, writer *Schema, location string) {
+	from, to := c.oldAndNew(reader, writer)
+	c.fail(TYPE_MISMATCH, location, "type changed from '%s' to '%s'", from.(*Schema).TypeName(), to.(*Schema).TypeName())
+}
+
+// check verifies that data written with the writer schema can be read with the reader schema.
+func (c *checker) check(reader *Schema, writer *Schema, location string) {
+	if reader.IsNamed() && writer.IsNamed() {
+		key := [2]*Schema{reader, writer}
+		if c.visited[key] {
+			return
+		}
+		c.visited[key] = true
+	}
+
+	if writer.Type == UNION {
+		for _, branch := range writer.Types {
+			if reader.Type == UNION && findBranch(reader, branch) < 0 {
+				if c.readerIsNew {
+					c.fail(MISSING_UNION_BRANCH, location, "union branch '%s' was removed", branch.TypeName())
+				} else {
+					c.fail(MISSING_UNION_BRANCH, location, "union branch '%s' was added", branch.TypeName())
+				}
+				continue
+			}
+			c.check(reader, branch, location)
+		}
+		return
+	}
+	if reader.Type == UNION {
+		if i := findBranch(reader, writer); i >= 0 {
+			c.check(reader.Types[i], writer, location)
+			return
+		}
+		c.typeMismatch(reader, writer, location)
+		return
+	}
+
+	if !promotable(writer, reader) {
+		c.typeMismatch(reader, writer, location)
+		return
+	}
+
+	switch reader.Type {
+	case RECORD, ENUM, FIXED:
+		if !namesMatch(reader, writer) {
+			from, to := c.oldAndNew(reader.Name, writer.Name)
+			c.fail(NAME_MISMATCH, location, "%s name changed from '%s' to '%s'", reader.Type, from, to)
+			return
+		}
+	}
+
+	switch reader.Type {
+	case FIXED:
+		if reader.Size != writer.Size {
+			from, to := c.oldAndNew(reader.Size, writer.Size)
+			c.fail(FIXED_SIZE_MISMATCH, location, "fixed %s size changed from %d to %d", reader.Name, from, to)
+		}
+	case ENUM:
+		if reader.EnumDefault != "" {
+			return
+		}
+		var missing []string
+		for _, symbol := range writer.Symbols {
+			if !contains(reader.Symbols, symbol) {
+				missing = append(missing, symbol)
+			}
+		}
+		if len(missing) > 0 {
+			if c.readerIsNew {
+				c.fail(MISSING_ENUM_SYMBOLS, location, "enum %s symbols [%s] were removed", reader.Name, strings.Join(missing, ","))
+			} else {
+				c.fail(MISSING_ENUM_SYMBOLS, location, "enum %s symbols [%s] were added", reader.Name, strings.Join(missing, ","))
+			}
+		}
+	case ARRAY:
+		c.check(reader.Items, writer.Items, location+"/items")
+	case MAP:
+		c.check(reader.Values, writer.Values, location+"/values")
+	case RECORD:
+		for _, field := range reader.Fields {
+			writerField := findField(writer, field)
+			if writerField == nil {
+				if !field.HasDefault {
+					if c.readerIsNew {
+						c.fail(READER_FIELD_MISSING_DEFAULT_VALUE, location+"/"+field.Name, "field '%s' was added without a default value", field.Name)
+					} else {
+						c.fail(READER_FIELD_MISSING_DEFAULT_VALUE, location+"/"+field.Name, "field '%s' was removed but has no default value", field.Name)
+					}
+				}
+				continue
+			}
+			c.check(field.Type, writerField.Type, location+"/"+field.Name)
+		}
+	}
+}
+
+// promotable returns true if the writer type can be read as the reader type.
+func promotable(writer *Schema, reader *Schema) bool {
+	if writer.Type == reader.Type {
+		return true
+	}
+	switch writer.Type {
+	case INT:
+		return reader.Type == LONG || reader.Type == FLOAT || reader.Type == DOUBLE
+	case LONG:
+		return reader.Type == FLOAT || reader.Type == DOUBLE
+	case FLOAT:
+		return reader.Type == DOUBLE
+	case STRING:
+		return reader.Type == BYTES
+	case BYTES:
+		return reader.Type == STRING
+	}
+	return false
+}
+
+// findBranch returns the index of the first branch of the reader union able to read the writer schema.
+func findBranch(reader *Schema, writer *Schema) int {
+	// first look for an exact match, then for a promotable type.
+	for i, t := range reader.Types {
+		if t.Type == writer.Type && (!t.IsNamed() || namesMatch(t, writer)) {
+			return i
+		}
+	}
+	for i, t := range reader.Types {
+		if !t.IsNamed() && promotable(writer, t) {
+			return i
+		}
+	}
+	return -1
+}
+
+func namesMatch(reader *Schema, writer *Schema) bool {
+	return reader.ShortName() == writer.ShortName() || contains(reader.Aliases, writer.Name)
+}
+
+func findField(writer *Schema, field *Field) *Field {
+	if f := writer.Field(field.Name); f != nil {
+		return f
+	}
+	for _, alias := range field.Aliases {
+		if f := writer.Field(alias); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,105 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package avro
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	userV1            = `{"type":"record","name":"User","fields":[{"name":"id","type":"long"}]}`
+	userWithEmail     = `{"type":"record","name":"User","fields":[{"name":"id","type":"long"},{"name":"email","type":"string","default":""}]}`
+	userEmailRequired = `{"type":"record","name":"User","fields":[{"name":"id","type":"long"},{"name":"email","type":"string"}]}`
+	userWithoutID     = `{"type":"record","name":"User","fields":[]}`
+	userDoubleID      = `{"type":"record","name":"User","fields":[{"name":"id","type":"double"}]}`
+	userStringID      = `{"type":"record","name":"User","fields":[{"name":"id","type":"string"}]}`
+	customerV1        = `{"type":"record","name":"Customer","fields":[{"name":"id","type":"long"}]}`
+	enumAB            = `{"type":"enum","name":"Kind","symbols":["A","B"]}`
+	enumA             = `{"type":"enum","name":"Kind","symbols":["A"]}`
+	enumADefault      = `{"type":"enum","name":"Kind","symbols":["A","OTHER"],"default":"OTHER"}`
+	fixed4            = `{"type":"fixed","name":"Hash","size":4}`
+	fixed8            = `{"type":"fixed","name":"Hash","size":8}`
+	nullableString    = `["null","string"]`
+	nullableLong      = `["null","long"]`
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    string
+		previous []string
+		schema   string
+		expected []string
+	}{
+		{"none accepts anything", NONE, []string{userV1}, userStringID, nil},
+		{"same schema", FULL_TRANSITIVE, []string{userV1}, userV1, nil},
+		{"field added with default", FULL, []string{userV1}, userWithEmail, nil},
+		{"field added without default", BACKWARD, []string{userV1}, userEmailRequired, []string{READER_FIELD_MISSING_DEFAULT_VALUE}},
+		{"field added without default read by old schema", FORWARD, []string{userV1}, userEmailRequired, nil},
+		{"field removed", BACKWARD, []string{userV1}, userWithoutID, nil},
+		{"field removed read by old schema", FORWARD, []string{userV1}, userWithoutID, []string{READER_FIELD_MISSING_DEFAULT_VALUE}},
+		{"type promoted", BACKWARD, []string{userV1}, userDoubleID, nil},
+		{"type promoted read by old schema", FORWARD, []string{userV1}, userDoubleID, []string{TYPE_MISMATCH}},
+		{"type changed", FULL, []string{userV1}, userStringID, []string{TYPE_MISMATCH, TYPE_MISMATCH}},
+		{"record renamed", BACKWARD, []string{userV1}, customerV1, []string{NAME_MISMATCH}},
+		{"enum symbol removed", BACKWARD, []string{enumAB}, enumA, []string{MISSING_ENUM_SYMBOLS}},
+		{"enum symbol added", BACKWARD, []string{enumA}, enumAB, nil},
+		{"enum symbol added read by old schema", FORWARD, []string{enumA}, enumAB, []string{MISSING_ENUM_SYMBOLS}},
+		{"enum default", FORWARD, []string{enumADefault}, `{"type":"enum","name":"Kind","symbols":["A","B","OTHER"],"default":"OTHER"}`, nil},
+		{"fixed size changed", BACKWARD, []string{fixed4}, fixed8, []string{FIXED_SIZE_MISMATCH}},
+		{"union branch added", BACKWARD, []string{`"string"`}, nullableString, nil},
+		{"union branch removed", BACKWARD, []string{nullableString}, `"string"`, []string{TYPE_MISMATCH}},
+		{"union branch replaced", BACKWARD, []string{nullableString}, nullableLong, []string{MISSING_UNION_BRANCH}},
+		{"non transitive only checks the latest", BACKWARD, []string{userV1, userWithEmail}, userEmailRequired, nil},
+		{"transitive checks all previous", BACKWARD_TRANSITIVE, []string{userV1, userWithEmail}, userEmailRequired, []string{READER_FIELD_MISSING_DEFAULT_VALUE}},
+		{"forward transitive", FORWARD_TRANSITIVE, []string{userWithEmail, userV1}, userWithoutID, []string{READER_FIELD_MISSING_DEFAULT_VALUE, READER_FIELD_MISSING_DEFAULT_VALUE}},
+		{"no previous schema", FULL_TRANSITIVE, nil, userV1, nil},
+	}
+	for _, test := range tests {
+		previous := make([]*Schema, len(test.previous))
+		for i, text := range test.previous {
+			previous[i] = MustParse(text)
+		}
+		res, err := CheckCompatibility(test.level, MustParse(test.schema), previous)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", test.name, err)
+			continue
+		}
+		var types []string
+		for _, incompatibility := range res {
+			types = append(types, incompatibility.Type)
+		}
+		if !reflect.DeepEqual(types, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, res)
+		}
+	}
+}
+
+func TestCheckCompatibilityReportsPreviousAndLocation(t *testing.T) {
+	previous := []*Schema{MustParse(userV1), MustParse(userWithEmail)}
+	res, _ := CheckCompatibility(BACKWARD_TRANSITIVE, MustParse(userEmailRequired), previous)
+	if len(res) != 1 {
+		t.Fatalf("expected 1 incompatibility, got %v", res)
+	}
+	if res[0].Previous != 0 || res[0].Location != "/email" {
+		t.Errorf("expected incompatibility with previous 0 at /email, got %+v", res[0])
+	}
+}
+
+func TestCheckCompatibilityUnknownLevel(t *testing.T) {
+	if _, err := CheckCompatibility("SIDEWAYS", MustParse(userV1), nil); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
@@ -0,0 +1,215 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Change kinds reported by Diff.
+const (
+	FIELD_ADDED          = "FIELD_ADDED"
+	FIELD_REMOVED        = "FIELD_REMOVED"
+	FIELD_RENAMED        = "FIELD_RENAMED"
+	TYPE_CHANGED         = "TYPE_CHANGED"
+	NAME_CHANGED         = "NAME_CHANGED"
+	DOC_CHANGED          = "DOC_CHANGED"
+	DEFAULT_ADDED        = "DEFAULT_ADDED"
+	DEFAULT_REMOVED      = "DEFAULT_REMOVED"
+	DEFAULT_CHANGED      = "DEFAULT_CHANGED"
+	ENUM_SYMBOL_ADDED    = "ENUM_SYMBOL_ADDED"
+	ENUM_SYMBOL_REMOVED  = "ENUM_SYMBOL_REMOVED"
+	ENUM_DEFAULT_CHANGED = "ENUM_DEFAULT_CHANGED"
+	UNION_TYPE_ADDED     = "UNION_TYPE_ADDED"
+	UNION_TYPE_REMOVED   = "UNION_TYPE_REMOVED"
+	FIXED_SIZE_CHANGED   = "FIXED_SIZE_CHANGED"
+)
+
+// Change describes a difference between two versions of a schema.
+type Change struct {
+	Kind    string      `json:"kind"`
+	Path    string      `json:"path"`
+	From    interface{} `json:"from,omitempty"`
+	To      interface{} `json:"to,omitempty"`
+	Message string      `json:"message"`
+}
+
+// Diff compares two versions of a schema.
+// Return the list of changes needed to go from the first schema to the second one.
+func Diff(from *Schema, to *Schema) []Change {
+	d := differ{visited: make(map[[2]*Schema]bool)}
+	d.diff(from, to, "")
+	return d.changes
+}
+
+type differ struct {
+	visited map[[2]*Schema]bool
+	changes []Change
+}
+
+func (d *differ) add(kind string, path string, from interface{}, to interface{}, format string, args ...interface{}) {
+	if path == "" {
+		path = "/"
+	}
+	d.changes = append(d.changes, Change{Kind: kind, Path: path, From: from, To: to, Message: fmt.Sprintf(format, args...)})
+}
+
+func (d *differ) diff(from *Schema, to *Schema, path string) {
+	if from.IsNamed() && to.IsNamed() {
+		key := [2]*Schema{from, to}
+		if d.visited[key] {
+			return
+		}
+		d.visited[key] = true
+	}
+
+	if from.Type == UNION && to.Type == UNION {
+		d.diffUnion(from, to, path)
+		return
+	}
+	if from.Type != to.Type || from.LogicalType != to.LogicalType {
+		d.add(TYPE_CHANGED, path, from.TypeName(), to.TypeName(), "type changed from '%s' to '%s'", from.TypeName(), to.TypeName())
+		return
+	}
+
+	if from.IsNamed() {
+		if from.Name != to.Name {
+			d.add(NAME_CHANGED, path, from.Name, to.Name, "%s renamed from '%s' to '%s'", from.Type, from.Name, to.Name)
+		}
+		if from.Doc != to.Doc {
+			d.add(DOC_CHANGED, path, from.Doc, to.Doc, "documentation of %s '%s' changed", from.Type, to.Name)
+		}
+	}
+
+	switch from.Type {
+	case RECORD:
+		d.diffRecord(from, to, path)
+	case ENUM:
+		for _, symbol := range from.Symbols {
+			if !contains(to.Symbols, symbol) {
+				d.add(ENUM_SYMBOL_REMOVED, path, symbol, nil, "symbol '%s' removed from enum '%s'", symbol, to.Name)
+			}
+		}
+		for _, symbol := range to.Symbols {
+			if !contains(from.Symbols, symbol) {
+				d.add(ENUM_SYMBOL_ADDED, path, nil, symbol, "symbol '%s' added to enum '%s'", symbol, to.Name)
+			}
+		}
+		if from.EnumDefault != to.EnumDefault {
+			d.add(ENUM_DEFAULT_CHANGED, path, from.EnumDefault, to.EnumDefault, "default symbol of enum '%s' changed from '%s' to '%s'", to.Name, from.EnumDefault, to.EnumDefault)
+		}
+	case FIXED:
+		if from.Size != to.Size {
+			d.add(FIXED_SIZE_CHANGED, path, from.Size, to.Size, "size of fixed '%s' changed from %d to %d", to.Name, from.Size, to.Size)
+		}
+	case ARRAY:
+		d.diff(from.Items, to.Items, path+"/items")
+	case MAP:
+		d.diff(from.Values, to.Values, path+"/values")
+	}
+}
+
+func (d *differ) diffUnion(from *Schema, to *Schema, path string) {
+	matched := make(map[*Schema]bool)
+	for _, branch := range from.Types {
+		other := findUnionType(to, branch)
+		if other == nil {
+			d.add(UNION_TYPE_REMOVED, path, branch.TypeName(), nil, "type '%s' removed from union", branch.TypeName())
+			continue
+		}
+		matched[other] = true
+		d.diff(branch, other, path)
+	}
+	for _, branch := range to.Types {
+		if !matched[branch] {
+			d.add(UNION_TYPE_ADDED, path, nil, branch.TypeName(), "type '%s' added to union", branch.TypeName())
+		}
+	}
+}
+
+func (d *differ) diffRecord(from *Schema, to *Schema, path string) {
+	matched := make(map[*Field]bool)
+	for _, field := range from.Fields {
+		fieldPath := path + "/" + field.Name
+		other := to.Field(field.Name)
+		if other == nil {
+			other = findRenamedField(to, field.Name)
+			if other != nil {
+				d.add(FIELD_RENAMED, fieldPath, field.Name, other.Name, "field '%s' renamed to '%s'", field.Name, other.Name)
+			}
+		}
+		if other == nil {
+			d.add(FIELD_REMOVED, fieldPath, field.Type.TypeName(), nil, "field '%s' of type '%s' removed", field.Name, field.Type.TypeName())
+			continue
+		}
+		matched[other] = true
+		d.diffField(field, other, fieldPath)
+	}
+	for _, field := range to.Fields {
+		if matched[field] {
+			continue
+		}
+		if field.HasDefault {
+			d.add(FIELD_ADDED, path+"/"+field.Name, nil, field.Type.TypeName(), "field '%s' of type '%s' added with default value %s", field.Name, field.Type.TypeName(), jsonString(field.Default))
+		} else {
+			d.add(FIELD_ADDED, path+"/"+field.Name, nil, field.Type.TypeName(), "field '%s' of type '%s' added without default value", field.Name, field.Type.TypeName())
+		}
+	}
+}
+
+func (d *differ) diffField(from *Field, to *Field, path string) {
+	if from.Doc != to.Doc {
+		d.add(DOC_CHANGED, path, from.Doc, to.Doc, "documentation of field '%s' changed", to.Name)
+	}
+	switch {
+	case from.HasDefault && !to.HasDefault:
+		d.add(DEFAULT_REMOVED, path, from.Default, nil, "default value %s of field '%s' removed", jsonString(from.Default), to.Name)
+	case !from.HasDefault && to.HasDefault:
+		d.add(DEFAULT_ADDED, path, nil, to.Default, "default value %s added to field '%s'", jsonString(to.Default), to.Name)
+	case from.HasDefault && to.HasDefault && jsonString(from.Default) != jsonString(to.Default):
+		d.add(DEFAULT_CHANGED, path, from.Default, to.Default, "default value of field '%s' changed from %s to %s", to.Name, jsonString(from.Default), jsonString(to.Default))
+	}
+	d.diff(from.Type, to.Type, path)
+}
+
+// findUnionType returns the branch of the union having the same type (or name for named types) as the given schema.
+func findUnionType(union *Schema, t *Schema) *Schema {
+	for _, branch := range union.Types {
+		if branch.Type == t.Type && (!t.IsNamed() || branch.Name == t.Name) {
+			return branch
+		}
+	}
+	for _, branch := range union.Types {
+		if branch.Type == t.Type && t.IsNamed() && (branch.ShortName() == t.ShortName() || contains(branch.Aliases, t.Name)) {
+			return branch
+		}
+	}
+	return nil
+}
+
+// findRenamedField returns the field of the record declaring the given name as alias.
+func findRenamedField(record *Schema, name string) *Field {
+	for _, f := range record.Fields {
+		if contains(f.Aliases, name) {
+			return f
+		}
+	}
+	return nil
+}
+
+func jsonString(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
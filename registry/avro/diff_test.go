@@ -0,0 +1,57 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package avro
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	from := MustParse(`{"type": "record", "name": "User", "doc": "v1", "fields": [
+		{"name": "id", "type": "int"},
+		{"name": "name", "type": "string"},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"]}},
+		{"name": "tag", "type": ["null", "string"], "default": null}]}`)
+	to := MustParse(`{"type": "record", "name": "User", "doc": "v2", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "email", "type": "string", "default": ""},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "C"]}},
+		{"name": "tag", "type": ["null", "string", "int"]}]}`)
+
+	var changes []string
+	for _, change := range Diff(from, to) {
+		changes = append(changes, change.Kind+" "+change.Path)
+	}
+	expected := []string{
+		DOC_CHANGED + " /",
+		TYPE_CHANGED + " /id",
+		FIELD_REMOVED + " /name",
+		ENUM_SYMBOL_REMOVED + " /kind",
+		ENUM_SYMBOL_ADDED + " /kind",
+		DEFAULT_REMOVED + " /tag",
+		UNION_TYPE_ADDED + " /tag",
+		FIELD_ADDED + " /email",
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected changes %v, got %v", expected, changes)
+	}
+}
+
+func TestDiffIdenticalSchemas(t *testing.T) {
+	schema := MustParse(`{"type": "record", "name": "Node", "fields": [{"name": "next", "type": ["null", "Node"]}]}`)
+	if changes := Diff(schema, schema); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package avro
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// EMPTY is the initial value of the CRC-64-AVRO (Rabin) fingerprint.
+const EMPTY = 0xc15d213aa4d7a795
+
+var crc64Table = makeCRC64Table()
+
+func makeCRC64Table() (table [256]uint64) {
+	for i := range table {
+		fp := uint64(i)
+		for j := 0; j < 8; j++ {
+			fp = (fp >> 1) ^ (EMPTY & -(fp & 1))
+		}
+		table[i] = fp
+	}
+	return
+}
+
+// Fingerprints holds the fingerprints of the Parsing Canonical Form of a schema.
+type Fingerprints struct {
+	CanonicalForm string `json:"canonical"`
+	CRC64         string `json:"crc64-avro"`
+	MD5           string `json:"md5"`
+	SHA256        string `json:"sha256"`
+}
+
+// Fingerprint64 computes the CRC-64-AVRO fingerprint of the given data.
+func Fingerprint64(data []byte) uint64 {
+	fp := uint64(EMPTY)
+	for _, b := range data {
+		fp = (fp >> 8) ^ crc64Table[byte(fp)^b]
+	}
+	return fp
+}
+
+// Fingerprint computes the CRC-64-AVRO, MD5 and SHA-256 fingerprints of the schema Parsing Canonical Form.
+// Fingerprints are hex-encoded, the CRC-64-AVRO being written as a big-endian 64-bit value.
+func Fingerprint(s *Schema) Fingerprints {
+	canonical := s.CanonicalForm()
+	md5sum := md5.Sum([]byte(canonical))
+	sha256sum := sha256.Sum256([]byte(canonical))
+	return Fingerprints{
+		CanonicalForm: canonical,
+		CRC64:         fmt.Sprintf("%016x", Fingerprint64([]byte(canonical))),
+		MD5:           hex.EncodeToString(md5sum[:]),
+		SHA256:        hex.EncodeToString(sha256sum[:]),
+	}
+}
@@ -0,0 +1,59 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package avro
+
+import (
+	"fmt"
+	"testing"
+)
+
+// The CRC-64-AVRO fingerprints of the Avro specification test vectors (share/test/data/schema-tests.txt),
+// written as signed 64-bit values.
+func TestFingerprint64SpecVectors(t *testing.T) {
+	tests := []struct {
+		schema      string
+		fingerprint int64
+	}{
+		{`"null"`, 7195948357588979594},
+		{`{"type":"null"}`, 7195948357588979594},
+		{`"boolean"`, -6970731678124411036},
+		{`"int"`, 8247732601305521295},
+		{`"long"`, -3434872931120570953},
+		{`"float"`, 5583340709985441680},
+		{`"double"`, -8181574048448539266},
+		{`"bytes"`, 5746618253357095269},
+		{`"string"`, -8142146995180207161},
+		{`[]`, -1241056759729112623},
+		{`[ "int"  ]`, -5232228896498058493},
+	}
+	for _, test := range tests {
+		canonical := MustParse(test.schema).CanonicalForm()
+		if fp := int64(Fingerprint64([]byte(canonical))); fp != test.fingerprint {
+			t.Errorf("%s: expected fingerprint %d, got %d", test.schema, test.fingerprint, fp)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	fingerprints := Fingerprint(MustParse(`{"type": "int", "logicalType": "date"}`))
+	if fingerprints.CanonicalForm != `"int"` {
+		t.Errorf("expected canonical form \"int\", got %s", fingerprints.CanonicalForm)
+	}
+	if expected := fmt.Sprintf("%016x", uint64(8247732601305521295)); fingerprints.CRC64 != expected {
+		t.Errorf("expected CRC-64-AVRO %s, got %s", expected, fingerprints.CRC64)
+	}
+	if len(fingerprints.MD5) != 32 || len(fingerprints.SHA256) != 64 {
+		t.Errorf("expected hex-encoded MD5 and SHA-256, got %s and %s", fingerprints.MD5, fingerprints.SHA256)
+	}
+}
@@ -0,0 +1,432 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package avro provides a parser for Apache Avro schemas and offline tools working on them.
+package avro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	NULL    = "null"
+	BOOLEAN = "boolean"
+	INT     = "int"
+	LONG    = "long"
+	FLOAT   = "float"
+	DOUBLE  = "double"
+	BYTES   = "bytes"
+	STRING  = "string"
+	RECORD  = "record"
+	ENUM    = "enum"
+	ARRAY   = "array"
+	MAP     = "map"
+	FIXED   = "fixed"
+	UNION   = "union"
+)
+
+var primitives = map[string]bool{
+	NULL: true, BOOLEAN: true, INT: true, LONG: true, FLOAT: true, DOUBLE: true, BYTES: true, STRING: true,
+}
+
+var nameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reserved attributes which are not kept as custom properties.
+var reserved = map[string]bool{
+	"type": true, "name": true, "namespace": true, "aliases": true, "doc": true, "fields": true,
+	"symbols": true, "default": true, "items": true, "values": true, "size": true, "logicalType": true,
+	"precision": true, "scale": true, "order": true,
+}
+
+// Schema describes a parsed Avro schema.
+// Named types (record, enum, fixed) are shared, so a recursive schema contains cycles.
+type Schema struct {
+	Type        string
+	Name        string // the full name of a record, enum or fixed.
+	Aliases     []string
+	Doc         string
+	Fields      []*Field
+	Symbols     []string
+	EnumDefault string
+	Items       *Schema
+	Values      *Schema
+	Size        int
+	Types       []*Schema
+	LogicalType string
+	Precision   int
+	Scale       int
+	Props       map[string]interface{}
+}
+
+// Field describes a field of a record schema.
+type Field struct {
+	Name       string
+	Aliases    []string
+	Doc        string
+	Type       *Schema
+	Default    interface{}
+	HasDefault bool
+	Order      string
+	Props      map[string]interface{}
+}
+
+// IsNamed returns true for record, enum and fixed schemas.
+func (s *Schema) IsNamed() bool {
+	return s.Type == RECORD || s.Type == ENUM || s.Type == FIXED
+}
+
+// IsPrimitive returns true for null, boolean, int, long, float, double, bytes and string schemas.
+func (s *Schema) IsPrimitive() bool {
+	return primitives[s.Type]
+}
+
+// ShortName returns the name of a named schema without its namespace.
+func (s *Schema) ShortName() string {
+	if i := strings.LastIndex(s.Name, "."); i >= 0 {
+		return s.Name[i+1:]
+	}
+	return s.Name
+}
+
+// Namespace returns the namespace of a named schema.
+func (s *Schema) Namespace() string {
+	if i := strings.LastIndex(s.Name, "."); i >= 0 {
+		return s.Name[:i]
+	}
+	return ""
+}
+
+// TypeName returns a short human-readable description of the schema type.
+func (s *Schema) TypeName() string {
+	switch s.Type {
+	case RECORD, ENUM, FIXED:
+		return s.Type + " " + s.Name
+	case ARRAY:
+		return "array<" + s.Items.TypeName() + ">"
+	case MAP:
+		return "map<" + s.Values.TypeName() + ">"
+	case UNION:
+		names := make([]string, len(s.Types))
+		for i, t := range s.Types {
+			names[i] = t.TypeName()
+		}
+		return "[" + strings.Join(names, ",") + "]"
+	}
+	if s.LogicalType != "" {
+		return s.Type + "(" + s.LogicalType + ")"
+	}
+	return s.Type
+}
+
+// Field returns the field with the given name, or nil if the record has no such field.
+func (s *Schema) Field(name string) *Field {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Parse parses an Avro schema from its JSON representation.
+func Parse(text string) (*Schema, error) {
+	return ParseWithNames(text, nil)
+}
+
+// ParseWithNames parses an Avro schema which may refer to the given named types,
+// e.g. the schemas referenced by a subject. Types defined by the schema are added to names.
+func ParseWithNames(text string, names map[string]*Schema) (*Schema, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, errors.New("invalid schema json: " + err.Error())
+	}
+	if names == nil {
+		names = make(map[string]*Schema)
+	}
+	p := parser{names: names}
+	return p.parse(v, "")
+}
+
+// MustParse is like Parse but panics if the schema cannot be parsed.
+func MustParse(text string) *Schema {
+	s, err := Parse(text)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+type parser struct {
+	names map[string]*Schema
+}
+
+func (p *parser) parse(v interface{}, namespace string) (*Schema, error) {
+	switch t := v.(type) {
+	case string:
+		if primitives[t] {
+			return &Schema{Type: t}, nil
+		}
+		return p.lookup(t, namespace)
+	case []interface{}:
+		return p.parseUnion(t, namespace)
+	case map[string]interface{}:
+		return p.parseObject(t, namespace)
+	}
+	return nil, fmt.Errorf("invalid schema: %v", v)
+}
+
+func (p *parser) lookup(name string, namespace string) (*Schema, error) {
+	if s, ok := p.names[fullName(name, namespace)]; ok {
+		return s, nil
+	}
+	if s, ok := p.names[name]; ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("unknown type '%s'", name)
+}
+
+func (p *parser) parseUnion(types []interface{}, namespace string) (*Schema, error) {
+	union := &Schema{Type: UNION}
+	seen := make(map[string]bool)
+	for _, t := range types {
+		branch, err := p.parse(t, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if branch.Type == UNION {
+			return nil, errors.New("unions may not immediately contain other unions")
+		}
+		key := branch.Type
+		if branch.IsNamed() {
+			key = branch.Name
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate type '%s' in union", key)
+		}
+		seen[key] = true
+		union.Types = append(union.Types, branch)
+	}
+	return union, nil
+}
+
+func (p *parser) parseObject(o map[string]interface{}, namespace string) (*Schema, error) {
+	t, ok := o["type"]
+	if !ok {
+		return nil, errors.New("missing 'type' attribute")
+	}
+	typeName, ok := t.(string)
+	if !ok {
+		return p.parse(t, namespace)
+	}
+	s := &Schema{Type: typeName}
+	if typeName == "error" {
+		s.Type = RECORD
+	}
+	s.Doc, _ = o["doc"].(string)
+	s.LogicalType, _ = o["logicalType"].(string)
+	s.Precision = intAttribute(o, "precision")
+	s.Scale = intAttribute(o, "scale")
+	s.Props = props(o)
+
+	switch s.Type {
+	case NULL, BOOLEAN, INT, LONG, FLOAT, DOUBLE, BYTES, STRING:
+		return s, nil
+	case RECORD, ENUM, FIXED:
+		if err := p.define(s, o, namespace); err != nil {
+			return nil, err
+		}
+	case ARRAY:
+		items, ok := o["items"]
+		if !ok {
+			return nil, errors.New("array schema has no 'items'")
+		}
+		var err error
+		if s.Items, err = p.parse(items, namespace); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case MAP:
+		values, ok := o["values"]
+		if !ok {
+			return nil, errors.New("map schema has no 'values'")
+		}
+		var err error
+		if s.Values, err = p.parse(values, namespace); err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return p.lookup(typeName, namespace)
+	}
+
+	ns := s.Namespace()
+	switch s.Type {
+	case RECORD:
+		fields, ok := o["fields"].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("record '%s' has no 'fields'", s.Name)
+		}
+		seen := make(map[string]bool)
+		for _, f := range fields {
+			field, err := p.parseField(f, ns)
+			if err != nil {
+				return nil, fmt.Errorf("record '%s': %s", s.Name, err)
+			}
+			if seen[field.Name] {
+				return nil, fmt.Errorf("record '%s' has duplicate field '%s'", s.Name, field.Name)
+			}
+			seen[field.Name] = true
+			s.Fields = append(s.Fields, field)
+		}
+	case ENUM:
+		symbols, ok := o["symbols"].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("enum '%s' has no 'symbols'", s.Name)
+		}
+		seen := make(map[string]bool)
+		for _, symbol := range symbols {
+			str, ok := symbol.(string)
+			if !ok || !nameRegex.MatchString(str) {
+				return nil, fmt.Errorf("enum '%s' has invalid symbol '%v'", s.Name, symbol)
+			}
+			if seen[str] {
+				return nil, fmt.Errorf("enum '%s' has duplicate symbol '%s'", s.Name, str)
+			}
+			seen[str] = true
+			s.Symbols = append(s.Symbols, str)
+		}
+		if def, ok := o["default"].(string); ok {
+			if !seen[def] {
+				return nil, fmt.Errorf("enum '%s' default '%s' is not a symbol", s.Name, def)
+			}
+			s.EnumDefault = def
+		}
+	case FIXED:
+		size, ok := o["size"].(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("fixed '%s' has no 'size'", s.Name)
+		}
+		n, err := size.Int64()
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("fixed '%s' has invalid size '%s'", s.Name, size)
+		}
+		s.Size = int(n)
+	}
+	return s, nil
+}
+
+// define resolves the full name of a named schema and registers it before parsing its content,
+// so that the schema may refer to itself.
+func (p *parser) define(s *Schema, o map[string]interface{}, namespace string) error {
+	name, ok := o["name"].(string)
+	if !ok || name == "" {
+		return fmt.Errorf("%s schema has no 'name'", s.Type)
+	}
+	if ns, ok := o["namespace"].(string); ok && !strings.Contains(name, ".") {
+		namespace = ns
+	}
+	s.Name = fullName(name, namespace)
+	for _, part := range strings.Split(s.Name, ".") {
+		if !nameRegex.MatchString(part) {
+			return fmt.Errorf("invalid name '%s'", s.Name)
+		}
+	}
+	if primitives[s.Name] {
+		return fmt.Errorf("'%s' cannot be redefined", s.Name)
+	}
+	if _, exists := p.names[s.Name]; exists {
+		return fmt.Errorf("type '%s' is already defined", s.Name)
+	}
+	for _, alias := range stringsAttribute(o, "aliases") {
+		s.Aliases = append(s.Aliases, fullName(alias, s.Namespace()))
+	}
+	p.names[s.Name] = s
+	return nil
+}
+
+func (p *parser) parseField(v interface{}, namespace string) (*Field, error) {
+	o, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid field: %v", v)
+	}
+	name, ok := o["name"].(string)
+	if !ok || !nameRegex.MatchString(name) {
+		return nil, fmt.Errorf("invalid field name '%v'", o["name"])
+	}
+	t, ok := o["type"]
+	if !ok {
+		return nil, fmt.Errorf("field '%s' has no 'type'", name)
+	}
+	fieldType, err := p.parse(t, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("field '%s': %s", name, err)
+	}
+	field := &Field{Name: name, Type: fieldType, Aliases: stringsAttribute(o, "aliases"), Props: props(o)}
+	field.Doc, _ = o["doc"].(string)
+	field.Order, _ = o["order"].(string)
+	if def, ok := o["default"]; ok {
+		field.Default = def
+		field.HasDefault = true
+		if errs := validateDefault(fieldType, def); len(errs) > 0 {
+			return nil, fmt.Errorf("field '%s' has an invalid default value: %s", name, errs[0].Message)
+		}
+	}
+	return field, nil
+}
+
+func fullName(name string, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+func intAttribute(o map[string]interface{}, key string) int {
+	if n, ok := o[key].(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return int(i)
+		}
+	}
+	return 0
+}
+
+func stringsAttribute(o map[string]interface{}, key string) (r []string) {
+	values, _ := o[key].([]interface{})
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			r = append(r, s)
+		}
+	}
+	return
+}
+
+func props(o map[string]interface{}) map[string]interface{} {
+	var r map[string]interface{}
+	for k, v := range o {
+		if reserved[k] {
+			continue
+		}
+		if r == nil {
+			r = make(map[string]interface{})
+		}
+		r[k] = v
+	}
+	return r
+}
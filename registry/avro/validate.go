@@ -0,0 +1,231 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ValidationError describes a value which does not conform to a schema.
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Validate checks that a JSON decoded value conforms to the schema.
+// Union values can be given either as plain JSON values or using the Avro JSON encoding, e.g {"string": "value"}.
+// Return all errors found, or nil if the value is valid.
+func Validate(s *Schema, value interface{}) []ValidationError {
+	v := validator{}
+	v.validate(s, value, "$")
+	return v.errors
+}
+
+// validateDefault checks a field default value, for which unions must match their first type.
+func validateDefault(s *Schema, value interface{}) []ValidationError {
+	v := validator{defaults: true}
+	v.validate(s, value, "$")
+	return v.errors
+}
+
+type validator struct {
+	defaults bool
+	errors   []ValidationError
+}
+
+func (v *validator) fail(path string, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(s *Schema, value interface{}, path string) {
+	switch s.Type {
+	case NULL:
+		if value != nil {
+			v.fail(path, "expected null but got %s", jsonType(value))
+		}
+	case BOOLEAN:
+		if _, ok := value.(bool); !ok {
+			v.fail(path, "expected boolean but got %s", jsonType(value))
+		}
+	case INT, LONG:
+		n, ok := toFloat(value)
+		if !ok {
+			v.fail(path, "expected %s but got %s", s.Type, jsonType(value))
+			return
+		}
+		if n != math.Trunc(n) {
+			v.fail(path, "expected %s but got decimal number %v", s.Type, value)
+		} else if s.Type == INT && (n < math.MinInt32 || n > math.MaxInt32) {
+			v.fail(path, "value %v overflows int", value)
+		}
+	case FLOAT, DOUBLE:
+		if _, ok := toFloat(value); !ok {
+			v.fail(path, "expected %s but got %s", s.Type, jsonType(value))
+		}
+	case STRING, BYTES:
+		switch value.(type) {
+		case string, []byte:
+		default:
+			v.fail(path, "expected %s but got %s", s.Type, jsonType(value))
+		}
+	case FIXED:
+		switch b := value.(type) {
+		case string:
+			if len([]rune(b)) != s.Size {
+				v.fail(path, "expected %d bytes for fixed %s but got %d", s.Size, s.Name, len([]rune(b)))
+			}
+		case []byte:
+			if len(b) != s.Size {
+				v.fail(path, "expected %d bytes for fixed %s but got %d", s.Size, s.Name, len(b))
+			}
+		default:
+			v.fail(path, "expected fixed %s but got %s", s.Name, jsonType(value))
+		}
+	case ENUM:
+		symbol, ok := value.(string)
+		if !ok {
+			v.fail(path, "expected enum %s but got %s", s.Name, jsonType(value))
+			return
+		}
+		for _, sym := range s.Symbols {
+			if sym == symbol {
+				return
+			}
+		}
+		v.fail(path, "'%s' is not a symbol of enum %s", symbol, s.Name)
+	case ARRAY:
+		items, ok := value.([]interface{})
+		if !ok {
+			v.fail(path, "expected array but got %s", jsonType(value))
+			return
+		}
+		for i, item := range items {
+			v.validate(s.Items, item, path+"["+strconv.Itoa(i)+"]")
+		}
+	case MAP:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			v.fail(path, "expected map but got %s", jsonType(value))
+			return
+		}
+		for _, k := range sortedKeys(entries) {
+			v.validate(s.Values, entries[k], path+"["+strconv.Quote(k)+"]")
+		}
+	case RECORD:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			v.fail(path, "expected record %s but got %s", s.Name, jsonType(value))
+			return
+		}
+		for _, f := range s.Fields {
+			fieldValue, ok := entries[f.Name]
+			if !ok {
+				if !f.HasDefault {
+					v.fail(path+"."+f.Name, "missing required field '%s'", f.Name)
+				}
+				continue
+			}
+			v.validate(f.Type, fieldValue, path+"."+f.Name)
+		}
+		for _, k := range sortedKeys(entries) {
+			if s.Field(k) == nil {
+				v.fail(path+"."+k, "unknown field '%s' for record %s", k, s.Name)
+			}
+		}
+	case UNION:
+		v.validateUnion(s, value, path)
+	}
+}
+
+func (v *validator) validateUnion(s *Schema, value interface{}, path string) {
+	if v.defaults {
+		if len(s.Types) > 0 {
+			v.validate(s.Types[0], value, path)
+		}
+		return
+	}
+	if branch := ResolveUnion(s, value); branch >= 0 {
+		return
+	}
+	v.fail(path, "value does not match any type of union %s", s.TypeName())
+}
+
+// ResolveUnion returns the index of the first branch of the union matching the value,
+// or -1 if there is none. Values wrapped using the Avro JSON encoding are matched on their type name.
+func ResolveUnion(s *Schema, value interface{}) int {
+	index, _ := resolveUnion(s, value)
+	return index
+}
+
+// resolveUnion returns the index of the union type matching the value,
+// and the value without its Avro JSON encoding wrapper if any.
+func resolveUnion(s *Schema, value interface{}) (int, interface{}) {
+	if wrapped, ok := value.(map[string]interface{}); ok && len(wrapped) == 1 {
+		for name, inner := range wrapped {
+			for i, t := range s.Types {
+				if (t.Name == name || t.ShortName() == name || (!t.IsNamed() && t.Type == name)) && len(Validate(t, inner)) == 0 {
+					return i, inner
+				}
+			}
+		}
+	}
+	for i, t := range s.Types {
+		if len(Validate(t, value)) == 0 {
+			return i, value
+		}
+	}
+	return -1, value
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64, float32, int, int32, int64:
+		return "number"
+	case string:
+		return "string"
+	case []byte:
+		return "bytes"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,74 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	order := `{"type":"record","name":"Order","namespace":"com.example","fields":[
+		{"name":"id","type":"long"},
+		{"name":"quantity","type":"int","default":1},
+		{"name":"status","type":{"type":"enum","name":"Status","symbols":["NEW","PAID"]}},
+		{"name":"hash","type":{"type":"fixed","name":"Hash","size":2}},
+		{"name":"lines","type":{"type":"array","items":"string"}},
+		{"name":"attributes","type":{"type":"map","values":"double"}},
+		{"name":"note","type":["null","string"],"default":null}
+	]}`
+	tests := []struct {
+		name     string
+		schema   string
+		value    string
+		expected []string
+	}{
+		{"valid record", order, `{"id":1,"status":"NEW","hash":"ab","lines":["a"],"attributes":{"a":1.5},"note":"b"}`, []string{}},
+		{"avro json union", order, `{"id":1,"status":"NEW","hash":"ab","lines":[],"attributes":{},"note":{"string":"b"}}`, []string{}},
+		{"invalid fields", order, `{"id":1.5,"quantity":3000000000,"status":"OLD","hash":"abc","lines":[1],"attributes":{"a":"b"},"note":1}`,
+			[]string{"$.id", "$.quantity", "$.status", "$.hash", "$.lines[0]", `$.attributes["a"]`, "$.note"}},
+		{"missing and unknown fields", order, `{"hash":"ab","lines":[],"attributes":{},"other":1}`, []string{"$.id", "$.status", "$.other"}},
+		{"not a record", order, `[]`, []string{"$"}},
+		{"null", `"null"`, `1`, []string{"$"}},
+		{"boolean", `"boolean"`, `true`, []string{}},
+		{"wrapped union", `["null",{"type":"record","name":"A","fields":[]},"long"]`, `{"A":{}}`, []string{}},
+		{"wrapped union mismatch", `["null","long"]`, `{"long":"a"}`, []string{"$"}},
+	}
+	for _, test := range tests {
+		decoder := json.NewDecoder(bytes.NewReader([]byte(test.value)))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			t.Fatal(err)
+		}
+		actual := []string{}
+		for _, err := range Validate(MustParse(test.schema), value) {
+			actual = append(actual, err.Path)
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s: expected errors at %v, got %v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestResolveUnion(t *testing.T) {
+	union := MustParse(`["null","string","long"]`)
+	for value, expected := range map[interface{}]int{nil: 0, "a": 1, json.Number("1"): 2, true: -1} {
+		if actual := ResolveUnion(union, value); actual != expected {
+			t.Errorf("expected branch %d for %v, got %d", expected, value, actual)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package registry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CacheStats holds the statistics of a CachedClient.
+type CacheStats struct {
+	Hits     int `json:"hits"`
+	DiskHits int `json:"disk_hits"`
+	Misses   int `json:"misses"`
+	Expired  int `json:"expired"`
+	Entries  int `json:"entries"`
+}
+
+// CachedClient wraps a Client to cache the responses of the registry.
+// Schemas by ID are immutable and cached forever, optionally on disk. Other entries (subject versions, subjects,
+// compatibility levels and modes) expire after the TTL, and are invalidated by the writes made through the CachedClient.
+// Even numbered subject versions are not immutable: a soft-deleted version is no longer served, and a subject
+// permanently deleted and registered again reuses its version numbers for other schemas. Caching them forever
+// would keep serving deleted or replaced schemas.
+type CachedClient struct {
+	client  Client
+	ttl     time.Duration
+	dir     string
+	lock    sync.Mutex
+	entries map[string]cacheEntry
+	stats   CacheStats
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// Create a new CachedClient struct.
+// If the directory is not empty, schemas by ID are also stored on disk. As schema IDs are only unique
+// within a registry, the directory must not be shared between registries.
+func NewCachedClient(client Client, ttl time.Duration, dir string) *CachedClient {
+	return &CachedClient{
+		client:  client,
+		ttl:     ttl,
+		dir:     dir,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// Stats returns the statistics of the cache.
+func (c *CachedClient) Stats() CacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	return stats
+}
+
+// get returns the value of an entry if it exists and has not expired.
+func (c *CachedClient) get(key string) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry, ok := c.entries[key]
+	if ok && !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		c.stats.Expired++
+		ok = false
+	}
+	if ok {
+		c.stats.Hits++
+	}
+	return entry.value, ok
+}
+
+// put adds an entry, which never expires if immutable.
+func (c *CachedClient) put(key string, value interface{}, immutable bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry := cacheEntry{value: value}
+	if !immutable {
+		entry.expires = time.Now().Add(c.ttl)
+	}
+	c.entries[key] = entry
+}
+
+func (c *CachedClient) invalidate(keys ...string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+}
+
+func (c *CachedClient) miss() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.stats.Misses++
+}
+
+// readDisk reads a schema from the disk cache.
+func (c *CachedClient) readDisk(path string, value interface{}) bool {
+	if c.dir == "" {
+		return false
+	}
+	content, err := ioutil.ReadFile(filepath.Join(c.dir, path))
+	if err != nil || json.Unmarshal(content, value) != nil {
+		return false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.stats.DiskHits++
+	return true
+}
+
+// writeDisk writes a schema to the disk cache. The cache being optional, errors are ignored.
+func (c *CachedClient) writeDisk(path string, value interface{}) {
+	if c.dir == "" {
+		return
+	}
+	content, _ := json.Marshal(value)
+	file := filepath.Join(c.dir, path)
+	if os.MkdirAll(filepath.Dir(file), 0755) == nil {
+		ioutil.WriteFile(file, content, 0644)
+	}
+}
+
+func schemaPath(id int) string {
+	return filepath.Join("schemas", strconv.Itoa(id)+".json")
+}
+
+// Subjects retrieves the list of registered subjects, cached until the TTL expires.
+func (c *CachedClient) Subjects() ([]string, error) {
+	if v, ok := c.get("subjects"); ok {
+		return v.([]string), nil
+	}
+	c.miss()
+	r, e := c.client.Subjects()
+	if e == nil {
+		c.put("subjects", r, false)
+	}
+	return r, e
+}
+
+// Versions retrieves the list of versions registered under the subject, cached until the TTL expires.
+func (c *CachedClient) Versions(subject string) ([]int, error) {
+	key := "versions/" + subject
+	if v, ok := c.get(key); ok {
+		return v.([]int), nil
+	}
+	c.miss()
+	r, e := c.client.Versions(subject)
+	if e == nil {
+		c.put(key, r, false)
+	}
+	return r, e
+}
+
+// GetSubjectVersion retrieves a version of the schema registered under the subject, cached until the TTL expires.
+// The schema is also cached by its ID.
+func (c *CachedClient) GetSubjectVersion(subject string, version string) (SchemaVersion, error) {
+	key := "version/" + subject + "/" + version
+	if v, ok := c.get(key); ok {
+		return v.(SchemaVersion), nil
+	}
+	c.miss()
+	r, e := c.client.GetSubjectVersion(subject, version)
+	if e != nil {
+		return r, e
+	}
+	c.put(key, r, false)
+	c.put("version/"+subject+"/"+strconv.Itoa(r.Version), r, false)
+	schema := Schema{Value: r.Schema, SchemaType: r.SchemaType, References: r.References}
+	c.put("schema/"+strconv.Itoa(r.ID), schema, true)
+	c.writeDisk(schemaPath(r.ID), schema)
+	return r, nil
+}
+
+// GetSchemaByID retrieves the schema identified by the globally unique ID, cached forever.
+func (c *CachedClient) GetSchemaByID(id int) (Schema, error) {
+	key := "schema/" + strconv.Itoa(id)
+	if v, ok := c.get(key); ok {
+		return v.(Schema), nil
+	}
+	var r Schema
+	if c.readDisk(schemaPath(id), &r) {
+		c.put(key, r, true)
+		return r, nil
+	}
+	c.miss()
+	r, e := c.client.GetSchemaByID(id)
+	if e == nil {
+		c.put(key, r, true)
+		c.writeDisk(schemaPath(id), r)
+	}
+	return r, e
+}
+
+// Register registers a new schema under the subject, invalidating the subjects, versions and latest version.
+func (c *CachedClient) Register(subject string, schema Schema) (ID, error) {
+	r, e := c.client.Register(subject, schema)
+	c.invalidate("subjects", "versions/"+subject, "version/"+subject+"/latest")
+	return r, e
+}
+
+// Exists checks if a schema has already been registered under the subject. The response is never cached.
+func (c *CachedClient) Exists(subject string, schema Schema) (NewSchemaVersion, error) {
+	return c.client.Exists(subject, schema)
+}
+
+// GetGlobalCompatibility retrieves the global compatibility level, cached until the TTL expires.
+func (c *CachedClient) GetGlobalCompatibility() (string, error) {
+	if v, ok := c.get("config"); ok {
+		return v.(string), nil
+	}
+	c.miss()
+	r, e := c.client.GetGlobalCompatibility()
+	if e == nil {
+		c.put("config", r, false)
+	}
+	return r, e
+}
+
+// UpdateGlobalCompatibility updates the global compatibility level, invalidating the cached one.
+func (c *CachedClient) UpdateGlobalCompatibility(compatibility Compatibility) (Compatibility, error) {
+	r, e := c.client.UpdateGlobalCompatibility(compatibility)
+	c.invalidate("config")
+	return r, e
+}
+
+// GetSubjectCompatibility retrieves the compatibility level of the subject, cached until the TTL expires.
+func (c *CachedClient) GetSubjectCompatibility(subject string) (CompatibilityLevel, error) {
+	key := "config/" + subject
+	if v, ok := c.get(key); ok {
+		return v.(CompatibilityLevel), nil
+	}
+	c.miss()
+	r, e := c.client.GetSubjectCompatibility(subject)
+	if e == nil {
+		c.put(key, r, false)
+	}
+	return r, e
+}
+
+// UpdateSubjectCompatibility updates the compatibility level of the subject, invalidating the cached one.
+func (c *CachedClient) UpdateSubjectCompatibility(subject string, compatibility Compatibility) (Compatibility, error) {
+	r, e := c.client.UpdateSubjectCompatibility(subject, compatibility)
+	c.invalidate("config/" + subject)
+	return r, e
+}
+
+// CheckSubjectCompatibility tests the schema against a version of the subject. The response is never cached.
+func (c *CachedClient) CheckSubjectCompatibility(subject string, versionId string, schema Schema) (IsCompatible, error) {
+	return c.client.CheckSubjectCompatibility(subject, versionId, schema)
+}
+
+// GetMode retrieves the mode of the registry, cached until the TTL expires.
+func (c *CachedClient) GetMode() (Mode, error) {
+	if v, ok := c.get("mode"); ok {
+		return v.(Mode), nil
+	}
+	c.miss()
+	r, e := c.client.GetMode()
+	if e == nil {
+		c.put("mode", r, false)
+	}
+	return r, e
+}
+
+// GetSubjectMode retrieves the mode of the subject, cached until the TTL expires.
+func (c *CachedClient) GetSubjectMode(subject string) (Mode, error) {
+	key := "mode/" + subject
+	if v, ok := c.get(key); ok {
+		return v.(Mode), nil
+	}
+	c.miss()
+	r, e := c.client.GetSubjectMode(subject)
+	if e == nil {
+		c.put(key, r, false)
+	}
+	return r, e
+}
+
+// SetMode updates the mode of the registry, invalidating the cached one.
+func (c *CachedClient) SetMode(mode Mode, force bool) (Mode, error) {
+	r, e := c.client.SetMode(mode, force)
+	c.invalidate("mode")
+	return r, e
+}
+
+// SetSubjectMode updates the mode of the subject, invalidating the cached one.
+func (c *CachedClient) SetSubjectMode(subject string, mode Mode, force bool) (Mode, error) {
+	r, e := c.client.SetSubjectMode(subject, mode, force)
+	c.invalidate("mode/" + subject)
+	return r, e
+}
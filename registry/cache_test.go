@@ -0,0 +1,138 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package registry_test
+
+import (
+	"github.com/fhussonnois/kafkacli/registry"
+	"github.com/fhussonnois/kafkacli/registry/registrytest"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+const (
+	userV1 = `{"type":"record","name":"User","fields":[{"name":"id","type":"long"}]}`
+	userV2 = `{"type":"record","name":"User","fields":[{"name":"id","type":"long"},{"name":"email","type":"string","default":""}]}`
+)
+
+func TestCachedClientCachesSchemasByID(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	server.Client().Register("users-value", registry.Schema{Value: userV1})
+	dir, _ := ioutil.TempDir("", "cache")
+	defer os.RemoveAll(dir)
+
+	client := registry.NewCachedClient(server.Client(), time.Minute, dir)
+	for i := 0; i < 2; i++ {
+		if schema, err := client.GetSchemaByID(1); err != nil || schema.Value != userV1 {
+			t.Fatalf("expected schema %s, got %+v (%v)", userV1, schema, err)
+		}
+	}
+	if stats := client.Stats(); stats.Misses != 1 || stats.Hits != 1 {
+		t.Errorf("expected 1 miss and 1 hit, got %+v", stats)
+	}
+
+	// Schemas by ID are read from the disk cache by a new client, even if the registry is down.
+	server.Close()
+	client = registry.NewCachedClient(server.Client(), time.Minute, dir)
+	if schema, err := client.GetSchemaByID(1); err != nil || schema.Value != userV1 {
+		t.Fatalf("expected schema %s from disk, got %+v (%v)", userV1, schema, err)
+	}
+	if stats := client.Stats(); stats.DiskHits != 1 || stats.Misses != 0 {
+		t.Errorf("expected 1 disk hit and no miss, got %+v", stats)
+	}
+}
+
+func TestCachedClientExpiresSubjectVersions(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	server.Client().Register("users-value", registry.Schema{Value: userV1})
+
+	client := registry.NewCachedClient(server.Client(), time.Millisecond, "")
+	client.GetSubjectVersion("users-value", "1")
+	time.Sleep(5 * time.Millisecond)
+	client.GetSubjectVersion("users-value", "1")
+	if stats := client.Stats(); stats.Misses != 2 || stats.Expired != 1 {
+		t.Errorf("expected 2 misses and 1 expired entry, got %+v", stats)
+	}
+	// The schema of a version is also cached by ID.
+	client.GetSchemaByID(1)
+	if stats := client.Stats(); stats.Hits != 1 {
+		t.Errorf("expected schema 1 to be cached, got %+v", stats)
+	}
+}
+
+func TestCachedClientInvalidatesOnRegister(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	client := registry.NewCachedClient(server.Client(), time.Minute, "")
+	client.Register("users-value", registry.Schema{Value: userV1})
+
+	latest, _ := client.GetSubjectVersion("users-value", "latest")
+	client.Register("users-value", registry.Schema{Value: userV2})
+	if latest, _ = client.GetSubjectVersion("users-value", "latest"); latest.Version != 2 {
+		t.Errorf("expected latest version 2 after registering, got %d", latest.Version)
+	}
+	versions, _ := client.Versions("users-value")
+	if len(versions) != 2 {
+		t.Errorf("expected 2 versions after registering, got %v", versions)
+	}
+}
+
+// A subject permanently deleted and registered again reuses its version numbers, which must not be served
+// from the disk cache.
+func TestCachedClientDoesNotStoreVersionsOnDisk(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	dir, _ := ioutil.TempDir("", "cache")
+	defer os.RemoveAll(dir)
+
+	client := registry.NewCachedClient(server.Client(), time.Minute, dir)
+	client.Register("users-value", registry.Schema{Value: userV1})
+	client.GetSubjectVersion("users-value", "1")
+
+	for _, query := range []string{"", "?permanent=true"} {
+		req, _ := http.NewRequest("DELETE", server.URL+"/subjects/users-value"+query, nil)
+		if res, err := http.DefaultClient.Do(req); err != nil || res.StatusCode != 200 {
+			t.Fatalf("failed to delete subject: %v %v", res, err)
+		}
+	}
+	server.Client().Register("users-value", registry.Schema{Value: userV2})
+
+	client = registry.NewCachedClient(server.Client(), time.Minute, dir)
+	if version, _ := client.GetSubjectVersion("users-value", "1"); version.Schema != userV2 {
+		t.Errorf("expected version 1 to be the new schema, got %s", version.Schema)
+	}
+}
+
+func TestCachedClientExpiresSoftDeletedVersions(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	server.Client().Register("users-value", registry.Schema{Value: userV1})
+
+	client := registry.NewCachedClient(server.Client(), time.Millisecond, "")
+	if _, err := client.GetSubjectVersion("users-value", "1"); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	req, _ := http.NewRequest("DELETE", server.URL+"/subjects/users-value/versions/1", nil)
+	if res, err := http.DefaultClient.Do(req); err != nil || res.StatusCode != 200 {
+		t.Fatalf("failed to delete version: %v %v", res, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := client.GetSubjectVersion("users-value", "1"); err == nil {
+		t.Error("expected the soft-deleted version not to be served once expired")
+	}
+}
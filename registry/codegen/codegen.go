@@ -0,0 +1,49 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package codegen generates source code from Avro schemas.
+package codegen
+
+import (
+	"errors"
+	"github.com/fhussonnois/kafkacli/registry/avro"
+	"strings"
+)
+
+const (
+	LANG_GO = "go"
+)
+
+// Languages lists all supported target languages.
+var Languages = []string{LANG_GO}
+
+// Options configures the code generation.
+type Options struct {
+	// Language is the target language, e.g "go".
+	Language string
+	// Package is the name of the generated package.
+	Package string
+	// Source describes where the schema comes from and is written in the generated file header.
+	Source string
+}
+
+// Generate generates the source code of the types defined by the schema.
+// The output only depends on the schema and options, so that it can be committed.
+func Generate(schema *avro.Schema, options Options) ([]byte, error) {
+	switch options.Language {
+	case LANG_GO:
+		return GenerateGo(schema, options)
+	}
+	return nil, errors.New("Unsupported language '" + options.Language + "'. Must be one of " + strings.Join(Languages, ","))
+}
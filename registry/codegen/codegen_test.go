@@ -0,0 +1,110 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package codegen
+
+import (
+	"bytes"
+	"flag"
+	"github.com/fhussonnois/kafkacli/registry/avro"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "Update the golden files of testdata.")
+
+// TestGenerateGoGolden compares the code generated from each testdata/<name>.avsc schema with testdata/<name>.go.golden.
+// Run with -update to regenerate the golden files.
+func TestGenerateGoGolden(t *testing.T) {
+	schemas, _ := filepath.Glob(filepath.Join("testdata", "*.avsc"))
+	if len(schemas) == 0 {
+		t.Fatal("no schema found in testdata")
+	}
+	for _, file := range schemas {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		schema, err := avro.Parse(string(content))
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", file, err)
+		}
+		options := Options{Language: LANG_GO, Package: "events", Source: filepath.Base(file)}
+		code, err := Generate(schema, options)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", file, err)
+		}
+		// The output only depends on the schema, so that it can be committed.
+		if again, _ := Generate(avro.MustParse(string(content)), options); !bytes.Equal(code, again) {
+			t.Errorf("%s: expected the same code to be generated twice", file)
+		}
+
+		golden := strings.TrimSuffix(file, ".avsc") + ".go.golden"
+		if *update {
+			if err = ioutil.WriteFile(golden, code, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		expected, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(code, expected) {
+			t.Errorf("%s: generated code differs from %s:\n%s", file, golden, code)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	record := avro.MustParse(`{"type":"record","name":"User","fields":[{"name":"id","type":"long"}]}`)
+	if _, err := Generate(record, Options{Language: LANG_GO}); err == nil {
+		t.Error("expected an error for a missing package")
+	}
+	if _, err := Generate(record, Options{Language: "java", Package: "users"}); err == nil {
+		t.Error("expected an error for an unsupported language")
+	}
+	if _, err := Generate(avro.MustParse(`"string"`), Options{Language: LANG_GO, Package: "users"}); err == nil {
+		t.Error("expected an error for a schema without named types")
+	}
+	if _, err := Generate(record, Options{Language: LANG_GO, Package: "func"}); err == nil {
+		t.Error("expected an error for code which does not compile")
+	}
+}
+
+func TestTypeCheck(t *testing.T) {
+	if err := typeCheck([]byte("package events\n\ntype E string\n\nconst (\n\tEA E = \"a\"\n\tEA E = \"A\"\n)\n")); err == nil {
+		t.Error("expected an error for duplicate declarations")
+	}
+	if err := typeCheck([]byte("package events\n\ntype User struct {\n\tAddress Address\n}\n")); err == nil {
+		t.Error("expected an error for an undefined type")
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"favorite_color": "FavoriteColor",
+		"userId":         "UserId",
+		"URL":            "Url",
+		"com.example":    "ComExample",
+		"_1":             "X1",
+		"kebab-case":     "KebabCase",
+	}
+	for name, expected := range tests {
+		if actual := goName(name); actual != expected {
+			t.Errorf("expected %s for %s, got %s", expected, name, actual)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/fhussonnois/kafkacli/registry/avro"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"unicode"
+)
+
+// GenerateGo generates Go types for all records, enums and fixed defined by the schema.
+// Records are generated as structs with avro and json tags, enums as string types with one constant per symbol
+// and fixed as byte arrays. Optional types (union with null) are generated as pointers, and other unions
+// as structs holding one pointer per type. Colliding identifiers are suffixed with a number.
+// Return an error if the generated code does not type-check.
+func GenerateGo(schema *avro.Schema, options Options) ([]byte, error) {
+	if options.Package == "" {
+		return nil, errors.New("Missing package name")
+	}
+	g := goGenerator{names: make(map[*avro.Schema]string), used: make(map[string]bool)}
+	g.collect(schema, make(map[*avro.Schema]bool))
+	if len(g.named) == 0 {
+		return nil, errors.New("The schema does not define any record, enum or fixed")
+	}
+	g.assignNames()
+
+	g.printf("// Code generated by schema-registry-cli codegen. DO NOT EDIT.\n")
+	if options.Source != "" {
+		g.printf("// Source: %s\n", options.Source)
+	}
+	g.printf("\npackage %s\n", options.Package)
+	for _, s := range g.named {
+		switch s.Type {
+		case avro.RECORD:
+			g.record(s)
+		case avro.ENUM:
+			g.enum(s)
+		case avro.FIXED:
+			g.printf("\n")
+			g.doc(g.names[s], "is the Avro fixed "+s.Name+".", s.Doc)
+			g.printf("type %s [%d]byte\n", g.names[s], s.Size)
+		}
+	}
+	code, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, errors.New("Invalid generated code: " + err.Error())
+	}
+	if err = typeCheck(code); err != nil {
+		return nil, errors.New("Invalid generated code: " + err.Error())
+	}
+	return code, nil
+}
+
+// typeCheck parses and type-checks the generated code, which does not import any package.
+func typeCheck(code []byte) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "generated.go", code, 0)
+	if err != nil {
+		return err
+	}
+	_, err = (&types.Config{}).Check(file.Name.Name, fset, []*ast.File{file}, nil)
+	return err
+}
+
+type goGenerator struct {
+	buf   bytes.Buffer
+	named []*avro.Schema
+	names map[*avro.Schema]string
+	used  map[string]bool
+}
+
+func (g *goGenerator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// collect lists the named types of the schema in depth-first order.
+func (g *goGenerator) collect(s *avro.Schema, seen map[*avro.Schema]bool) {
+	if seen[s] {
+		return
+	}
+	seen[s] = true
+	if s.IsNamed() {
+		g.named = append(g.named, s)
+	}
+	switch s.Type {
+	case avro.RECORD:
+		for _, f := range s.Fields {
+			g.collect(f.Type, seen)
+		}
+	case avro.ARRAY:
+		g.collect(s.Items, seen)
+	case avro.MAP:
+		g.collect(s.Values, seen)
+	case avro.UNION:
+		for _, t := range s.Types {
+			g.collect(t, seen)
+		}
+	}
+}
+
+// assignNames names Go types after the short names of named types,
+// falling back to full names when short names collide.
+func (g *goGenerator) assignNames() {
+	count := make(map[string]int)
+	for _, s := range g.named {
+		count[goName(s.ShortName())]++
+	}
+	for _, s := range g.named {
+		name := goName(s.ShortName())
+		if count[name] > 1 {
+			name = goName(strings.Replace(s.Name, ".", "_", -1))
+		}
+		g.names[s] = g.unique(name)
+	}
+}
+
+func (g *goGenerator) unique(name string) string {
+	res := name
+	for i := 2; g.used[res]; i++ {
+		res = fmt.Sprintf("%s%d", name, i)
+	}
+	g.used[res] = true
+	return res
+}
+
+func (g *goGenerator) doc(name string, summary string, doc string) {
+	g.printf("// %s %s\n", name, summary)
+	if doc != "" {
+		for _, line := range strings.Split(strings.TrimSpace(doc), "\n") {
+			g.printf("// %s\n", strings.TrimSpace(line))
+		}
+	}
+}
+
+func (g *goGenerator) record(s *avro.Schema) {
+	name := g.names[s]
+	var unions []func()
+	fieldNames := make(map[string]bool)
+
+	g.printf("\n")
+	g.doc(name, "is the Avro record "+s.Name+".", s.Doc)
+	g.printf("type %s struct {\n", name)
+	for _, f := range s.Fields {
+		fieldName := goName(f.Name)
+		for i := 2; fieldNames[fieldName]; i++ {
+			fieldName = fmt.Sprintf("%s%d", goName(f.Name), i)
+		}
+		fieldNames[fieldName] = true
+		if f.Doc != "" {
+			for _, line := range strings.Split(strings.TrimSpace(f.Doc), "\n") {
+				g.printf("\t// %s\n", strings.TrimSpace(line))
+			}
+		}
+		fieldType := g.goType(f.Type, s, name+fieldName, &unions)
+		g.printf("\t%s %s `avro:\"%s\" json:\"%s\"`\n", fieldName, fieldType, f.Name, f.Name)
+	}
+	g.printf("}\n")
+	for _, union := range unions {
+		union()
+	}
+}
+
+func (g *goGenerator) enum(s *avro.Schema) {
+	name := g.names[s]
+	g.printf("\n")
+	g.doc(name, "is the Avro enum "+s.Name+".", s.Doc)
+	g.printf("type %s string\n\nconst (\n", name)
+	for _, symbol := range s.Symbols {
+		g.printf("\t%s %s = %q\n", g.unique(name+goName(symbol)), name, symbol)
+	}
+	g.printf(")\n")
+}
+
+// goType returns the Go type of a schema. The record is the one declaring the field, and the name
+// is used to name the struct generated for unions, which are declared after the record.
+func (g *goGenerator) goType(s *avro.Schema, record *avro.Schema, name string, unions *[]func()) string {
+	switch s.Type {
+	case avro.NULL:
+		return "*struct{}"
+	case avro.BOOLEAN:
+		return "bool"
+	case avro.INT:
+		return "int32"
+	case avro.LONG:
+		return "int64"
+	case avro.FLOAT:
+		return "float32"
+	case avro.DOUBLE:
+		return "float64"
+	case avro.BYTES:
+		return "[]byte"
+	case avro.STRING:
+		return "string"
+	case avro.RECORD:
+		if s == record {
+			return "*" + g.names[s]
+		}
+		return g.names[s]
+	case avro.ENUM, avro.FIXED:
+		return g.names[s]
+	case avro.ARRAY:
+		return "[]" + g.goType(s.Items, record, name+"Item", unions)
+	case avro.MAP:
+		return "map[string]" + g.goType(s.Values, record, name+"Value", unions)
+	}
+
+	var types []*avro.Schema
+	for _, t := range s.Types {
+		if t.Type != avro.NULL {
+			types = append(types, t)
+		}
+	}
+	switch {
+	case len(types) == 0:
+		return "*struct{}"
+	case len(types) == 1:
+		t := g.goType(types[0], record, name, unions)
+		if len(types) == len(s.Types) || nullable(types[0]) {
+			return t
+		}
+		return "*" + strings.TrimPrefix(t, "*")
+	}
+
+	unionName := g.unique(name + "Union")
+	var fields []string
+	fieldNames := make(map[string]bool)
+	for _, t := range types {
+		fieldType := g.goType(t, record, name, unions)
+		if !nullable(t) {
+			fieldType = "*" + strings.TrimPrefix(fieldType, "*")
+		}
+		branchName, tag := unionBranchName(g, t)
+		fieldName := branchName
+		for i := 2; fieldNames[fieldName]; i++ {
+			fieldName = fmt.Sprintf("%s%d", branchName, i)
+		}
+		fieldNames[fieldName] = true
+		fields = append(fields, fmt.Sprintf("\t%s %s `avro:\"%s\" json:\"%s,omitempty\"`\n", fieldName, fieldType, tag, tag))
+	}
+	summary := "Only one field is set."
+	if len(types) < len(s.Types) {
+		summary = "Only one field is set, or none for null."
+	}
+	*unions = append(*unions, func() {
+		g.printf("\n// %s holds the value of the union %s. %s\n", unionName, s.TypeName(), summary)
+		g.printf("type %s struct {\n", unionName)
+		for _, f := range fields {
+			g.printf("%s", f)
+		}
+		g.printf("}\n")
+	})
+	return unionName
+}
+
+// unionBranchName returns the Go field name and the tag used for a union branch.
+func unionBranchName(g *goGenerator, t *avro.Schema) (string, string) {
+	if t.IsNamed() {
+		return g.names[t], t.Name
+	}
+	return goName(t.Type), t.Type
+}
+
+// nullable returns true for types which Go zero value can represent null.
+func nullable(t *avro.Schema) bool {
+	return t.Type == avro.ARRAY || t.Type == avro.MAP || t.Type == avro.BYTES
+}
+
+// goName converts an Avro name into an exported Go identifier, e.g "favorite_color" to "FavoriteColor".
+func goName(name string) string {
+	var res bytes.Buffer
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+		runes := []rune(part)
+		if strings.ToUpper(part) == part {
+			runes = []rune(strings.ToLower(part))
+		}
+		runes[0] = unicode.ToUpper(runes[0])
+		res.WriteString(string(runes))
+	}
+	if res.Len() == 0 || unicode.IsDigit([]rune(res.String())[0]) {
+		return "X" + res.String()
+	}
+	return res.String()
+}
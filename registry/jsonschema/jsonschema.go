@@ -0,0 +1,561 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package jsonschema validates JSON values against JSON schemas.
+// It supports the validation keywords of draft-07 except format and remote references.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError describes a value which does not conform to a schema.
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Schema is a parsed JSON schema.
+type Schema struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// Parse parses a JSON schema.
+// Return a new Schema struct.
+func Parse(text string) (*Schema, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(text)))
+	decoder.UseNumber()
+	var root interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return nil, errors.New("invalid JSON: " + err.Error())
+	}
+	switch root.(type) {
+	case bool, map[string]interface{}:
+	default:
+		return nil, errors.New("a schema must be an object or a boolean")
+	}
+	s := &Schema{root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := s.compilePatterns(root); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// compilePatterns compiles all regular expressions of the schema, so that validation never fails on them.
+// The values of keywords mapping names to schemas (e.g properties) are walked as schemas whatever their names,
+// so that a property named "default" is not mistaken for the keyword.
+func (s *Schema) compilePatterns(node interface{}) error {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		var patterns []string
+		if p, ok := n["pattern"].(string); ok {
+			patterns = append(patterns, p)
+		}
+		if props, ok := n["patternProperties"].(map[string]interface{}); ok {
+			for p := range props {
+				patterns = append(patterns, p)
+			}
+		}
+		for _, p := range patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return fmt.Errorf("invalid pattern '%s': %s", p, err)
+			}
+			s.patterns[p] = re
+		}
+		for _, k := range sortedKeys(n) {
+			switch k {
+			case "enum", "const", "default", "examples":
+				continue
+			case "properties", "patternProperties", "definitions", "$defs", "dependencies":
+				if schemas, ok := n[k].(map[string]interface{}); ok {
+					for _, name := range sortedKeys(schemas) {
+						if err := s.compilePatterns(schemas[name]); err != nil {
+							return err
+						}
+					}
+					continue
+				}
+			}
+			if err := s.compilePatterns(n[k]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range n {
+			if err := s.compilePatterns(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// match checks a value against a pattern of the schema. Patterns are compiled by Parse, but a pattern may
+// still be missing if it is only reachable through a reference to an unusual location.
+func (s *Schema) match(pattern string, value string) (bool, error) {
+	re, ok := s.patterns[pattern]
+	if !ok {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return false, fmt.Errorf("invalid pattern '%s': %s", pattern, err)
+		}
+	}
+	return re.MatchString(value), nil
+}
+
+// Validate checks that a JSON decoded value conforms to the schema.
+// Return all errors found, or nil if the value is valid.
+func (s *Schema) Validate(value interface{}) []ValidationError {
+	v := validator{schema: s}
+	v.validate(s.root, value, "$", 0)
+	return v.errors
+}
+
+// maxDepth bounds the resolution of recursive references.
+const maxDepth = 256
+
+type validator struct {
+	schema *Schema
+	errors []ValidationError
+}
+
+func (v *validator) fail(path string, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// valid checks a value against a sub-schema without reporting errors.
+func (v *validator) valid(node interface{}, value interface{}, path string, depth int) bool {
+	sub := validator{schema: v.schema}
+	sub.validate(node, value, path, depth)
+	return len(sub.errors) == 0
+}
+
+func (v *validator) validate(node interface{}, value interface{}, path string, depth int) {
+	if depth > maxDepth {
+		v.fail(path, "too many nested references")
+		return
+	}
+	switch n := node.(type) {
+	case bool:
+		if !n {
+			v.fail(path, "no value is allowed")
+		}
+		return
+	case map[string]interface{}:
+		v.validateObjectSchema(n, value, path, depth)
+	}
+}
+
+func (v *validator) validateObjectSchema(n map[string]interface{}, value interface{}, path string, depth int) {
+	if ref, ok := n["$ref"].(string); ok {
+		target, err := v.schema.resolve(ref)
+		if err != nil {
+			v.fail(path, "%s", err)
+			return
+		}
+		// as in draft-07, other keywords are ignored next to a reference.
+		v.validate(target, value, path, depth+1)
+		return
+	}
+
+	if t, ok := n["type"]; ok && !matchesType(t, value) {
+		v.fail(path, "expected %s but got %s", typeNames(t), jsonType(value))
+		return
+	}
+	if enum, ok := n["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			found = found || equal(e, value)
+		}
+		if !found {
+			v.fail(path, "value %s is not one of %s", jsonString(value), jsonString(enum))
+		}
+	}
+	if c, ok := n["const"]; ok && !equal(c, value) {
+		v.fail(path, "expected %s but got %s", jsonString(c), jsonString(value))
+	}
+
+	switch val := value.(type) {
+	case json.Number, float64:
+		v.validateNumber(n, value, path)
+	case string:
+		v.validateString(n, val, path)
+	case []interface{}:
+		v.validateArray(n, val, path, depth)
+	case map[string]interface{}:
+		v.validateObject(n, val, path, depth)
+	}
+
+	if all, ok := n["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			v.validate(sub, value, path, depth+1)
+		}
+	}
+	if anyOf, ok := n["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if v.valid(sub, value, path, depth+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "value does not match any schema of anyOf")
+		}
+	}
+	if oneOf, ok := n["oneOf"].([]interface{}); ok {
+		count := 0
+		for _, sub := range oneOf {
+			if v.valid(sub, value, path, depth+1) {
+				count++
+			}
+		}
+		if count != 1 {
+			v.fail(path, "value matches %d schemas of oneOf instead of exactly one", count)
+		}
+	}
+	if not, ok := n["not"]; ok && v.valid(not, value, path, depth+1) {
+		v.fail(path, "value must not match the schema of not")
+	}
+	if cond, ok := n["if"]; ok {
+		if v.valid(cond, value, path, depth+1) {
+			if then, ok := n["then"]; ok {
+				v.validate(then, value, path, depth+1)
+			}
+		} else if otherwise, ok := n["else"]; ok {
+			v.validate(otherwise, value, path, depth+1)
+		}
+	}
+}
+
+func (v *validator) validateNumber(n map[string]interface{}, value interface{}, path string) {
+	f, _ := toFloat(value)
+	if min, ok := toFloat(n["minimum"]); ok && f < min {
+		v.fail(path, "value %v is less than minimum %v", f, min)
+	}
+	if max, ok := toFloat(n["maximum"]); ok && f > max {
+		v.fail(path, "value %v is greater than maximum %v", f, max)
+	}
+	if min, ok := toFloat(n["exclusiveMinimum"]); ok && f <= min {
+		v.fail(path, "value %v must be greater than %v", f, min)
+	}
+	if max, ok := toFloat(n["exclusiveMaximum"]); ok && f >= max {
+		v.fail(path, "value %v must be less than %v", f, max)
+	}
+	if m, ok := toFloat(n["multipleOf"]); ok && m > 0 {
+		if q := f / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(path, "value %v is not a multiple of %v", f, m)
+		}
+	}
+}
+
+func (v *validator) validateString(n map[string]interface{}, value string, path string) {
+	length := utf8.RuneCountInString(value)
+	if min, ok := toFloat(n["minLength"]); ok && float64(length) < min {
+		v.fail(path, "length %d is less than minLength %v", length, min)
+	}
+	if max, ok := toFloat(n["maxLength"]); ok && float64(length) > max {
+		v.fail(path, "length %d is greater than maxLength %v", length, max)
+	}
+	if p, ok := n["pattern"].(string); ok {
+		if matched, err := v.schema.match(p, value); err != nil {
+			v.fail(path, "%s", err)
+		} else if !matched {
+			v.fail(path, "value '%s' does not match pattern '%s'", value, p)
+		}
+	}
+}
+
+func (v *validator) validateArray(n map[string]interface{}, items []interface{}, path string, depth int) {
+	if min, ok := toFloat(n["minItems"]); ok && float64(len(items)) < min {
+		v.fail(path, "%d items are less than minItems %v", len(items), min)
+	}
+	if max, ok := toFloat(n["maxItems"]); ok && float64(len(items)) > max {
+		v.fail(path, "%d items are more than maxItems %v", len(items), max)
+	}
+	if unique, ok := n["uniqueItems"].(bool); ok && unique {
+		for i := range items {
+			for j := 0; j < i; j++ {
+				if equal(items[i], items[j]) {
+					v.fail(path+"["+strconv.Itoa(i)+"]", "duplicate of item %d", j)
+					break
+				}
+			}
+		}
+	}
+	switch schemas := n["items"].(type) {
+	case nil:
+	case []interface{}:
+		for i, item := range items {
+			itemPath := path + "[" + strconv.Itoa(i) + "]"
+			if i < len(schemas) {
+				v.validate(schemas[i], item, itemPath, depth+1)
+			} else if additional, ok := n["additionalItems"]; ok {
+				v.validate(additional, item, itemPath, depth+1)
+			}
+		}
+	default:
+		for i, item := range items {
+			v.validate(schemas, item, path+"["+strconv.Itoa(i)+"]", depth+1)
+		}
+	}
+	if contains, ok := n["contains"]; ok {
+		found := false
+		for i, item := range items {
+			found = found || v.valid(contains, item, path+"["+strconv.Itoa(i)+"]", depth+1)
+		}
+		if !found {
+			v.fail(path, "no item matches the schema of contains")
+		}
+	}
+}
+
+func (v *validator) validateObject(n map[string]interface{}, entries map[string]interface{}, path string, depth int) {
+	if min, ok := toFloat(n["minProperties"]); ok && float64(len(entries)) < min {
+		v.fail(path, "%d properties are less than minProperties %v", len(entries), min)
+	}
+	if max, ok := toFloat(n["maxProperties"]); ok && float64(len(entries)) > max {
+		v.fail(path, "%d properties are more than maxProperties %v", len(entries), max)
+	}
+	if required, ok := n["required"].([]interface{}); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, present := entries[name]; !present {
+					v.fail(childPath(path, name), "missing required property '%s'", name)
+				}
+			}
+		}
+	}
+	if deps, ok := n["dependencies"].(map[string]interface{}); ok {
+		for _, k := range sortedKeys(deps) {
+			if _, present := entries[k]; !present {
+				continue
+			}
+			switch dep := deps[k].(type) {
+			case []interface{}:
+				for _, r := range dep {
+					if name, ok := r.(string); ok {
+						if _, present := entries[name]; !present {
+							v.fail(childPath(path, name), "missing property '%s' required by '%s'", name, k)
+						}
+					}
+				}
+			default:
+				v.validate(dep, entries, path, depth+1)
+			}
+		}
+	}
+
+	properties, _ := n["properties"].(map[string]interface{})
+	patternProperties, _ := n["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := n["additionalProperties"]
+	names, hasNames := n["propertyNames"]
+	for _, k := range sortedKeys(entries) {
+		propertyPath := childPath(path, k)
+		if hasNames && !v.valid(names, k, propertyPath, depth+1) {
+			v.fail(propertyPath, "invalid property name '%s'", k)
+		}
+		matched := false
+		if sub, ok := properties[k]; ok {
+			matched = true
+			v.validate(sub, entries[k], propertyPath, depth+1)
+		}
+		for _, p := range sortedKeys(patternProperties) {
+			if ok, err := v.schema.match(p, k); err != nil {
+				v.fail(propertyPath, "%s", err)
+			} else if ok {
+				matched = true
+				v.validate(patternProperties[p], entries[k], propertyPath, depth+1)
+			}
+		}
+		if !matched && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				v.fail(propertyPath, "additional property '%s' is not allowed", k)
+			} else {
+				v.validate(additional, entries[k], propertyPath, depth+1)
+			}
+		}
+	}
+}
+
+// resolve returns the sub-schema designated by a local reference, e.g "#/definitions/address".
+func (s *Schema) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported remote reference '%s'", ref)
+	}
+	node := s.root
+	pointer := strings.TrimPrefix(ref, "#")
+	if pointer == "" {
+		return node, nil
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token, _ = url.PathUnescape(token)
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		switch n := node.(type) {
+		case map[string]interface{}:
+			next, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("cannot resolve reference '%s'", ref)
+			}
+			node = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("cannot resolve reference '%s'", ref)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("cannot resolve reference '%s'", ref)
+		}
+	}
+	return node, nil
+}
+
+// matchesType checks the value against the "type" keyword, either a type name or a list of type names.
+func matchesType(t interface{}, value interface{}) bool {
+	switch types := t.(type) {
+	case string:
+		return matchesTypeName(types, value)
+	case []interface{}:
+		for _, name := range types {
+			if s, ok := name.(string); ok && matchesTypeName(s, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesTypeName(name string, value interface{}) bool {
+	actual := jsonType(value)
+	switch name {
+	case "integer":
+		f, ok := toFloat(value)
+		return ok && f == math.Trunc(f)
+	case "number":
+		return actual == "number"
+	}
+	return actual == name
+}
+
+func typeNames(t interface{}) string {
+	if types, ok := t.([]interface{}); ok {
+		var names []string
+		for _, name := range types {
+			names = append(names, fmt.Sprint(name))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+// equal compares two JSON values, numbers being compared by value.
+func equal(a interface{}, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	switch av := a.(type) {
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, item := range av {
+			other, ok := bv[k]
+			if !ok || !equal(item, other) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// childPath returns the path of an object property, e.g $.name or $["first name"].
+func childPath(path string, name string) string {
+	if identifier.MatchString(name) {
+		return path + "." + name
+	}
+	return path + "[" + strconv.Quote(name) + "]"
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func jsonString(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,140 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, text string) interface{} {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(text)))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		t.Fatalf("invalid JSON %s: %s", text, err)
+	}
+	return value
+}
+
+// paths returns the paths of the errors found by validating the value against the schema.
+func paths(t *testing.T, schema string, value string) []string {
+	s, err := Parse(schema)
+	if err != nil {
+		t.Fatalf("unexpected error %s for schema %s", err, schema)
+	}
+	res := []string{}
+	for _, e := range s.Validate(decode(t, value)) {
+		res = append(res, e.Path)
+	}
+	return res
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		value    string
+		expected []string
+	}{
+		{"true schema", `true`, `{"a":1}`, []string{}},
+		{"false schema", `false`, `1`, []string{"$"}},
+		{"type", `{"type":"string"}`, `1`, []string{"$"}},
+		{"type list", `{"type":["string","null"]}`, `null`, []string{}},
+		{"integer", `{"type":"integer"}`, `1.0`, []string{}},
+		{"not an integer", `{"type":"integer"}`, `1.5`, []string{"$"}},
+		{"enum", `{"enum":["a",1]}`, `1.0`, []string{}},
+		{"not in enum", `{"enum":["a",1]}`, `"b"`, []string{"$"}},
+		{"const", `{"const":{"a":[1]}}`, `{"a":[1]}`, []string{}},
+		{"minimum and maximum", `{"minimum":1,"exclusiveMaximum":3}`, `3`, []string{"$"}},
+		{"multipleOf", `{"multipleOf":0.1}`, `0.3`, []string{}},
+		{"string length", `{"minLength":2,"maxLength":3}`, `"é"`, []string{"$"}},
+		{"pattern", `{"pattern":"^a"}`, `"ba"`, []string{"$"}},
+		{"array items", `{"items":{"type":"integer"},"minItems":1,"uniqueItems":true}`, `[1,"a",1]`, []string{"$[2]", "$[1]"}},
+		{"tuple items", `{"items":[{"type":"string"}],"additionalItems":false}`, `["a",1]`, []string{"$[1]"}},
+		{"contains", `{"contains":{"const":2}}`, `[1,3]`, []string{"$"}},
+		{"required", `{"required":["id","first name"]}`, `{}`, []string{"$.id", `$["first name"]`}},
+		{"properties", `{"properties":{"id":{"type":"integer"}},"additionalProperties":false}`, `{"id":"a","other":1}`, []string{"$.id", "$.other"}},
+		{"additional properties schema", `{"additionalProperties":{"type":"string"}}`, `{"a":"b","c":1}`, []string{"$.c"}},
+		{"pattern properties", `{"patternProperties":{"^x-":{"type":"string"}},"additionalProperties":false}`, `{"x-a":"b","x-c":1,"d":1}`, []string{"$.d", "$[\"x-c\"]"}},
+		{"property names", `{"propertyNames":{"maxLength":2}}`, `{"ab":1,"abc":2}`, []string{"$.abc"}},
+		{"min and max properties", `{"minProperties":2}`, `{"a":1}`, []string{"$"}},
+		{"dependencies", `{"dependencies":{"a":["b"],"c":{"required":["d"]}}}`, `{"a":1,"c":1}`, []string{"$.b", "$.d"}},
+		{"allOf", `{"allOf":[{"type":"number"},{"minimum":2}]}`, `1`, []string{"$"}},
+		{"anyOf", `{"anyOf":[{"type":"string"},{"type":"null"}]}`, `1`, []string{"$"}},
+		{"oneOf", `{"oneOf":[{"type":"number"},{"type":"integer"}]}`, `1`, []string{"$"}},
+		{"not", `{"not":{"type":"string"}}`, `"a"`, []string{"$"}},
+		{"if then", `{"if":{"properties":{"a":{"const":1}}},"then":{"required":["b"]},"else":{"required":["c"]}}`, `{"a":1}`, []string{"$.b"}},
+		{"if else", `{"if":{"properties":{"a":{"const":1}}},"then":{"required":["b"]},"else":{"required":["c"]}}`, `{"a":2}`, []string{"$.c"}},
+		{"reference", `{"definitions":{"code":{"type":"string","pattern":"^[A-Z]+$"}},"properties":{"code":{"$ref":"#/definitions/code"}}}`, `{"code":"abc"}`, []string{"$.code"}},
+		{"recursive reference", `{"properties":{"child":{"$ref":"#"},"id":{"type":"integer"}}}`, `{"child":{"child":{"id":"a"}}}`, []string{"$.child.child.id"}},
+		{"escaped reference", `{"definitions":{"a/b":{"type":"integer"}},"properties":{"x":{"$ref":"#/definitions/a~1b"}}}`, `{"x":"a"}`, []string{"$.x"}},
+		{"unresolved reference", `{"$ref":"#/definitions/missing"}`, `1`, []string{"$"}},
+		{"remote reference", `{"$ref":"http://example.com/schema.json"}`, `1`, []string{"$"}},
+		// Properties named after keywords are schemas.
+		{"property named default", `{"type":"object","properties":{"default":{"type":"string","pattern":"^a"}}}`, `{"default":"bc"}`, []string{"$.default"}},
+		{"property named enum", `{"properties":{"enum":{"pattern":"^a"},"const":{"pattern":"^a"}}}`, `{"enum":"abc","const":"b"}`, []string{"$.const"}},
+		{"definition named examples", `{"definitions":{"examples":{"pattern":"^a"}},"$ref":"#/definitions/examples"}`, `"b"`, []string{"$"}},
+		{"pattern properties named default", `{"patternProperties":{"^d":{"properties":{"default":{"pattern":"^a"}}}}}`, `{"d":{"default":"b"}}`, []string{"$.d.default"}},
+		// The pattern of an example is only reachable through a reference.
+		{"reference to an example", `{"examples":[{"pattern":"^a"}],"$ref":"#/examples/0"}`, `"b"`, []string{"$"}},
+	}
+	for _, test := range tests {
+		actual := paths(t, test.schema, test.value)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s: expected errors at %v, got %v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestValidateMessages(t *testing.T) {
+	s, _ := Parse(`{"type":"object","properties":{"default":{"type":"string","pattern":"^a"}}}`)
+	errs := s.Validate(decode(t, `{"default":"bc"}`))
+	expected := []ValidationError{{Path: "$.default", Message: "value 'bc' does not match pattern '^a'"}}
+	if !reflect.DeepEqual(errs, expected) {
+		t.Errorf("expected %v, got %v", expected, errs)
+	}
+	if errs = s.Validate(decode(t, `{"default":"abc"}`)); errs != nil {
+		t.Errorf("expected no error, got %v", errs)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, schema := range []string{
+		`{"type":`,
+		`"string"`,
+		`{"pattern":"("}`,
+		`{"patternProperties":{"(":{}}}`,
+		`{"properties":{"default":{"pattern":"("}}}`,
+	} {
+		if _, err := Parse(schema); err == nil {
+			t.Errorf("expected an error for schema %s", schema)
+		}
+	}
+	// Values of enum, const, default and examples are not schemas.
+	if _, err := Parse(`{"default":{"pattern":"("},"enum":[{"pattern":"("}]}`); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+}
+
+func TestChildPath(t *testing.T) {
+	if p := childPath("$", "first_name"); p != "$.first_name" {
+		t.Errorf("unexpected path %s", p)
+	}
+	if p := childPath("$", "first name"); p != `$["first name"]` {
+		t.Errorf("unexpected path %s", p)
+	}
+}
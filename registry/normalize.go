@@ -0,0 +1,44 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package registry
+
+import (
+	"github.com/fhussonnois/kafkacli/registry/avro"
+)
+
+// Normalize rewrites an Avro schema in its normalized form, so that registering or looking up
+// schemas which only differ by whitespaces, attributes order or namespace declarations gives the same result.
+// Schemas which are not Avro schemas are returned unchanged.
+// Return a new Schema struct.
+func Normalize(schema Schema) (Schema, error) {
+	if schema.SchemaType != "" && schema.SchemaType != "AVRO" {
+		return schema, nil
+	}
+	parsed, err := avro.Parse(schema.Value)
+	if err != nil {
+		return schema, err
+	}
+	schema.Value = parsed.NormalizedForm()
+	return schema, nil
+}
+
+// Fingerprint computes the Parsing Canonical Form of an Avro schema along with its fingerprints.
+// Return a new avro.Fingerprints struct.
+func Fingerprint(schema Schema) (avro.Fingerprints, error) {
+	parsed, err := avro.Parse(schema.Value)
+	if err != nil {
+		return avro.Fingerprints{}, err
+	}
+	return avro.Fingerprint(parsed), nil
+}
@@ -0,0 +1,45 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package registrytest provides an in-memory fake Schema Registry to test code using the registry package
+// without a live registry.
+package registrytest
+
+import (
+	"github.com/fhussonnois/kafkacli/registry"
+	"github.com/fhussonnois/kafkacli/registry/server"
+	"net/http/httptest"
+)
+
+// Server is an in-memory Schema Registry served through an httptest.Server.
+// See the server package for the supported REST API.
+type Server struct {
+	*httptest.Server
+	Registry *server.Registry
+}
+
+// Create and start a new Server. It must be closed once done.
+func NewServer() *Server {
+	r, _ := server.New("")
+	return &Server{Server: httptest.NewServer(r), Registry: r}
+}
+
+// Client returns a client connected to the server.
+func (s *Server) Client() *registry.SchemaRegistryRestClient {
+	client, err := registry.NewRegistryClientFromURL(s.URL)
+	if err != nil {
+		panic(err)
+	}
+	return &client
+}
@@ -0,0 +1,136 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package registrytest
+
+import (
+	"encoding/json"
+	"github.com/fhussonnois/kafkacli/registry"
+	"testing"
+)
+
+const (
+	userV1        = `{"type":"record","name":"User","fields":[{"name":"id","type":"long"}]}`
+	userV2        = `{"type":"record","name":"User","fields":[{"name":"id","type":"long"},{"name":"email","type":"string","default":""}]}`
+	userIncompat  = `{"type":"record","name":"User","fields":[{"name":"id","type":"string"}]}`
+	userV1Spacing = `{"type": "record", "name": "User", "fields": [{"name": "id", "type": "long"}]}`
+)
+
+func TestRegisterAndGetVersions(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	id, err := client.Register("users-value", registry.Schema{Value: userV1})
+	if err != nil || id.Value != 1 {
+		t.Fatalf("expected schema registered with ID 1, got %v (%v)", id, err)
+	}
+	id, err = client.Register("users-value", registry.Schema{Value: userV2})
+	if err != nil || id.Value != 2 {
+		t.Fatalf("expected schema registered with ID 2, got %v (%v)", id, err)
+	}
+	// The same schema registered under another subject keeps its ID.
+	if id, _ = client.Register("customers-value", registry.Schema{Value: userV1Spacing}); id.Value != 1 {
+		t.Errorf("expected schema ID 1 to be reused, got %d", id.Value)
+	}
+
+	subjects, _ := client.Subjects()
+	if len(subjects) != 2 {
+		t.Errorf("expected 2 subjects, got %v", subjects)
+	}
+	versions, _ := client.Versions("users-value")
+	if len(versions) != 2 || versions[0] != 1 || versions[1] != 2 {
+		t.Errorf("expected versions [1 2], got %v", versions)
+	}
+	latest, err := client.GetSubjectVersion("users-value", "latest")
+	if err != nil || latest.Version != 2 || latest.ID != 2 || latest.Subject != "users-value" {
+		t.Errorf("expected latest version 2 with ID 2, got %+v (%v)", latest, err)
+	}
+	schema, err := client.GetSchemaByID(1)
+	if err != nil || schema.Value != userV1 {
+		t.Errorf("expected schema 1 to be %s, got %+v (%v)", userV1, schema, err)
+	}
+	if _, err = client.GetSchemaByID(42); err == nil {
+		t.Error("expected an error for an unknown schema ID")
+	}
+	existing, err := client.Exists("users-value", registry.Schema{Value: userV1})
+	if err != nil || existing.Version != 1 || existing.ID != 1 {
+		t.Errorf("expected schema to exist as version 1, got %+v (%v)", existing, err)
+	}
+	if _, err = client.Exists("users-value", registry.Schema{Value: userIncompat}); err == nil {
+		t.Error("expected an error for a schema which is not registered")
+	}
+}
+
+func TestCompatibilityChecks(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := server.Client()
+	client.Register("users-value", registry.Schema{Value: userV1})
+
+	compatible, _ := client.CheckSubjectCompatibility("users-value", "latest", registry.Schema{Value: userV2})
+	if !compatible.Value {
+		t.Error("expected adding a field with a default to be compatible")
+	}
+	compatible, _ = client.CheckSubjectCompatibility("users-value", "latest", registry.Schema{Value: userIncompat})
+	if compatible.Value {
+		t.Error("expected changing the type of a field to be incompatible")
+	}
+	if _, err := client.Register("users-value", registry.Schema{Value: userIncompat}); err == nil {
+		t.Error("expected an incompatible schema to be rejected")
+	}
+
+	if _, err := client.UpdateSubjectCompatibility("users-value", registry.Compatibility{Value: "NONE"}); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	level, _ := client.GetSubjectCompatibility("users-value")
+	if level.Value != "NONE" {
+		t.Errorf("expected subject compatibility NONE, got %s", level.Value)
+	}
+	if _, err := client.Register("users-value", registry.Schema{Value: userIncompat}); err != nil {
+		t.Errorf("expected any schema to be accepted with compatibility NONE, got %s", err)
+	}
+
+	if _, err := client.UpdateGlobalCompatibility(registry.Compatibility{Value: "FULL"}); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	var global registry.CompatibilityLevel
+	config, _ := client.GetGlobalCompatibility()
+	if json.Unmarshal([]byte(config), &global); global.Value != "FULL" {
+		t.Errorf("expected global compatibility FULL, got %s", config)
+	}
+}
+
+func TestImportMode(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	if _, err := client.Register("users-value", registry.Schema{Value: userV1, ID: 10, Version: 3}); err == nil {
+		t.Error("expected IDs to be rejected outside of IMPORT mode")
+	}
+	if _, err := client.SetMode(registry.Mode{Value: registry.MODE_IMPORT}, false); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if id, err := client.Register("users-value", registry.Schema{Value: userV1, ID: 10, Version: 3}); err != nil || id.Value != 10 {
+		t.Fatalf("expected schema to be imported with ID 10, got %v (%v)", id, err)
+	}
+	version, _ := client.GetSubjectVersion("users-value", "3")
+	if version.ID != 10 {
+		t.Errorf("expected version 3 to have ID 10, got %+v", version)
+	}
+	mode, _ := client.GetMode()
+	if mode.Value != registry.MODE_IMPORT {
+		t.Errorf("expected mode IMPORT, got %s", mode.Value)
+	}
+}
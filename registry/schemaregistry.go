@@ -19,23 +19,43 @@ import (
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 )
 
 const (
 	HTTP     = "HTTP://"
+	HTTPS    = "HTTPS://"
 	SUBJECTS = "/subjects/"
 )
 
+const (
+	MODE_READWRITE = "READWRITE"
+	MODE_READONLY  = "READONLY"
+	MODE_IMPORT    = "IMPORT"
+)
+
 const (
 	HEADER_ACCEPT       = `application/vnd.schemaregistry.v1+json, application/vnd.schemaregistry+json, application/json`
 	HEADER_CONTENT_TYPE = `application/json`
 )
 
 type SchemaVersion struct {
+	Name       string            `json:"name"`
+	Subject    string            `json:"subject"`
+	ID         int               `json:"id"`
+	Version    int               `json:"version"`
+	SchemaType string            `json:"schemaType,omitempty"`
+	References []SchemaReference `json:"references,omitempty"`
+	Schema     string            `json:"schema"`
+}
+
+// SchemaReference is a reference to a schema registered under another subject.
+type SchemaReference struct {
 	Name    string `json:"name"`
+	Subject string `json:"subject"`
 	Version int    `json:"version"`
-	Schema  string `json:"schema"`
 }
 
 type ID struct {
@@ -61,14 +81,44 @@ type IsCompatible struct {
 	Value bool `json:"is_compatible"`
 }
 
+type Mode struct {
+	Value string `json:"mode"`
+}
+
+// Schema is the payload used to register, lookup or test a schema.
+// ID and Version are only honored by a registry (or subject) in IMPORT mode.
 type Schema struct {
-	Value string `json:"schema"`
+	Value      string            `json:"schema"`
+	SchemaType string            `json:"schemaType,omitempty"`
+	References []SchemaReference `json:"references,omitempty"`
+	ID         int               `json:"id,omitempty"`
+	Version    int               `json:"version,omitempty"`
+}
+
+// Client is the method set of SchemaRegistryRestClient, so that it can be wrapped (e.g CachedClient).
+type Client interface {
+	Subjects() ([]string, error)
+	Versions(subject string) ([]int, error)
+	GetSubjectVersion(subject string, version string) (SchemaVersion, error)
+	GetSchemaByID(id int) (Schema, error)
+	Register(subject string, schema Schema) (ID, error)
+	Exists(subject string, schema Schema) (NewSchemaVersion, error)
+	GetGlobalCompatibility() (string, error)
+	UpdateGlobalCompatibility(compatibility Compatibility) (Compatibility, error)
+	GetSubjectCompatibility(subject string) (CompatibilityLevel, error)
+	UpdateSubjectCompatibility(subject string, compatibility Compatibility) (Compatibility, error)
+	CheckSubjectCompatibility(subject string, versionId string, schema Schema) (IsCompatible, error)
+	GetMode() (Mode, error)
+	GetSubjectMode(subject string) (Mode, error)
+	SetMode(mode Mode, force bool) (Mode, error)
+	SetSubjectMode(subject string, mode Mode, force bool) (Mode, error)
 }
 
 // SchemaRegistryRestClient is a simple http-client to interact with a schema registry instance.
 type SchemaRegistryRestClient struct {
-	host string
-	port int
+	scheme string
+	host   string
+	port   int
 }
 
 // Create a new SchemaRegistryRestClient struct.
@@ -79,8 +129,36 @@ func NewRegistryClient(host string, port int) SchemaRegistryRestClient {
 	}
 }
 
+// Create a new SchemaRegistryRestClient struct from an URL, e.g http://localhost:8081.
+func NewRegistryClientFromURL(rawurl string) (SchemaRegistryRestClient, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Hostname() == "" {
+		return SchemaRegistryRestClient{}, errors.New("Invalid schema registry url '" + rawurl + "'")
+	}
+	scheme := strings.ToUpper(u.Scheme) + "://"
+	if scheme != HTTP && scheme != HTTPS {
+		return SchemaRegistryRestClient{}, errors.New("Unsupported scheme for schema registry url '" + rawurl + "'")
+	}
+	port := 80
+	if scheme == HTTPS {
+		port = 443
+	}
+	if u.Port() != "" {
+		if port, err = strconv.Atoi(u.Port()); err != nil {
+			return SchemaRegistryRestClient{}, errors.New("Invalid port for schema registry url '" + rawurl + "'")
+		}
+	}
+	client := NewRegistryClient(u.Hostname(), port)
+	client.scheme = scheme
+	return client, nil
+}
+
 func (client *SchemaRegistryRestClient) hostname() string {
-	return HTTP + client.host + ":" + strconv.Itoa(client.port)
+	scheme := client.scheme
+	if scheme == "" {
+		scheme = HTTP
+	}
+	return scheme + client.host + ":" + strconv.Itoa(client.port)
 }
 
 func (client *SchemaRegistryRestClient) subjectsEndPoint() string {
@@ -120,8 +198,22 @@ func (client *SchemaRegistryRestClient) GetSubjectVersion(subject string, versio
 	return
 }
 
+// GetSchemaByID retrieves the schema identified by the globally unique ID.
+// Return a new Schema struct.
+func (client *SchemaRegistryRestClient) GetSchemaByID(id int) (r Schema, e error) {
+	response, e := sendGetResponse("GET", client.hostname()+"/schemas/ids/"+strconv.Itoa(id), "")
+	if e == nil {
+		err := json.Unmarshal(response, &r)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return
+}
+
 // Register registers a new schema under the specified subject.
-// Return a new NewSchemaVersion struct.
+// If the schema has an ID and a Version, the registry (or subject) must be in IMPORT mode.
+// Return the ID of the registered schema.
 func (client *SchemaRegistryRestClient) Register(subject string, schema Schema) (r ID, e error) {
 	body, _ := json.Marshal(schema)
 	response, e := sendGetResponse("POST", client.subjectsEndPoint()+subject+"/versions", string(body))
@@ -157,6 +249,20 @@ func (client *SchemaRegistryRestClient) GetGlobalCompatibility() (r string, e er
 	return
 }
 
+// UpdateGlobalCompatibility sets the global compatibility level.
+// Return the new Compatibility.
+func (client *SchemaRegistryRestClient) UpdateGlobalCompatibility(compatibility Compatibility) (r Compatibility, e error) {
+	body, _ := json.Marshal(compatibility)
+	response, e := sendGetResponse("PUT", client.hostname()+"/config", string(body))
+	if e == nil {
+		err := json.Unmarshal(response, &r)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return
+}
+
 // GetSubjectCompatibility retrieves the compatibility level for the specified subject.
 // Return as JSON string.
 func (client *SchemaRegistryRestClient) GetSubjectCompatibility(subject string) (r CompatibilityLevel, e error) {
@@ -198,6 +304,69 @@ func (client *SchemaRegistryRestClient) CheckSubjectCompatibility(subject string
 	return
 }
 
+// GetMode retrieves the global mode of the schema registry.
+// Return a new Mode struct.
+func (client *SchemaRegistryRestClient) GetMode() (r Mode, e error) {
+	response, e := sendGetResponse("GET", client.hostname()+"/mode", "")
+	if e == nil {
+		err := json.Unmarshal(response, &r)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return
+}
+
+// GetSubjectMode retrieves the mode for the specified subject.
+// Return a new Mode struct.
+func (client *SchemaRegistryRestClient) GetSubjectMode(subject string) (r Mode, e error) {
+	response, e := sendGetResponse("GET", client.hostname()+"/mode/"+subject, "")
+	if e == nil {
+		err := json.Unmarshal(response, &r)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return
+}
+
+// SetMode sets the global mode of the schema registry.
+// The force flag allows switching to IMPORT mode even if the registry already contains schemas.
+// Return the new Mode.
+func (client *SchemaRegistryRestClient) SetMode(mode Mode, force bool) (r Mode, e error) {
+	body, _ := json.Marshal(mode)
+	response, e := sendGetResponse("PUT", client.hostname()+"/mode"+forceQuery(force), string(body))
+	if e == nil {
+		err := json.Unmarshal(response, &r)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return
+}
+
+// SetSubjectMode sets the mode for the specified subject.
+// The force flag allows switching to IMPORT mode even if the subject already contains schemas.
+// Return the new Mode.
+func (client *SchemaRegistryRestClient) SetSubjectMode(subject string, mode Mode, force bool) (r Mode, e error) {
+	body, _ := json.Marshal(mode)
+	response, e := sendGetResponse("PUT", client.hostname()+"/mode/"+subject+forceQuery(force), string(body))
+	if e == nil {
+		err := json.Unmarshal(response, &r)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return
+}
+
+func forceQuery(force bool) string {
+	if force {
+		return "?force=true"
+	}
+	return ""
+}
+
 func unmarshalArrayString(s string) []string {
 	res := make([]string, 0)
 	err := json.Unmarshal([]byte(s), &res)
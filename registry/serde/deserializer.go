@@ -0,0 +1,63 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package serde
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/fhussonnois/kafkacli/registry/avro"
+)
+
+// Message is a deserialized message.
+type Message struct {
+	SchemaID   int         `json:"schema_id"`
+	SchemaType string      `json:"schema_type"`
+	Value      interface{} `json:"value"`
+}
+
+// Deserializer decodes messages using the schema identified in their header.
+type Deserializer struct {
+	schemas *schemaCache
+}
+
+// Create a new Deserializer. Schemas retrieved by ID are cached.
+func NewDeserializer(client Client) *Deserializer {
+	return &Deserializer{schemas: newSchemaCache(client)}
+}
+
+// Deserialize decodes a message in the wire format.
+// Return a new Message struct.
+func (d *Deserializer) Deserialize(data []byte) (*Message, error) {
+	id, payload, err := ParseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := d.schemas.get(id)
+	if err != nil {
+		return nil, err
+	}
+	res := &Message{SchemaID: id, SchemaType: schema.schemaType}
+	switch schema.schemaType {
+	case AVRO:
+		res.Value, err = avro.Decode(schema.avro, payload)
+	case JSON:
+		decoder := json.NewDecoder(bytes.NewReader(payload))
+		decoder.UseNumber()
+		err = decoder.Decode(&res.Value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
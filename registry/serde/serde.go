@@ -0,0 +1,191 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package serde serializes and deserializes messages using the Confluent wire format:
+// a magic byte, the 4-bytes big-endian ID of the schema in the registry, and the encoded payload.
+package serde
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fhussonnois/kafkacli/registry"
+	"github.com/fhussonnois/kafkacli/registry/avro"
+	"strconv"
+	"sync"
+)
+
+const (
+	MAGIC_BYTE  = 0x0
+	HEADER_SIZE = 5
+)
+
+// Schema types.
+const (
+	AVRO = "AVRO"
+	JSON = "JSON"
+)
+
+// Client is the subset of the SchemaRegistryRestClient methods used to serialize and deserialize messages.
+type Client interface {
+	Register(subject string, schema registry.Schema) (registry.ID, error)
+	Exists(subject string, schema registry.Schema) (registry.NewSchemaVersion, error)
+	GetSubjectVersion(subject string, version string) (registry.SchemaVersion, error)
+	GetSchemaByID(id int) (registry.Schema, error)
+}
+
+// SubjectNameStrategy returns the subject under which the schema of a message is registered.
+// The record name is the full name of an Avro record, or the title of a JSON schema.
+type SubjectNameStrategy func(topic string, isKey bool, recordName string) string
+
+// TopicNameStrategy uses the topic name suffixed with "-key" or "-value".
+func TopicNameStrategy(topic string, isKey bool, recordName string) string {
+	if isKey {
+		return topic + "-key"
+	}
+	return topic + "-value"
+}
+
+// RecordNameStrategy uses the record name.
+func RecordNameStrategy(topic string, isKey bool, recordName string) string {
+	return recordName
+}
+
+// TopicRecordNameStrategy uses the topic name followed by the record name.
+func TopicRecordNameStrategy(topic string, isKey bool, recordName string) string {
+	return topic + "-" + recordName
+}
+
+// Frame prepends the wire format header to the payload.
+func Frame(id int, payload []byte) []byte {
+	res := make([]byte, HEADER_SIZE, HEADER_SIZE+len(payload))
+	res[0] = MAGIC_BYTE
+	binary.BigEndian.PutUint32(res[1:HEADER_SIZE], uint32(id))
+	return append(res, payload...)
+}
+
+// ParseHeader reads the wire format header of a message.
+// Return the schema ID and the payload.
+func ParseHeader(data []byte) (int, []byte, error) {
+	if len(data) < HEADER_SIZE {
+		return 0, nil, fmt.Errorf("message is too short (%d bytes) to be in the wire format", len(data))
+	}
+	if data[0] != MAGIC_BYTE {
+		return 0, nil, fmt.Errorf("unknown magic byte %d", data[0])
+	}
+	return int(binary.BigEndian.Uint32(data[1:HEADER_SIZE])), data[HEADER_SIZE:], nil
+}
+
+// registeredSchema is a schema registered in the registry along with its parsed form.
+type registeredSchema struct {
+	id         int
+	schemaType string
+	text       string
+	avro       *avro.Schema
+}
+
+// recordName returns the full name of an Avro record or the title of a JSON schema.
+func (s *registeredSchema) recordName() string {
+	if s.avro != nil {
+		return s.avro.Name
+	}
+	var jsonSchema struct {
+		Title string `json:"title"`
+	}
+	json.Unmarshal([]byte(s.text), &jsonSchema)
+	return jsonSchema.Title
+}
+
+// schemaCache caches the schemas retrieved by ID. Schemas are immutable and never evicted.
+type schemaCache struct {
+	client Client
+	lock   sync.Mutex
+	byID   map[int]*registeredSchema
+}
+
+func newSchemaCache(client Client) *schemaCache {
+	return &schemaCache{client: client, byID: make(map[int]*registeredSchema)}
+}
+
+func (c *schemaCache) get(id int) (*registeredSchema, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if s, ok := c.byID[id]; ok {
+		return s, nil
+	}
+	schema, err := c.client.GetSchemaByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s, err := parseSchema(c.client, id, schema)
+	if err != nil {
+		return nil, err
+	}
+	c.byID[id] = s
+	return s, nil
+}
+
+func (c *schemaCache) put(s *registeredSchema) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.byID[s.id] = s
+}
+
+// parseSchema parses an Avro schema, resolving its references. JSON schemas are kept as is.
+func parseSchema(client Client, id int, schema registry.Schema) (*registeredSchema, error) {
+	res := &registeredSchema{id: id, schemaType: schema.SchemaType, text: schema.Value}
+	if res.schemaType == "" {
+		res.schemaType = AVRO
+	}
+	switch res.schemaType {
+	case AVRO:
+		names := make(map[string]*avro.Schema)
+		if err := resolveReferences(client, schema.References, names); err != nil {
+			return nil, err
+		}
+		parsed, err := avro.ParseWithNames(schema.Value, names)
+		if err != nil {
+			return nil, errors.New("Invalid Avro schema: " + err.Error())
+		}
+		res.avro = parsed
+	case JSON:
+		if !json.Valid([]byte(schema.Value)) {
+			return nil, errors.New("Invalid JSON schema")
+		}
+	default:
+		return nil, errors.New("Unsupported schema type '" + res.schemaType + "'")
+	}
+	return res, nil
+}
+
+// resolveReferences parses the referenced Avro schemas, and their own references, into names.
+func resolveReferences(client Client, references []registry.SchemaReference, names map[string]*avro.Schema) error {
+	for _, ref := range references {
+		version, err := client.GetSubjectVersion(ref.Subject, strconv.Itoa(ref.Version))
+		if err != nil {
+			return errors.New("Error while resolving reference " + ref.Name + ": " + err.Error())
+		}
+		if err := resolveReferences(client, version.References, names); err != nil {
+			return err
+		}
+		if _, defined := names[ref.Name]; defined {
+			continue
+		}
+		if _, err := avro.ParseWithNames(version.Schema, names); err != nil {
+			return errors.New("Invalid referenced schema " + ref.Name + ": " + err.Error())
+		}
+	}
+	return nil
+}
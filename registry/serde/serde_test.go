@@ -0,0 +1,122 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package serde
+
+import (
+	"encoding/json"
+	"github.com/fhussonnois/kafkacli/registry"
+	"github.com/fhussonnois/kafkacli/registry/registrytest"
+	"reflect"
+	"testing"
+)
+
+const userSchema = `{"type":"record","name":"User","namespace":"com.example","fields":[{"name":"id","type":"long"},{"name":"email","type":["null","string"],"default":null}]}`
+
+func TestFrameAndParseHeader(t *testing.T) {
+	message := Frame(258, []byte("payload"))
+	if !reflect.DeepEqual(message[:HEADER_SIZE], []byte{MAGIC_BYTE, 0, 0, 1, 2}) {
+		t.Errorf("unexpected header %v", message[:HEADER_SIZE])
+	}
+	id, payload, err := ParseHeader(message)
+	if err != nil || id != 258 || string(payload) != "payload" {
+		t.Errorf("expected ID 258 and payload, got %d %q (%v)", id, payload, err)
+	}
+	if _, _, err = ParseHeader([]byte{0, 1}); err == nil {
+		t.Error("expected an error for a message too short")
+	}
+	if _, _, err = ParseHeader([]byte{1, 0, 0, 0, 1}); err == nil {
+		t.Error("expected an error for an unknown magic byte")
+	}
+}
+
+func TestSubjectNameStrategies(t *testing.T) {
+	if s := TopicNameStrategy("users", true, "com.example.User"); s != "users-key" {
+		t.Errorf("unexpected subject %s", s)
+	}
+	if s := RecordNameStrategy("users", false, "com.example.User"); s != "com.example.User" {
+		t.Errorf("unexpected subject %s", s)
+	}
+	if s := TopicRecordNameStrategy("users", false, "com.example.User"); s != "users-com.example.User" {
+		t.Errorf("unexpected subject %s", s)
+	}
+}
+
+func TestAvroRoundTrip(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	serializer := NewAvroSerializer(client, SerializerConfig{AutoRegister: true})
+	value := map[string]interface{}{"id": 42, "email": "jane@example.com"}
+	message, err := serializer.Serialize("users", userSchema, value)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if version, err := client.GetSubjectVersion("users-value", "latest"); err != nil || version.ID != 1 {
+		t.Errorf("expected schema to be registered under users-value, got %+v (%v)", version, err)
+	}
+
+	res, err := NewDeserializer(client).Deserialize(message)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if res.SchemaID != 1 || res.SchemaType != AVRO {
+		t.Errorf("expected Avro schema 1, got %d %s", res.SchemaID, res.SchemaType)
+	}
+	decoded, _ := json.Marshal(res.Value)
+	if string(decoded) != `{"email":"jane@example.com","id":42}` {
+		t.Errorf("unexpected value %s", decoded)
+	}
+}
+
+func TestSerializeWithoutAutoRegister(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	serializer := NewAvroSerializer(client, SerializerConfig{})
+	value := map[string]interface{}{"id": 1, "email": nil}
+	if _, err := serializer.Serialize("users", userSchema, value); err == nil {
+		t.Error("expected an error for a schema which is not registered")
+	}
+	client.Register("users-value", registry.Schema{Value: userSchema})
+	if _, err := serializer.Serialize("users", userSchema, value); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+	if _, err := serializer.Serialize("users", userSchema, map[string]interface{}{"id": "not a long"}); err == nil {
+		t.Error("expected an error for an invalid value")
+	}
+}
+
+func TestJSONRoundTripWithLatestVersion(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	schema := `{"title":"User","type":"object","properties":{"id":{"type":"integer"}}}`
+	client.Register("users-value", registry.Schema{Value: schema, SchemaType: JSON})
+
+	serializer := NewJSONSerializer(client, SerializerConfig{UseLatestVersion: true})
+	message, err := serializer.Serialize("users", "", map[string]interface{}{"id": 7})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	res, err := NewDeserializer(client).Deserialize(message)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	decoded, _ := json.Marshal(res.Value)
+	if res.SchemaType != JSON || string(decoded) != `{"id":7}` {
+		t.Errorf("unexpected message %s %s", res.SchemaType, decoded)
+	}
+}
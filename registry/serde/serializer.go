@@ -0,0 +1,225 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package serde
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/fhussonnois/kafkacli/registry"
+	"github.com/fhussonnois/kafkacli/registry/avro"
+	"sync"
+)
+
+// SerializerConfig configures how a Serializer retrieves the ID of the schema used to encode messages.
+type SerializerConfig struct {
+	// AutoRegister registers the schema if it does not exist under the subject.
+	AutoRegister bool
+	// UseLatestVersion uses the latest version registered under the subject instead of the given schema.
+	UseLatestVersion bool
+	// IsKey specifies whether messages are keys or values.
+	IsKey bool
+	// SubjectNameStrategy defaults to TopicNameStrategy.
+	SubjectNameStrategy SubjectNameStrategy
+}
+
+// Serializer encodes values using either Avro binary or JSON, framed with the ID of their schema.
+type Serializer struct {
+	client     Client
+	schemaType string
+	config     SerializerConfig
+	schemas    *schemaCache
+	lock       sync.Mutex
+	ids        map[string]int
+	latest     map[string]int
+}
+
+// Create a new Serializer encoding values using the Avro binary encoding.
+func NewAvroSerializer(client Client, config SerializerConfig) *Serializer {
+	return newSerializer(client, AVRO, config)
+}
+
+// Create a new Serializer encoding values as JSON documents, described by JSON schemas.
+func NewJSONSerializer(client Client, config SerializerConfig) *Serializer {
+	return newSerializer(client, JSON, config)
+}
+
+func newSerializer(client Client, schemaType string, config SerializerConfig) *Serializer {
+	if config.SubjectNameStrategy == nil {
+		config.SubjectNameStrategy = TopicNameStrategy
+	}
+	return &Serializer{
+		client:     client,
+		schemaType: schemaType,
+		config:     config,
+		schemas:    newSchemaCache(client),
+		ids:        make(map[string]int),
+		latest:     make(map[string]int),
+	}
+}
+
+// Serialize encodes a value for the given topic.
+// The schema is required unless the serializer uses the latest version of the subject, and the value
+// is either a JSON decoded value or any value which can be marshalled to JSON (e.g generated structs).
+// Return the message in the wire format.
+func (s *Serializer) Serialize(topic string, schema string, value interface{}) ([]byte, error) {
+	var registered *registeredSchema
+	var err error
+	if s.config.UseLatestVersion {
+		registered, err = s.latestSchema(topic, schema)
+	} else {
+		registered, err = s.lookupSchema(topic, schema)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return encode(registered, value)
+}
+
+// SerializeVersion encodes a value using the schema of a version registered in the registry.
+// Return the message in the wire format.
+func SerializeVersion(client Client, version registry.SchemaVersion, value interface{}) ([]byte, error) {
+	schema := registry.Schema{Value: version.Schema, SchemaType: version.SchemaType, References: version.References}
+	registered, err := parseSchema(client, version.ID, schema)
+	if err != nil {
+		return nil, err
+	}
+	return encode(registered, value)
+}
+
+// encode writes the value using the schema, framed with the schema ID.
+func encode(registered *registeredSchema, value interface{}) ([]byte, error) {
+	value, err := toJsonValue(value)
+	if err != nil {
+		return nil, err
+	}
+	var payload []byte
+	switch registered.schemaType {
+	case AVRO:
+		payload, err = avro.Encode(registered.avro, value)
+	case JSON:
+		payload, err = json.Marshal(value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Frame(registered.id, payload), nil
+}
+
+// lookupSchema retrieves the ID of the schema under the subject, registering it if auto-registration is enabled.
+func (s *Serializer) lookupSchema(topic string, schema string) (*registeredSchema, error) {
+	if schema == "" {
+		return nil, errors.New("Missing schema")
+	}
+	parsed, err := parseSchema(s.client, 0, registry.Schema{Value: schema, SchemaType: s.registryType()})
+	if err != nil {
+		return nil, err
+	}
+	subject := s.config.SubjectNameStrategy(topic, s.config.IsKey, parsed.recordName())
+	if subject == "" {
+		return nil, errors.New("Cannot resolve the subject for topic " + topic)
+	}
+
+	key := subject + "\x00" + schema
+	s.lock.Lock()
+	id, ok := s.ids[key]
+	s.lock.Unlock()
+	if !ok {
+		payload := registry.Schema{Value: schema, SchemaType: s.registryType()}
+		if s.config.AutoRegister {
+			res, err := s.client.Register(subject, payload)
+			if err != nil {
+				return nil, err
+			}
+			id = res.Value
+		} else {
+			res, err := s.client.Exists(subject, payload)
+			if err != nil {
+				return nil, err
+			}
+			id = res.ID
+		}
+		s.lock.Lock()
+		s.ids[key] = id
+		s.lock.Unlock()
+	}
+	parsed.id = id
+	s.schemas.put(parsed)
+	return parsed, nil
+}
+
+// latestSchema retrieves the latest version registered under the subject.
+// The schema, if any, is only used to resolve the record name.
+func (s *Serializer) latestSchema(topic string, schema string) (*registeredSchema, error) {
+	recordName := ""
+	if schema != "" {
+		parsed, err := parseSchema(s.client, 0, registry.Schema{Value: schema, SchemaType: s.registryType()})
+		if err != nil {
+			return nil, err
+		}
+		recordName = parsed.recordName()
+	}
+	subject := s.config.SubjectNameStrategy(topic, s.config.IsKey, recordName)
+	if subject == "" {
+		return nil, errors.New("Cannot resolve the subject for topic " + topic + " without schema")
+	}
+
+	s.lock.Lock()
+	id, ok := s.latest[subject]
+	s.lock.Unlock()
+	if ok {
+		return s.schemas.get(id)
+	}
+	version, err := s.client.GetSubjectVersion(subject, "latest")
+	if err != nil {
+		return nil, err
+	}
+	registered, err := parseSchema(s.client, version.ID, registry.Schema{Value: version.Schema, SchemaType: version.SchemaType, References: version.References})
+	if err != nil {
+		return nil, err
+	}
+	if registered.schemaType != s.schemaType {
+		return nil, errors.New("The latest version of subject " + subject + " is not a " + s.schemaType + " schema")
+	}
+	s.schemas.put(registered)
+	s.lock.Lock()
+	s.latest[subject] = version.ID
+	s.lock.Unlock()
+	return registered, nil
+}
+
+// registryType returns the schema type as expected by the registry, which omits it for Avro.
+func (s *Serializer) registryType() string {
+	if s.schemaType == AVRO {
+		return ""
+	}
+	return s.schemaType
+}
+
+// toJsonValue converts a value into a JSON decoded value, by marshalling it to JSON if needed.
+func toJsonValue(value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case nil, bool, string, json.Number, float64, int, int32, int64, []interface{}, map[string]interface{}:
+		return value, nil
+	}
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var res interface{}
+	err = decoder.Decode(&res)
+	return res, err
+}
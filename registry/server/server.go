@@ -0,0 +1,740 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package server implements a lightweight Schema Registry serving the core REST API (subjects, versions,
+// schemas by ID, compatibility levels and checks, deletes and modes), with an optional file persistence.
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/fhussonnois/kafkacli/registry"
+	"github.com/fhussonnois/kafkacli/registry/avro"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Error codes returned by the registry.
+const (
+	SUBJECT_NOT_FOUND            = 40401
+	VERSION_NOT_FOUND            = 40402
+	SCHEMA_NOT_FOUND             = 40403
+	SUBJECT_SOFT_DELETED         = 40404
+	SUBJECT_NOT_SOFT_DELETED     = 40405
+	VERSION_SOFT_DELETED         = 40406
+	VERSION_NOT_SOFT_DELETED     = 40407
+	SUBJECT_CONFIG_NOT_FOUND     = 40408
+	SUBJECT_MODE_NOT_FOUND       = 40409
+	INCOMPATIBLE_SCHEMA          = 409
+	INVALID_SCHEMA               = 42201
+	INVALID_VERSION              = 42202
+	INVALID_COMPATIBILITY        = 42203
+	INVALID_MODE                 = 42204
+	OPERATION_NOT_PERMITTED      = 42205
+	INTERNAL_SERVER_ERROR        = 50001
+	DEFAULT_COMPATIBILITY        = avro.BACKWARD
+	DEFAULT_MODE                 = registry.MODE_READWRITE
+	DEFAULT_SCHEMA_TYPE          = "AVRO"
+	CONTENT_TYPE_SCHEMA_REGISTRY = "application/vnd.schemaregistry.v1+json"
+	DATA_FILE                    = "registry.json"
+)
+
+// Registry is an http.Handler implementing the Schema Registry REST API.
+// Compatibility checks are only made for Avro schemas, schemas of other types being always compatible.
+type Registry struct {
+	lock  sync.Mutex
+	dir   string
+	state state
+	ids   map[string]int
+}
+
+// state holds all the data of the registry, as persisted in the data file.
+type state struct {
+	NextID        int                     `json:"next_id"`
+	Compatibility string                  `json:"compatibility"`
+	Mode          string                  `json:"mode"`
+	Schemas       map[int]registry.Schema `json:"schemas"`
+	Subjects      map[string]*subject     `json:"subjects"`
+}
+
+type subject struct {
+	Compatibility string    `json:"compatibility,omitempty"`
+	Mode          string    `json:"mode,omitempty"`
+	Versions      []version `json:"versions"`
+}
+
+type version struct {
+	registry.SchemaVersion
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// Create a new Registry. If the directory is not empty, the registry is loaded from and saved into
+// the file registry.json of this directory, which is created if needed.
+func New(dir string) (*Registry, error) {
+	r := &Registry{
+		dir: dir,
+		ids: make(map[string]int),
+		state: state{
+			NextID:        1,
+			Compatibility: DEFAULT_COMPATIBILITY,
+			Mode:          DEFAULT_MODE,
+			Schemas:       make(map[int]registry.Schema),
+			Subjects:      make(map[string]*subject),
+		},
+	}
+	if dir == "" {
+		return r, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, DATA_FILE))
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &r.state); err != nil {
+		return nil, fmt.Errorf("invalid data file %s: %s", filepath.Join(dir, DATA_FILE), err)
+	}
+	for _, id := range sortedIDs(r.state.Schemas) {
+		if key := r.key(r.state.Schemas[id]); key != "" {
+			if _, exists := r.ids[key]; !exists {
+				r.ids[key] = id
+			}
+		}
+	}
+	return r, nil
+}
+
+// save writes the data file, replacing the previous one only once fully written.
+func (r *Registry) save() error {
+	if r.dir == "" {
+		return nil
+	}
+	content, _ := json.MarshalIndent(r.state, "", "  ")
+	file := filepath.Join(r.dir, DATA_FILE)
+	if err := ioutil.WriteFile(file+".tmp", content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(file+".tmp", file)
+}
+
+// restError is the body of error responses.
+type restError struct {
+	Code    int    `json:"error_code"`
+	Message string `json:"message"`
+}
+
+func newError(code int, format string, args ...interface{}) *restError {
+	return &restError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// status returns the HTTP status of an error code, e.g 404 for 40401.
+func (e *restError) status() int {
+	if e.Code < 1000 {
+		return e.Code
+	}
+	return e.Code / 100
+}
+
+// ServeHTTP implements the REST API.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var segments []string
+	for _, segment := range strings.Split(strings.Trim(req.URL.EscapedPath(), "/"), "/") {
+		segment, _ = url.PathUnescape(segment)
+		segments = append(segments, segment)
+	}
+
+	r.lock.Lock()
+	res, err := r.route(req, segments)
+	if err == nil && req.Method != "GET" {
+		if e := r.save(); e != nil {
+			err = newError(INTERNAL_SERVER_ERROR, "Error while saving the registry: %s", e)
+		}
+	}
+	r.lock.Unlock()
+
+	w.Header().Set("Content-Type", CONTENT_TYPE_SCHEMA_REGISTRY)
+	if err != nil {
+		w.WriteHeader(err.status())
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+	if raw, ok := res.(string); ok {
+		w.Write([]byte(raw))
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (r *Registry) route(req *http.Request, segments []string) (interface{}, *restError) {
+	path := strings.Join(segments, "/")
+	query := req.URL.Query()
+	n := len(segments)
+	switch {
+	case path == "" && req.Method == "GET":
+		return map[string]interface{}{}, nil
+	case path == "subjects" && req.Method == "GET":
+		return r.listSubjects(query.Get("deleted") == "true"), nil
+	case path == "schemas/types" && req.Method == "GET":
+		return []string{"AVRO", "JSON"}, nil
+	case n == 3 && segments[0] == "schemas" && segments[1] == "ids" && req.Method == "GET":
+		return r.getSchemaByID(segments[2])
+	case n == 4 && segments[0] == "schemas" && segments[1] == "ids" && segments[3] == "schema" && req.Method == "GET":
+		schema, err := r.getSchemaByID(segments[2])
+		return schema.Value, err
+	case n == 2 && segments[0] == "subjects" && req.Method == "POST":
+		return r.lookupSchema(segments[1], req)
+	case n == 2 && segments[0] == "subjects" && req.Method == "DELETE":
+		return r.deleteSubject(segments[1], query.Get("permanent") == "true")
+	case n == 3 && segments[0] == "subjects" && segments[2] == "versions" && req.Method == "GET":
+		return r.listVersions(segments[1], query.Get("deleted") == "true")
+	case n == 3 && segments[0] == "subjects" && segments[2] == "versions" && req.Method == "POST":
+		return r.register(segments[1], req)
+	case n == 4 && segments[0] == "subjects" && segments[2] == "versions" && req.Method == "GET":
+		v, err := r.getVersion(segments[1], segments[3], query.Get("deleted") == "true")
+		return v.SchemaVersion, err
+	case n == 4 && segments[0] == "subjects" && segments[2] == "versions" && req.Method == "DELETE":
+		return r.deleteVersion(segments[1], segments[3], query.Get("permanent") == "true")
+	case n == 5 && segments[0] == "subjects" && segments[2] == "versions" && segments[4] == "schema" && req.Method == "GET":
+		v, err := r.getVersion(segments[1], segments[3], false)
+		return v.Schema, err
+	case path == "config" && req.Method == "GET":
+		return registry.CompatibilityLevel{Value: r.state.Compatibility}, nil
+	case path == "config" && req.Method == "PUT":
+		return r.updateCompatibility("", req)
+	case n == 2 && segments[0] == "config" && req.Method == "GET":
+		return r.getSubjectCompatibility(segments[1], query.Get("defaultToGlobal") == "true")
+	case n == 2 && segments[0] == "config" && req.Method == "PUT":
+		return r.updateCompatibility(segments[1], req)
+	case n == 2 && segments[0] == "config" && req.Method == "DELETE":
+		return r.deleteSubjectCompatibility(segments[1])
+	case n == 5 && segments[0] == "compatibility" && segments[1] == "subjects" && segments[3] == "versions" && req.Method == "POST":
+		return r.testCompatibility(segments[2], segments[4], req)
+	case path == "mode" && req.Method == "GET":
+		return registry.Mode{Value: r.state.Mode}, nil
+	case path == "mode" && req.Method == "PUT":
+		return r.updateMode("", req)
+	case n == 2 && segments[0] == "mode" && req.Method == "GET":
+		return r.getSubjectMode(segments[1], query.Get("defaultToGlobal") == "true")
+	case n == 2 && segments[0] == "mode" && req.Method == "PUT":
+		return r.updateMode(segments[1], req)
+	}
+	return nil, &restError{Code: http.StatusNotFound, Message: "HTTP 404 Not Found"}
+}
+
+// active returns the versions of the subject which have not been deleted.
+func (sub *subject) active() []version {
+	var res []version
+	for _, v := range sub.Versions {
+		if !v.Deleted {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func (r *Registry) listSubjects(deleted bool) []string {
+	res := make([]string, 0, len(r.state.Subjects))
+	for name, sub := range r.state.Subjects {
+		if len(sub.active()) > 0 || (deleted && len(sub.Versions) > 0) {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// getSubject returns the subject if it has versions, including deleted ones if specified.
+func (r *Registry) getSubject(name string, deleted bool) (*subject, *restError) {
+	sub, ok := r.state.Subjects[name]
+	if !ok || len(sub.Versions) == 0 || (!deleted && len(sub.active()) == 0) {
+		return nil, newError(SUBJECT_NOT_FOUND, "Subject '%s' not found.", name)
+	}
+	return sub, nil
+}
+
+func (r *Registry) listVersions(name string, deleted bool) ([]int, *restError) {
+	sub, err := r.getSubject(name, deleted)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]int, 0, len(sub.Versions))
+	for _, v := range sub.Versions {
+		if deleted || !v.Deleted {
+			res = append(res, v.Version)
+		}
+	}
+	return res, nil
+}
+
+func (r *Registry) getVersion(name string, number string, deleted bool) (version, *restError) {
+	sub, err := r.getSubject(name, deleted)
+	if err != nil {
+		return version{}, err
+	}
+	versions := sub.Versions
+	if !deleted {
+		versions = sub.active()
+	}
+	if number == "latest" || number == "-1" {
+		return versions[len(versions)-1], nil
+	}
+	n, e := strconv.Atoi(number)
+	if e != nil || n <= 0 {
+		return version{}, newError(INVALID_VERSION, "The specified version '%s' is not a valid version id. Allowed values are between [1, 2^31-1] and the string \"latest\"", number)
+	}
+	for _, v := range versions {
+		if v.Version == n {
+			return v, nil
+		}
+	}
+	return version{}, newError(VERSION_NOT_FOUND, "Version %d not found.", n)
+}
+
+func (r *Registry) getSchemaByID(id string) (registry.Schema, *restError) {
+	n, _ := strconv.Atoi(id)
+	schema, ok := r.state.Schemas[n]
+	if !ok {
+		return registry.Schema{}, newError(SCHEMA_NOT_FOUND, "Schema %s not found", id)
+	}
+	return schema, nil
+}
+
+func readSchema(req *http.Request) (registry.Schema, *restError) {
+	var schema registry.Schema
+	if err := json.NewDecoder(req.Body).Decode(&schema); err != nil {
+		return schema, newError(INVALID_SCHEMA, "Invalid request body: %s", err)
+	}
+	if schema.Value == "" {
+		return schema, newError(INVALID_SCHEMA, "Empty schema")
+	}
+	return schema, nil
+}
+
+// parse parses an Avro schema, resolving its references. Schemas of other types are only checked to be valid JSON.
+// Return the parsed Avro schema (nil for other types) and the key identifying the schema.
+func (r *Registry) parse(schema registry.Schema) (*avro.Schema, string, *restError) {
+	schemaType := schema.SchemaType
+	if schemaType == "" {
+		schemaType = DEFAULT_SCHEMA_TYPE
+	}
+	refs, _ := json.Marshal(schema.References)
+	switch schemaType {
+	case DEFAULT_SCHEMA_TYPE:
+		names := make(map[string]*avro.Schema)
+		if err := r.resolveReferences(schema.References, names); err != nil {
+			return nil, "", err
+		}
+		parsed, err := avro.ParseWithNames(schema.Value, names)
+		if err != nil {
+			return nil, "", newError(INVALID_SCHEMA, "Invalid schema: %s", err)
+		}
+		return parsed, schemaType + "|" + parsed.NormalizedForm() + "|" + string(refs), nil
+	case "JSON":
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, []byte(schema.Value)); err != nil {
+			return nil, "", newError(INVALID_SCHEMA, "Invalid schema: %s", err)
+		}
+		return nil, schemaType + "|" + compact.String() + "|" + string(refs), nil
+	}
+	return nil, "", newError(INVALID_SCHEMA, "Unsupported schema type %s", schemaType)
+}
+
+func (r *Registry) resolveReferences(references []registry.SchemaReference, names map[string]*avro.Schema) *restError {
+	for _, ref := range references {
+		v, err := r.getVersion(ref.Subject, strconv.Itoa(ref.Version), true)
+		if err != nil {
+			return newError(INVALID_SCHEMA, "Invalid reference %s: %s", ref.Name, err.Message)
+		}
+		if err := r.resolveReferences(v.References, names); err != nil {
+			return err
+		}
+		if _, defined := names[ref.Name]; !defined {
+			avro.ParseWithNames(v.Schema, names)
+		}
+	}
+	return nil
+}
+
+func (r *Registry) key(schema registry.Schema) string {
+	_, key, _ := r.parse(schema)
+	return key
+}
+
+func versionSchema(v registry.SchemaVersion) registry.Schema {
+	return registry.Schema{Value: v.Schema, SchemaType: v.SchemaType, References: v.References}
+}
+
+// findVersion returns the active version of the subject having the given schema key, or nil.
+func (r *Registry) findVersion(sub *subject, key string) *registry.SchemaVersion {
+	for _, v := range sub.active() {
+		if r.key(versionSchema(v.SchemaVersion)) == key {
+			return &v.SchemaVersion
+		}
+	}
+	return nil
+}
+
+func (r *Registry) lookupSchema(name string, req *http.Request) (interface{}, *restError) {
+	schema, err := readSchema(req)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := r.getSubject(name, false)
+	if err != nil {
+		return nil, err
+	}
+	_, key, err := r.parse(schema)
+	if err != nil {
+		return nil, err
+	}
+	if v := r.findVersion(sub, key); v != nil {
+		return v, nil
+	}
+	return nil, newError(SCHEMA_NOT_FOUND, "Schema not found")
+}
+
+func (r *Registry) subjectMode(name string) string {
+	if sub, ok := r.state.Subjects[name]; ok && sub.Mode != "" {
+		return sub.Mode
+	}
+	return r.state.Mode
+}
+
+// checkWritable checks that the subject is not in READONLY mode.
+func (r *Registry) checkWritable(name string) *restError {
+	if r.subjectMode(name) == registry.MODE_READONLY {
+		return newError(OPERATION_NOT_PERMITTED, "Subject %s is in read-only mode", name)
+	}
+	return nil
+}
+
+func (r *Registry) register(name string, req *http.Request) (interface{}, *restError) {
+	schema, err := readSchema(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkWritable(name); err != nil {
+		return nil, err
+	}
+	importing := r.subjectMode(name) == registry.MODE_IMPORT
+	if importing && schema.ID == 0 {
+		return nil, newError(OPERATION_NOT_PERMITTED, "Subject %s is in import mode, the schema id must be specified", name)
+	}
+	if !importing && schema.ID > 0 {
+		return nil, newError(OPERATION_NOT_PERMITTED, "Subject %s is not in import mode, the schema id cannot be specified", name)
+	}
+	parsed, key, err := r.parse(schema)
+	if err != nil {
+		return nil, err
+	}
+	sub, ok := r.state.Subjects[name]
+	if !ok {
+		sub = &subject{}
+		r.state.Subjects[name] = sub
+	}
+	if v := r.findVersion(sub, key); v != nil && (schema.ID == 0 || schema.ID == v.ID) {
+		return registry.ID{Value: v.ID}, nil
+	}
+
+	if parsed != nil && !importing {
+		if err := r.checkCompatibility(sub.active(), parsed, r.subjectCompatibility(sub)); err != nil {
+			return nil, err
+		}
+	}
+
+	number := 1
+	if len(sub.Versions) > 0 {
+		number = sub.Versions[len(sub.Versions)-1].Version + 1
+	}
+	if schema.Version > 0 {
+		for _, v := range sub.Versions {
+			if v.Version == schema.Version {
+				return nil, newError(OPERATION_NOT_PERMITTED, "Version %d already exists under subject %s", schema.Version, name)
+			}
+		}
+		number = schema.Version
+	}
+
+	id, exists := r.ids[key]
+	if schema.ID > 0 {
+		if other, used := r.state.Schemas[schema.ID]; used && r.key(other) != key {
+			return nil, newError(OPERATION_NOT_PERMITTED, "Overwrite new schema with id %d is not permitted.", schema.ID)
+		}
+		id = schema.ID
+	} else if !exists {
+		id = r.state.NextID
+	}
+	if id >= r.state.NextID {
+		r.state.NextID = id + 1
+	}
+
+	stored := registry.Schema{Value: schema.Value, SchemaType: schema.SchemaType, References: schema.References}
+	if stored.SchemaType == DEFAULT_SCHEMA_TYPE {
+		stored.SchemaType = ""
+	}
+	if _, used := r.state.Schemas[id]; !used {
+		r.state.Schemas[id] = stored
+	}
+	if !exists {
+		r.ids[key] = id
+	}
+	sub.Versions = append(sub.Versions, version{SchemaVersion: registry.SchemaVersion{
+		Subject:    name,
+		ID:         id,
+		Version:    number,
+		SchemaType: stored.SchemaType,
+		References: stored.References,
+		Schema:     stored.Value,
+	}})
+	sort.Slice(sub.Versions, func(i, j int) bool { return sub.Versions[i].Version < sub.Versions[j].Version })
+	return registry.ID{Value: id}, nil
+}
+
+func (r *Registry) deleteSubject(name string, permanent bool) (interface{}, *restError) {
+	sub, err := r.getSubject(name, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkWritable(name); err != nil {
+		return nil, err
+	}
+	res := []int{}
+	if permanent {
+		if len(sub.active()) > 0 {
+			return nil, newError(SUBJECT_NOT_SOFT_DELETED, "Subject '%s' was not deleted first before being permanently deleted", name)
+		}
+		for _, v := range sub.Versions {
+			res = append(res, v.Version)
+		}
+		delete(r.state.Subjects, name)
+		return res, nil
+	}
+	if len(sub.active()) == 0 {
+		return nil, newError(SUBJECT_SOFT_DELETED, "Subject '%s' was soft deleted. Set permanent=true to delete permanently", name)
+	}
+	for i := range sub.Versions {
+		if !sub.Versions[i].Deleted {
+			sub.Versions[i].Deleted = true
+			res = append(res, sub.Versions[i].Version)
+		}
+	}
+	return res, nil
+}
+
+func (r *Registry) deleteVersion(name string, number string, permanent bool) (interface{}, *restError) {
+	// Deleted versions are looked up as well to report they were already soft deleted, except the latest one.
+	latest := number == "latest" || number == "-1"
+	v, err := r.getVersion(name, number, permanent || !latest)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkWritable(name); err != nil {
+		return nil, err
+	}
+	sub := r.state.Subjects[name]
+	for i := range sub.Versions {
+		if sub.Versions[i].Version != v.Version {
+			continue
+		}
+		switch {
+		case permanent && !v.Deleted:
+			return nil, newError(VERSION_NOT_SOFT_DELETED, "Subject '%s' Version %d was not deleted first before being permanently deleted", name, v.Version)
+		case permanent:
+			sub.Versions = append(sub.Versions[:i], sub.Versions[i+1:]...)
+		case v.Deleted:
+			return nil, newError(VERSION_SOFT_DELETED, "Subject '%s' Version %d was soft deleted. Set permanent=true to delete permanently", name, v.Version)
+		default:
+			sub.Versions[i].Deleted = true
+		}
+		return v.Version, nil
+	}
+	return v.Version, nil
+}
+
+// checkCompatibility checks the schema against the Avro versions of a subject, according to the level.
+func (r *Registry) checkCompatibility(versions []version, schema *avro.Schema, level string) *restError {
+	var previous []*avro.Schema
+	for _, v := range versions {
+		if v.SchemaType != "" && v.SchemaType != DEFAULT_SCHEMA_TYPE {
+			continue
+		}
+		parsed, _, err := r.parse(versionSchema(v.SchemaVersion))
+		if err == nil {
+			previous = append(previous, parsed)
+		}
+	}
+	if len(previous) == 0 {
+		return nil
+	}
+	incompatibilities, e := avro.CheckCompatibility(level, schema, previous)
+	if e != nil {
+		return newError(INTERNAL_SERVER_ERROR, "%s", e)
+	}
+	if len(incompatibilities) > 0 {
+		return newError(INCOMPATIBLE_SCHEMA, "Schema being registered is incompatible with an earlier schema for subject, details: %s", incompatibilities[0].Message)
+	}
+	return nil
+}
+
+func (r *Registry) subjectCompatibility(sub *subject) string {
+	if sub != nil && sub.Compatibility != "" {
+		return sub.Compatibility
+	}
+	return r.state.Compatibility
+}
+
+func (r *Registry) getSubjectCompatibility(name string, defaultToGlobal bool) (registry.CompatibilityLevel, *restError) {
+	sub, ok := r.state.Subjects[name]
+	if ok && sub.Compatibility != "" {
+		return registry.CompatibilityLevel{Value: sub.Compatibility}, nil
+	}
+	if defaultToGlobal {
+		return registry.CompatibilityLevel{Value: r.state.Compatibility}, nil
+	}
+	return registry.CompatibilityLevel{}, newError(SUBJECT_CONFIG_NOT_FOUND, "Subject '%s' does not have subject-level compatibility configured", name)
+}
+
+func (r *Registry) updateCompatibility(name string, req *http.Request) (interface{}, *restError) {
+	var compatibility registry.Compatibility
+	json.NewDecoder(req.Body).Decode(&compatibility)
+	if !contains(avro.CompatibilityLevels, compatibility.Value) {
+		return nil, newError(INVALID_COMPATIBILITY, "Invalid compatibility level. Valid values are %s", strings.Join(avro.CompatibilityLevels, ", "))
+	}
+	if name == "" {
+		r.state.Compatibility = compatibility.Value
+		return compatibility, nil
+	}
+	if err := r.checkWritable(name); err != nil {
+		return nil, err
+	}
+	r.subject(name).Compatibility = compatibility.Value
+	return compatibility, nil
+}
+
+func (r *Registry) deleteSubjectCompatibility(name string) (interface{}, *restError) {
+	sub, ok := r.state.Subjects[name]
+	if !ok || sub.Compatibility == "" {
+		return nil, newError(SUBJECT_CONFIG_NOT_FOUND, "Subject '%s' does not have subject-level compatibility configured", name)
+	}
+	res := registry.Compatibility{Value: sub.Compatibility}
+	sub.Compatibility = ""
+	return res, nil
+}
+
+// subject returns the subject, creating it if needed to hold its configuration.
+func (r *Registry) subject(name string) *subject {
+	sub, ok := r.state.Subjects[name]
+	if !ok {
+		sub = &subject{}
+		r.state.Subjects[name] = sub
+	}
+	return sub
+}
+
+// compatibilityResult is the response of the compatibility endpoint.
+type compatibilityResult struct {
+	IsCompatible bool     `json:"is_compatible"`
+	Messages     []string `json:"messages,omitempty"`
+}
+
+func (r *Registry) testCompatibility(name string, number string, req *http.Request) (interface{}, *restError) {
+	schema, err := readSchema(req)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := r.getVersion(name, number, false)
+	if err != nil {
+		return nil, err
+	}
+	parsed, _, err := r.parse(schema)
+	if err != nil {
+		return nil, err
+	}
+	res := compatibilityResult{IsCompatible: true}
+	if parsed == nil {
+		return res, nil
+	}
+	if err := r.checkCompatibility([]version{previous}, parsed, r.subjectCompatibility(r.state.Subjects[name])); err != nil {
+		res.IsCompatible = false
+		if req.URL.Query().Get("verbose") == "true" {
+			res.Messages = []string{err.Message}
+		}
+	}
+	return res, nil
+}
+
+func (r *Registry) getSubjectMode(name string, defaultToGlobal bool) (registry.Mode, *restError) {
+	sub, ok := r.state.Subjects[name]
+	if ok && sub.Mode != "" {
+		return registry.Mode{Value: sub.Mode}, nil
+	}
+	if defaultToGlobal {
+		return registry.Mode{Value: r.state.Mode}, nil
+	}
+	return registry.Mode{}, newError(SUBJECT_MODE_NOT_FOUND, "Subject '%s' does not have subject-level mode configured", name)
+}
+
+// updateMode sets the mode of the registry, or of a subject. Switching to IMPORT mode requires
+// the registry (or subject) to be empty, unless forced.
+func (r *Registry) updateMode(name string, req *http.Request) (interface{}, *restError) {
+	var mode registry.Mode
+	json.NewDecoder(req.Body).Decode(&mode)
+	if !contains([]string{registry.MODE_READWRITE, registry.MODE_READONLY, registry.MODE_IMPORT}, mode.Value) {
+		return nil, newError(INVALID_MODE, "Invalid mode. Valid values are READWRITE, READONLY, IMPORT")
+	}
+	force := req.URL.Query().Get("force") == "true"
+	if name == "" {
+		if mode.Value == registry.MODE_IMPORT && !force && len(r.listSubjects(false)) > 0 {
+			return nil, newError(OPERATION_NOT_PERMITTED, "Cannot import since found existing subjects")
+		}
+		r.state.Mode = mode.Value
+		return mode, nil
+	}
+	sub := r.subject(name)
+	if mode.Value == registry.MODE_IMPORT && !force && len(sub.active()) > 0 {
+		return nil, newError(OPERATION_NOT_PERMITTED, "Cannot import since found existing subjects")
+	}
+	sub.Mode = mode.Value
+	return mode, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedIDs(schemas map[int]registry.Schema) []int {
+	ids := make([]int, 0, len(schemas))
+	for id := range schemas {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
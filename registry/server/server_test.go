@@ -0,0 +1,259 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	userV1       = `{"type":"record","name":"User","fields":[{"name":"id","type":"long"}]}`
+	userV2       = `{"type":"record","name":"User","fields":[{"name":"id","type":"long"},{"name":"email","type":"string","default":""}]}`
+	userIncompat = `{"type":"record","name":"User","fields":[{"name":"id","type":"string"}]}`
+)
+
+// call sends a request to the registry and returns the status and the raw body of the response.
+func call(t *testing.T, r *Registry, method string, path string, body string) (int, string) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code, strings.TrimSpace(w.Body.String())
+}
+
+// schemaBody returns the body registering the given schema.
+func schemaBody(schema string) string {
+	content, _ := json.Marshal(map[string]string{"schema": schema})
+	return string(content)
+}
+
+func expect(t *testing.T, r *Registry, method string, path string, body string, status int, response string) {
+	t.Helper()
+	code, res := call(t, r, method, path, body)
+	if code != status || res != response {
+		t.Errorf("%s %s: got %d %s, expected %d %s", method, path, code, res, status, response)
+	}
+}
+
+// expectError checks the status and the error code of a failed request.
+func expectError(t *testing.T, r *Registry, method string, path string, body string, errorCode int) {
+	t.Helper()
+	code, res := call(t, r, method, path, body)
+	var err restError
+	json.Unmarshal([]byte(res), &err)
+	if err.Code != errorCode || code != err.status() {
+		t.Errorf("%s %s: got %d %s, expected error code %d", method, path, code, res, errorCode)
+	}
+}
+
+func newRegistry(t *testing.T, dir string) *Registry {
+	r, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestRegisterVersionsAndIDs(t *testing.T) {
+	r := newRegistry(t, "")
+	expect(t, r, "POST", "/subjects/users-value/versions", schemaBody(userV1), 200, `{"id":1}`)
+	expect(t, r, "POST", "/subjects/users-value/versions", schemaBody(userV1), 200, `{"id":1}`)
+	expect(t, r, "POST", "/subjects/users-value/versions", schemaBody(userV2), 200, `{"id":2}`)
+	// The same schema under another subject keeps its id.
+	expect(t, r, "POST", "/subjects/other-value/versions", schemaBody(userV1), 200, `{"id":1}`)
+
+	expect(t, r, "GET", "/subjects", "", 200, `["other-value","users-value"]`)
+	expect(t, r, "GET", "/subjects/users-value/versions", "", 200, `[1,2]`)
+	expect(t, r, "GET", "/subjects/users-value/versions/latest/schema", "", 200, userV2)
+	expect(t, r, "GET", "/schemas/ids/2/schema", "", 200, userV2)
+	expect(t, r, "GET", "/schemas/types", "", 200, `["AVRO","JSON"]`)
+
+	code, res := call(t, r, "GET", "/subjects/users-value/versions/1", "")
+	if code != 200 || !strings.Contains(res, `"subject":"users-value","id":1,"version":1`) {
+		t.Errorf("unexpected version: %d %s", code, res)
+	}
+	code, res = call(t, r, "POST", "/subjects/users-value", schemaBody(`{"type": "record", "name": "User", "fields": [{"name": "id", "type": "long"}]}`))
+	if code != 200 || !strings.Contains(res, `"id":1,"version":1`) {
+		t.Errorf("lookup should ignore the formatting of the schema: %d %s", code, res)
+	}
+
+	expectError(t, r, "GET", "/subjects/unknown/versions", "", SUBJECT_NOT_FOUND)
+	expectError(t, r, "GET", "/subjects/users-value/versions/3", "", VERSION_NOT_FOUND)
+	expectError(t, r, "GET", "/subjects/users-value/versions/first", "", INVALID_VERSION)
+	expectError(t, r, "GET", "/schemas/ids/42", "", SCHEMA_NOT_FOUND)
+	expectError(t, r, "POST", "/subjects/users-value", schemaBody(userIncompat), SCHEMA_NOT_FOUND)
+	expectError(t, r, "POST", "/subjects/users-value/versions", schemaBody(`{"type":"record"`), INVALID_SCHEMA)
+	expectError(t, r, "POST", "/subjects/users-value/versions", `{}`, INVALID_SCHEMA)
+	expectError(t, r, "GET", "/unknown", "", http.StatusNotFound)
+}
+
+func TestEscapedSubjectNames(t *testing.T) {
+	r := newRegistry(t, "")
+	expect(t, r, "POST", "/subjects/a%2Fb/versions", schemaBody(userV1), 200, `{"id":1}`)
+	expect(t, r, "GET", "/subjects", "", 200, `["a/b"]`)
+	expect(t, r, "GET", "/subjects/a%2Fb/versions", "", 200, `[1]`)
+}
+
+func TestReferencesAndJsonSchemas(t *testing.T) {
+	r := newRegistry(t, "")
+	expect(t, r, "POST", "/subjects/address/versions", schemaBody(`{"type":"record","name":"Address","fields":[{"name":"city","type":"string"}]}`), 200, `{"id":1}`)
+
+	body := `{"schema":"{\"type\":\"record\",\"name\":\"User\",\"fields\":[{\"name\":\"address\",\"type\":\"Address\"}]}",` +
+		`"references":[{"name":"Address","subject":"address","version":1}]}`
+	expect(t, r, "POST", "/subjects/users-value/versions", body, 200, `{"id":2}`)
+	expectError(t, r, "POST", "/subjects/users-value/versions", strings.Replace(body, `"version":1`, `"version":2`, 1), INVALID_SCHEMA)
+
+	expect(t, r, "POST", "/subjects/json-value/versions", `{"schemaType":"JSON","schema":"{\"type\": \"object\"}"}`, 200, `{"id":3}`)
+	expect(t, r, "POST", "/subjects/json-value/versions", `{"schemaType":"JSON","schema":"{\"type\":\"object\"}"}`, 200, `{"id":3}`)
+	expectError(t, r, "POST", "/subjects/json-value/versions", `{"schemaType":"JSON","schema":"{"}`, INVALID_SCHEMA)
+	expectError(t, r, "POST", "/subjects/proto-value/versions", `{"schemaType":"PROTOBUF","schema":"syntax = \"proto3\";"}`, INVALID_SCHEMA)
+}
+
+func TestCompatibility(t *testing.T) {
+	r := newRegistry(t, "")
+	expect(t, r, "POST", "/subjects/users-value/versions", schemaBody(userV1), 200, `{"id":1}`)
+	expectError(t, r, "POST", "/subjects/users-value/versions", schemaBody(userIncompat), INCOMPATIBLE_SCHEMA)
+
+	expect(t, r, "POST", "/compatibility/subjects/users-value/versions/latest", schemaBody(userV2), 200, `{"is_compatible":true}`)
+	code, res := call(t, r, "POST", "/compatibility/subjects/users-value/versions/latest?verbose=true", schemaBody(userIncompat))
+	if code != 200 || !strings.HasPrefix(res, `{"is_compatible":false,"messages":[`) {
+		t.Errorf("unexpected compatibility result: %d %s", code, res)
+	}
+
+	expect(t, r, "GET", "/config", "", 200, `{"compatibilityLevel":"BACKWARD"}`)
+	expectError(t, r, "GET", "/config/users-value", "", SUBJECT_CONFIG_NOT_FOUND)
+	expect(t, r, "GET", "/config/users-value?defaultToGlobal=true", "", 200, `{"compatibilityLevel":"BACKWARD"}`)
+	expectError(t, r, "PUT", "/config/users-value", `{"compatibility":"SOMETIMES"}`, INVALID_COMPATIBILITY)
+
+	expect(t, r, "PUT", "/config/users-value", `{"compatibility":"NONE"}`, 200, `{"compatibility":"NONE"}`)
+	expect(t, r, "GET", "/config/users-value", "", 200, `{"compatibilityLevel":"NONE"}`)
+	expect(t, r, "POST", "/subjects/users-value/versions", schemaBody(userIncompat), 200, `{"id":2}`)
+
+	expect(t, r, "DELETE", "/config/users-value", "", 200, `{"compatibility":"NONE"}`)
+	expectError(t, r, "DELETE", "/config/users-value", "", SUBJECT_CONFIG_NOT_FOUND)
+	expect(t, r, "PUT", "/config", `{"compatibility":"FULL"}`, 200, `{"compatibility":"FULL"}`)
+	expect(t, r, "GET", "/config/users-value?defaultToGlobal=true", "", 200, `{"compatibilityLevel":"FULL"}`)
+}
+
+func TestSoftAndPermanentDeletes(t *testing.T) {
+	r := newRegistry(t, "")
+	expect(t, r, "POST", "/subjects/users-value/versions", schemaBody(userV1), 200, `{"id":1}`)
+	expect(t, r, "POST", "/subjects/users-value/versions", schemaBody(userV2), 200, `{"id":2}`)
+
+	expectError(t, r, "DELETE", "/subjects/users-value/versions/1?permanent=true", "", VERSION_NOT_SOFT_DELETED)
+	expect(t, r, "DELETE", "/subjects/users-value/versions/1", "", 200, `1`)
+	expectError(t, r, "DELETE", "/subjects/users-value/versions/1", "", VERSION_SOFT_DELETED)
+	expect(t, r, "GET", "/subjects/users-value/versions", "", 200, `[2]`)
+	expect(t, r, "GET", "/subjects/users-value/versions?deleted=true", "", 200, `[1,2]`)
+	expectError(t, r, "GET", "/subjects/users-value/versions/1", "", VERSION_NOT_FOUND)
+	expect(t, r, "DELETE", "/subjects/users-value/versions/1?permanent=true", "", 200, `1`)
+	expect(t, r, "GET", "/subjects/users-value/versions?deleted=true", "", 200, `[2]`)
+
+	expectError(t, r, "DELETE", "/subjects/users-value?permanent=true", "", SUBJECT_NOT_SOFT_DELETED)
+	expect(t, r, "DELETE", "/subjects/users-value", "", 200, `[2]`)
+	expectError(t, r, "DELETE", "/subjects/users-value", "", SUBJECT_SOFT_DELETED)
+	expect(t, r, "GET", "/subjects", "", 200, `[]`)
+	expect(t, r, "GET", "/subjects?deleted=true", "", 200, `["users-value"]`)
+	expectError(t, r, "POST", "/subjects/users-value", schemaBody(userV2), SUBJECT_NOT_FOUND)
+
+	// Registering a soft deleted schema again creates a new version with the same id.
+	expect(t, r, "POST", "/subjects/users-value/versions", schemaBody(userV2), 200, `{"id":2}`)
+	expect(t, r, "GET", "/subjects/users-value/versions", "", 200, `[3]`)
+	expect(t, r, "DELETE", "/subjects/users-value", "", 200, `[3]`)
+	expect(t, r, "DELETE", "/subjects/users-value?permanent=true", "", 200, `[2,3]`)
+	expect(t, r, "GET", "/subjects?deleted=true", "", 200, `[]`)
+	// Schemas are never deleted.
+	expect(t, r, "GET", "/schemas/ids/1/schema", "", 200, userV1)
+}
+
+func TestModes(t *testing.T) {
+	r := newRegistry(t, "")
+	expect(t, r, "GET", "/mode", "", 200, `{"mode":"READWRITE"}`)
+	expectError(t, r, "PUT", "/mode", `{"mode":"WRITEONLY"}`, INVALID_MODE)
+	expectError(t, r, "POST", "/subjects/users-value/versions", `{"schema":`+jsonString(userV1)+`,"id":10}`, OPERATION_NOT_PERMITTED)
+
+	expect(t, r, "PUT", "/mode/users-value", `{"mode":"READONLY"}`, 200, `{"mode":"READONLY"}`)
+	expect(t, r, "GET", "/mode/users-value", "", 200, `{"mode":"READONLY"}`)
+	expectError(t, r, "GET", "/mode/other-value", "", SUBJECT_MODE_NOT_FOUND)
+	expect(t, r, "GET", "/mode/other-value?defaultToGlobal=true", "", 200, `{"mode":"READWRITE"}`)
+	expectError(t, r, "POST", "/subjects/users-value/versions", schemaBody(userV1), OPERATION_NOT_PERMITTED)
+	expectError(t, r, "PUT", "/config/users-value", `{"compatibility":"NONE"}`, OPERATION_NOT_PERMITTED)
+	expect(t, r, "POST", "/subjects/other-value/versions", schemaBody(userV1), 200, `{"id":1}`)
+
+	// IMPORT mode requires an empty registry, unless forced.
+	expectError(t, r, "PUT", "/mode", `{"mode":"IMPORT"}`, OPERATION_NOT_PERMITTED)
+	expect(t, r, "PUT", "/mode?force=true", `{"mode":"IMPORT"}`, 200, `{"mode":"IMPORT"}`)
+	expectError(t, r, "POST", "/subjects/imported-value/versions", schemaBody(userV2), OPERATION_NOT_PERMITTED)
+	expect(t, r, "POST", "/subjects/imported-value/versions", `{"schema":`+jsonString(userIncompat)+`,"id":10,"version":5}`, 200, `{"id":10}`)
+	expect(t, r, "POST", "/subjects/imported-value/versions", `{"schema":`+jsonString(userV1)+`,"id":11,"version":6}`, 200, `{"id":11}`)
+	expectError(t, r, "POST", "/subjects/imported-value/versions", `{"schema":`+jsonString(userV2)+`,"id":10}`, OPERATION_NOT_PERMITTED)
+	expectError(t, r, "POST", "/subjects/imported-value/versions", `{"schema":`+jsonString(userV2)+`,"id":12,"version":5}`, OPERATION_NOT_PERMITTED)
+	expect(t, r, "GET", "/subjects/imported-value/versions", "", 200, `[5,6]`)
+
+	// New ids are allocated after the imported ones.
+	expect(t, r, "PUT", "/mode", `{"mode":"READWRITE"}`, 200, `{"mode":"READWRITE"}`)
+	expect(t, r, "POST", "/subjects/new-value/versions", schemaBody(userV2), 200, `{"id":12}`)
+}
+
+func jsonString(value string) string {
+	content, _ := json.Marshal(value)
+	return string(content)
+}
+
+func TestPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := newRegistry(t, dir)
+	expect(t, r, "POST", "/subjects/users-value/versions", schemaBody(userV1), 200, `{"id":1}`)
+	expect(t, r, "POST", "/subjects/users-value/versions", schemaBody(userV2), 200, `{"id":2}`)
+	expect(t, r, "DELETE", "/subjects/users-value/versions/1", "", 200, `1`)
+	expect(t, r, "PUT", "/config/users-value", `{"compatibility":"FORWARD"}`, 200, `{"compatibility":"FORWARD"}`)
+	if _, err := os.Stat(filepath.Join(dir, DATA_FILE+".tmp")); !os.IsNotExist(err) {
+		t.Errorf("temporary data file should have been renamed: %v", err)
+	}
+
+	reloaded := newRegistry(t, dir)
+	if !reflect.DeepEqual(reloaded.state, r.state) {
+		t.Errorf("reloaded state differs:\n%+v\n%+v", reloaded.state, r.state)
+	}
+	expect(t, reloaded, "GET", "/subjects/users-value/versions?deleted=true", "", 200, `[1,2]`)
+	expect(t, reloaded, "GET", "/config/users-value", "", 200, `{"compatibilityLevel":"FORWARD"}`)
+	// Known schemas keep their id, new ones get the next one.
+	expect(t, reloaded, "POST", "/subjects/other-value/versions", schemaBody(userV1), 200, `{"id":1}`)
+	expectError(t, reloaded, "POST", "/subjects/other-value/versions", schemaBody(userIncompat), INCOMPATIBLE_SCHEMA)
+	expect(t, reloaded, "POST", "/subjects/fresh-value/versions", schemaBody(userIncompat), 200, `{"id":3}`)
+}
+
+func TestInvalidDataFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, DATA_FILE), []byte("{"), 0644)
+	if _, err := New(dir); err == nil || !strings.Contains(err.Error(), "invalid data file") {
+		t.Errorf("expected an invalid data file error, got %v", err)
+	}
+}